@@ -55,6 +55,11 @@ type Remote struct {
 	// hostname of the remote backend server
 	hostname string
 
+	// serviceURL and apiToken are the URL and token that the client uses
+	// to access the remote backend API, for requests it has no method for.
+	serviceURL *url.URL
+	apiToken   string
+
 	// organization is the organization that contains the target workspaces
 	organization string
 
@@ -234,6 +239,8 @@ func (b *Remote) Configure(obj cty.Value) tfdiags.Diagnostics {
 		}
 	}
 
+	b.serviceURL, b.apiToken = service, token
+
 	cfg := &tfe.Config{
 		Address:  service.String(),
 		BasePath: service.Path,
@@ -0,0 +1,60 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform/version"
+)
+
+// RunPlanJSON returns the JSON representation of the plan of the run with
+// the given ID, as produced by the remote backend once the plan has
+// finished. The representation is in the format of the version of
+// Terraform that the run used, which may be newer than this one.
+func (b *Remote) RunPlanJSON(ctx context.Context, runID string) ([]byte, error) {
+	r, err := b.client.Runs.Read(ctx, runID)
+	if err != nil {
+		return nil, generalError(fmt.Sprintf("Failed to read run %s", runID), err)
+	}
+	if r.Plan == nil {
+		return nil, fmt.Errorf("Run %s has no plan.", runID)
+	}
+	if r.Plan.Status != tfe.PlanFinished {
+		return nil, fmt.Errorf("The plan of run %s is %s, so there is no plan to show yet.", runID, r.Plan.Status)
+	}
+
+	// The client has no method for the JSON output of a plan, so it is
+	// requested directly, in the same way as the client would.
+	u := *b.serviceURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/plans/" + url.PathEscape(r.Plan.ID) + "/json-output"
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+b.apiToken)
+	req.Header.Set(version.Header, version.Version)
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		return nil, generalError(fmt.Sprintf("Failed to download the plan of run %s", runID), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, generalError(fmt.Sprintf("Failed to download the plan of run %s", runID), tfe.ErrResourceNotFound)
+	case http.StatusUnauthorized:
+		return nil, generalError(fmt.Sprintf("Failed to download the plan of run %s", runID), tfe.ErrUnauthorized)
+	default:
+		return nil, generalError(fmt.Sprintf("Failed to download the plan of run %s", runID), fmt.Errorf("unexpected response %s", resp.Status))
+	}
+}
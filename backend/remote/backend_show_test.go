@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+func TestRemote_runPlanJSON(t *testing.T) {
+	b := testBackendDefault(t)
+
+	const planJSON = `{"format_version":"0.1","resource_changes":[]}`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testCred {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v2/plans/plan-finished/json-output" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, planJSON)
+	}))
+	defer s.Close()
+	serviceURL, err := url.Parse(s.URL + "/api/v2/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.serviceURL = serviceURL
+
+	runs := b.client.Runs.(*mockRuns)
+	runs.runs["run-finished"] = &tfe.Run{
+		ID:     "run-finished",
+		Plan:   &tfe.Plan{ID: "plan-finished", Status: tfe.PlanFinished},
+		Status: tfe.RunPlanned,
+	}
+	runs.runs["run-running"] = &tfe.Run{
+		ID:     "run-running",
+		Plan:   &tfe.Plan{ID: "plan-running", Status: tfe.PlanRunning},
+		Status: tfe.RunPlanning,
+	}
+	runs.runs["run-missing"] = &tfe.Run{
+		ID:     "run-missing",
+		Plan:   &tfe.Plan{ID: "plan-missing", Status: tfe.PlanFinished},
+		Status: tfe.RunPlanned,
+	}
+
+	ctx := context.Background()
+	got, err := b.RunPlanJSON(ctx, "run-finished")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got) != planJSON {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, planJSON)
+	}
+
+	tests := map[string]string{
+		"run-unknown": "Failed to read run run-unknown: resource not found",
+		"run-running": "The plan of run run-running is running",
+		"run-missing": "Failed to download the plan of run run-missing: resource not found",
+	}
+	for runID, want := range tests {
+		t.Run(runID, func(t *testing.T) {
+			_, err := b.RunPlanJSON(ctx, runID)
+			if err == nil {
+				t.Fatal("succeeded; want error")
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package addrfilter implements methods for selecting the resources of a
+// state or plan by address, so that each machine-readable representation of
+// them can be pruned in the same way
+package addrfilter
@@ -0,0 +1,109 @@
+package addrfilter
+
+import (
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+// Filter selects resource instances by whether any of a set of target
+// addresses contains them, with the same meaning of containment as the
+// -target option of terraform plan. A module address selects every resource
+// instance within that module and its descendents, a resource address
+// selects every instance of that resource, and a resource instance address
+// selects just that instance.
+//
+// A nil *Filter selects everything, so that callers don't need to treat an
+// absent filter specially.
+type Filter struct {
+	targets []addrs.Targetable
+}
+
+// New returns a filter selecting the resource instances contained by any of
+// the given targets, or nil if there are no targets.
+func New(targets []addrs.Targetable) *Filter {
+	if len(targets) == 0 {
+		return nil
+	}
+	return &Filter{targets: targets}
+}
+
+// Targets returns the target addresses of the filter, or nil if the filter
+// is nil.
+func (f *Filter) Targets() []addrs.Targetable {
+	if f == nil {
+		return nil
+	}
+	return f.targets
+}
+
+// Matches returns true if the given resource instance is selected.
+func (f *Filter) Matches(addr addrs.AbsResourceInstance) bool {
+	if f == nil {
+		return true
+	}
+	for _, target := range f.targets {
+		if target.TargetContains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns a new state containing only the selected resource
+// instances of the given state, with all of their objects. The output values
+// are not included, since they can't be targeted. If the filter is nil, the
+// given state is returned unchanged.
+//
+// The result shares its resource instance objects with the given state, so
+// neither should be modified afterwards.
+func (f *Filter) State(s *states.State) *states.State {
+	if f == nil || s == nil {
+		return s
+	}
+
+	ret := states.NewState()
+	for _, ms := range s.Modules {
+		for key, rs := range ms.Resources {
+			for instKey, is := range rs.Instances {
+				if !f.Matches(rs.Addr.Instance(instKey).Absolute(ms.Addr)) {
+					continue
+				}
+
+				retMs := ret.EnsureModule(ms.Addr)
+				retRs := retMs.Resources[key]
+				if retRs == nil {
+					retRs = &states.Resource{
+						Addr:           rs.Addr,
+						EachMode:       rs.EachMode,
+						Instances:      make(map[addrs.InstanceKey]*states.ResourceInstance),
+						ProviderConfig: rs.ProviderConfig,
+					}
+					retMs.Resources[key] = retRs
+				}
+				retRs.Instances[instKey] = is
+			}
+		}
+	}
+	return ret
+}
+
+// Changes returns new changes containing only the changes to the selected
+// resource instances from the given changes, in the same order. The output
+// value changes are all kept, since they can't be targeted. If the filter
+// is nil, the given changes are returned unchanged.
+func (f *Filter) Changes(changes *plans.Changes) *plans.Changes {
+	if f == nil || changes == nil {
+		return changes
+	}
+
+	ret := &plans.Changes{
+		Outputs: changes.Outputs,
+	}
+	for _, rc := range changes.Resources {
+		if f.Matches(rc.Addr) {
+			ret.Resources = append(ret.Resources, rc)
+		}
+	}
+	return ret
+}
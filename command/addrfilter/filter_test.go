@@ -0,0 +1,150 @@
+package addrfilter
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	f := testFilter(t, "module.a", "test_thing.foo", "test_thing.bar[1]")
+
+	tests := map[string]bool{
+		"module.a.test_thing.baz":          true,
+		"module.a.module.b.test_thing.baz": true,
+		"module.ab.test_thing.baz":         false,
+		"test_thing.foo":                   true,
+		`test_thing.foo["x"]`:              true,
+		"module.b.test_thing.foo":          false,
+		"test_thing.bar[1]":                true,
+		"test_thing.bar[0]":                false,
+		"data.test_thing.foo":              false,
+	}
+	for addr, want := range tests {
+		if got := f.Matches(testInstanceAddr(t, addr)); got != want {
+			t.Errorf("wrong result for %s: %t; want %t", addr, got, want)
+		}
+	}
+
+	var nilFilter *Filter
+	if !nilFilter.Matches(testInstanceAddr(t, "test_thing.baz")) {
+		t.Errorf("nil filter doesn't match everything")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if f := New(nil); f != nil {
+		t.Errorf("filter without targets is %#v; want nil", f)
+	}
+}
+
+func TestFilter_State(t *testing.T) {
+	s := states.BuildState(func(s *states.SyncState) {
+		for _, addr := range []string{"test_thing.foo[0]", "test_thing.foo[1]", "test_thing.bar", "module.a.test_thing.foo"} {
+			s.SetResourceInstanceCurrent(
+				testInstanceAddr(t, addr),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{}`),
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		s.SetResourceInstanceDeposed(
+			testInstanceAddr(t, "test_thing.foo[1]"),
+			states.DeposedKey("deadbeef"),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetOutputValue(addrs.OutputValue{Name: "foo"}.Absolute(addrs.RootModuleInstance), cty.StringVal("bar"), false)
+	})
+
+	got := testFilter(t, "test_thing.foo[1]", "module.a").State(s)
+	var gotAddrs []string
+	for _, ms := range got.Modules {
+		for _, rs := range ms.Resources {
+			for key, is := range rs.Instances {
+				gotAddrs = append(gotAddrs, rs.Addr.Instance(key).Absolute(ms.Addr).String())
+				if len(is.Deposed) != len(s.ResourceInstance(rs.Addr.Instance(key).Absolute(ms.Addr)).Deposed) {
+					t.Errorf("wrong deposed objects for %s", rs.Addr.Instance(key).Absolute(ms.Addr))
+				}
+			}
+		}
+	}
+	sort.Strings(gotAddrs)
+	if want := []string{"module.a.test_thing.foo", "test_thing.foo[1]"}; !reflect.DeepEqual(gotAddrs, want) {
+		t.Errorf("wrong instances\ngot:  %#v\nwant: %#v", gotAddrs, want)
+	}
+	if len(got.RootModule().OutputValues) != 0 {
+		t.Errorf("output values were kept")
+	}
+	if got.ResourceInstance(testInstanceAddr(t, "test_thing.foo[1]")).Current == nil {
+		t.Errorf("current object of test_thing.foo[1] is missing")
+	}
+
+	var nilFilter *Filter
+	if got := nilFilter.State(s); got != s {
+		t.Errorf("nil filter changed the state")
+	}
+}
+
+func TestFilter_Changes(t *testing.T) {
+	changes := &plans.Changes{
+		Outputs: []*plans.OutputChangeSrc{
+			{Addr: addrs.OutputValue{Name: "foo"}.Absolute(addrs.RootModuleInstance)},
+		},
+	}
+	for _, addr := range []string{"test_thing.foo", "test_thing.bar", "module.a.test_thing.foo", "test_thing.baz"} {
+		changes.Resources = append(changes.Resources, &plans.ResourceInstanceChangeSrc{
+			Addr: testInstanceAddr(t, addr),
+		})
+	}
+
+	got := testFilter(t, "test_thing.baz", "test_thing.foo").Changes(changes)
+	var gotAddrs []string
+	for _, rc := range got.Resources {
+		gotAddrs = append(gotAddrs, rc.Addr.String())
+	}
+	if want := []string{"test_thing.foo", "test_thing.baz"}; !reflect.DeepEqual(gotAddrs, want) {
+		t.Errorf("wrong changes\ngot:  %#v\nwant: %#v", gotAddrs, want)
+	}
+	if !reflect.DeepEqual(got.Outputs, changes.Outputs) {
+		t.Errorf("output changes were not kept")
+	}
+
+	var nilFilter *Filter
+	if got := nilFilter.Changes(changes); got != changes {
+		t.Errorf("nil filter changed the changes")
+	}
+}
+
+func testFilter(t *testing.T, targets ...string) *Filter {
+	t.Helper()
+	var ts []addrs.Targetable
+	for _, raw := range targets {
+		target, diags := addrs.ParseTargetStr(raw)
+		if diags.HasErrors() {
+			t.Fatal(diags.Err())
+		}
+		ts = append(ts, target.Subject)
+	}
+	return New(ts)
+}
+
+func testInstanceAddr(t *testing.T, raw string) addrs.AbsResourceInstance {
+	t.Helper()
+	addr, diags := addrs.ParseAbsResourceInstanceStr(raw)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	return addr
+}
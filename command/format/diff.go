@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mitchellh/colorstring"
 	"github.com/zclconf/go-cty/cty"
@@ -139,6 +140,10 @@ type blockBodyDiffPrinter struct {
 	color           *colorstring.Colorize
 	action          plans.Action
 	requiredReplace cty.PathSet
+
+	// maxValueLen, if greater than zero, is the length in bytes beyond
+	// which writeValue truncates string values.
+	maxValueLen int
 }
 
 const forcesNewResourceCaption = " [red]# forces replacement[reset]"
@@ -425,6 +430,10 @@ func (p *blockBodyDiffPrinter) writeValue(val cty.Value, action plans.Action, in
 	case ty.IsPrimitiveType():
 		switch ty {
 		case cty.String:
+			if str := val.AsString(); p.maxValueLen > 0 && len(str) > p.maxValueLen {
+				fmt.Fprintf(p.buf, "%q… (truncated, %d bytes)", truncateString(str, p.maxValueLen), len(str))
+				break
+			}
 			{
 				// Special behavior for JSON strings containing array or object
 				src := []byte(val.AsString())
@@ -846,6 +855,18 @@ func (p *blockBodyDiffPrinter) pathForcesNewResource(path cty.Path) bool {
 	return p.requiredReplace.Has(path)
 }
 
+// truncateString returns the longest prefix of the given string that is at
+// most n bytes long and doesn't split a UTF-8 encoded character.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 func ctyGetAttrMaybeNull(val cty.Value, name string) cty.Value {
 	if val.IsNull() {
 		ty := val.Type().AttributeType(name)
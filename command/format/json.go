@@ -0,0 +1,96 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitchellh/colorstring"
+)
+
+// JSON returns the given JSON document indented with two spaces per level,
+// for display to a human.
+//
+// Unless color is disabled, object keys, strings, numbers and the literals
+// true, false and null are each highlighted in their own color. The
+// document's own content is never interpreted as color codes.
+func JSON(src []byte, color *colorstring.Colorize) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, src, "", "  "); err != nil {
+		return "", err
+	}
+	if color == nil || color.Disable {
+		return buf.String(), nil
+	}
+
+	indented := buf.Bytes()
+	var ret bytes.Buffer
+	for i := 0; i < len(indented); {
+		b := indented[i]
+		switch {
+		case b == '"':
+			end := jsonStringEnd(indented, i)
+			colorName := "green"
+			if jsonIsKey(indented, end) {
+				colorName = "bold"
+			}
+			writeColored(&ret, color, colorName, indented[i:end])
+			i = end
+		case b == '-' || (b >= '0' && b <= '9'):
+			end := i + 1
+			for end < len(indented) && bytes.IndexByte([]byte("0123456789.eE+-"), indented[end]) >= 0 {
+				end++
+			}
+			writeColored(&ret, color, "cyan", indented[i:end])
+			i = end
+		case b == 't' || b == 'f' || b == 'n':
+			end := i + 1
+			for end < len(indented) && indented[end] >= 'a' && indented[end] <= 'z' {
+				end++
+			}
+			writeColored(&ret, color, "yellow", indented[i:end])
+			i = end
+		default:
+			ret.WriteByte(b)
+			i++
+		}
+	}
+	return ret.String(), nil
+}
+
+// jsonStringEnd returns the offset just after the closing quote of the JSON
+// string starting at offset start.
+func jsonStringEnd(src []byte, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '"':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// jsonIsKey returns true if the JSON string ending just before offset end is
+// an object key, which is to say that it is followed by a colon.
+func jsonIsKey(src []byte, end int) bool {
+	rest := bytes.TrimLeft(src[end:], " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
+
+// writeColored writes the given token to buf wrapped in the escape sequences
+// for the named color. The color codes are written directly rather than via
+// color.Color so that brackets within the token are not taken as codes.
+func writeColored(buf *bytes.Buffer, color *colorstring.Colorize, name string, token []byte) {
+	code, ok := color.Colors[name]
+	if !ok {
+		buf.Write(token)
+		return
+	}
+	fmt.Fprintf(buf, "\033[%sm", code)
+	buf.Write(token)
+	if reset, ok := color.Colors["reset"]; ok {
+		fmt.Fprintf(buf, "\033[%sm", reset)
+	}
+}
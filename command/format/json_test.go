@@ -0,0 +1,78 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/mitchellh/colorstring"
+)
+
+func TestJSON(t *testing.T) {
+	src := []byte(`{"a[bold]":["x[red]",1.5,true,null],"b":{}}`)
+
+	tests := map[string]struct {
+		color *colorstring.Colorize
+		want  string
+	}{
+		"no color": {
+			&colorstring.Colorize{
+				Colors:  colorstring.DefaultColors,
+				Disable: true,
+			},
+			`{
+  "a[bold]": [
+    "x[red]",
+    1.5,
+    true,
+    null
+  ],
+  "b": {}
+}`,
+		},
+		"color": {
+			&colorstring.Colorize{
+				Colors: colorstring.DefaultColors,
+			},
+			"{\n" +
+				"  \033[1m\"a[bold]\"\033[0m: [\n" +
+				"    \033[32m\"x[red]\"\033[0m,\n" +
+				"    \033[36m1.5\033[0m,\n" +
+				"    \033[33mtrue\033[0m,\n" +
+				"    \033[33mnull\033[0m\n" +
+				"  ],\n" +
+				"  \033[1m\"b\"\033[0m: {}\n" +
+				"}",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := JSON(src, tc.color)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSON_escapedQuote(t *testing.T) {
+	color := &colorstring.Colorize{
+		Colors: colorstring.DefaultColors,
+	}
+	got, err := JSON([]byte(`["a\"b",1]`), color)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := "[\n  \033[32m\"a\\\"b\"\033[0m,\n  \033[36m1\033[0m\n]"
+	if got != want {
+		t.Errorf("wrong result\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestJSON_invalid(t *testing.T) {
+	if _, err := JSON([]byte(`{`), nil); err == nil {
+		t.Fatal("succeeded; want error")
+	}
+}
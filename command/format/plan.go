@@ -8,8 +8,11 @@ import (
 	"strings"
 
 	"github.com/mitchellh/colorstring"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/terraform"
@@ -23,6 +26,22 @@ import (
 // there only to clean up the state).
 type Plan struct {
 	Resources []*InstanceDiff
+
+	// Outputs describes the changes to the root module output values, in
+	// order of name.
+	Outputs []*OutputDiff
+
+	// GroupByModule, if set, causes Format to render the resource diffs
+	// under a header for each module, with the root module resources under
+	// a "Root module" header. By default the diffs are rendered as a single
+	// flat list.
+	GroupByModule bool
+
+	// ShowUnchanged, if set, causes Format to render the unchanged
+	// attributes of updated and replaced instances along with the changed
+	// ones. By default each run of consecutive unchanged attributes is
+	// collapsed into a single line counting them, as "terraform plan" does.
+	ShowUnchanged bool
 }
 
 // InstanceDiff is a representation of an instance diff optimized
@@ -31,13 +50,20 @@ type InstanceDiff struct {
 	Addr   *terraform.ResourceAddress
 	Action terraform.DiffChangeType
 
-	// Attributes describes changes to the attributes of the instance.
+	// Attributes describes changes to the attributes of the instance, in
+	// order of their paths. For update and replacement diffs it also
+	// includes the attributes that aren't changing, with the action
+	// terraform.DiffNone, so that they can be shown for context.
 	//
 	// For destroy diffs this is always nil.
 	Attributes []*AttributeDiff
 
 	Tainted bool
 	Deposed bool
+
+	// CreateBeforeDestroy is set for a DiffDestroyCreate action where the
+	// replacement object is created before the existing one is destroyed.
+	CreateBeforeDestroy bool
 }
 
 // AttributeDiff is a representation of an attribute diff optimized
@@ -57,13 +83,36 @@ type AttributeDiff struct {
 	ForcesNew   bool
 }
 
+// OutputDiff is a representation of a change to a root module output value
+// optimized for display, in conjunction with DisplayPlan.
+type OutputDiff struct {
+	Name   string
+	Action terraform.DiffChangeType
+
+	// OldValue and NewValue are the values in JSON syntax, or empty if there
+	// is no value or if the value isn't yet known.
+	OldValue string
+	NewValue string
+
+	NewComputed bool
+	Sensitive   bool
+}
+
 // PlanStats gives summary counts for a Plan.
 type PlanStats struct {
 	ToAdd, ToChange, ToDestroy int
 }
 
 // NewPlan produces a display-oriented Plan from a terraform.Plan.
-func NewPlan(changes *plans.Changes) *Plan {
+//
+// If schemas is not nil, each instance diff other than a destroy includes
+// the changes to its attributes, with the values of any attributes that the
+// schemas mark as sensitive redacted. Otherwise, only the instances and
+// their actions are included.
+//
+// The changes to root module output values are included regardless of
+// schemas, since they don't need them.
+func NewPlan(changes *plans.Changes, schemas *terraform.Schemas) *Plan {
 	log.Printf("[TRACE] NewPlan for %#v", changes)
 	ret := &Plan{}
 	if changes == nil {
@@ -107,8 +156,11 @@ func NewPlan(changes *plans.Changes) *Plan {
 			did.Action = terraform.DiffRefresh
 		case plans.Delete:
 			did.Action = terraform.DiffDestroy
-		case plans.DeleteThenCreate, plans.CreateThenDelete:
+		case plans.DeleteThenCreate:
+			did.Action = terraform.DiffDestroyCreate
+		case plans.CreateThenDelete:
 			did.Action = terraform.DiffDestroyCreate
+			did.CreateBeforeDestroy = true
 		case plans.Update:
 			did.Action = terraform.DiffUpdate
 		default:
@@ -119,11 +171,11 @@ func NewPlan(changes *plans.Changes) *Plan {
 			did.Deposed = true
 		}
 
-		// Since this is just a temporary stub implementation on the way
-		// to us replacing this with the structural diff renderer, we currently
-		// don't include any attributes here.
 		// FIXME: Implement the structural diff renderer to replace this
-		// codepath altogether.
+		// flat rendering of the attributes altogether.
+		if schemas != nil && did.Action != terraform.DiffDestroy {
+			did.Attributes = planAttributeDiffs(rc, schemas)
+		}
 
 		ret.Resources = append(ret.Resources, did)
 	}
@@ -135,12 +187,252 @@ func NewPlan(changes *plans.Changes) *Plan {
 		return iAddr.Less(jAddr)
 	})
 
+	for _, oc := range changes.Outputs {
+		if !oc.Addr.Module.IsRoot() {
+			continue
+		}
+		od := planOutputDiff(oc)
+		if od == nil {
+			continue
+		}
+		ret.Outputs = append(ret.Outputs, od)
+	}
+	sort.Slice(ret.Outputs, func(i, j int) bool {
+		return ret.Outputs[i].Name < ret.Outputs[j].Name
+	})
+
+	return ret
+}
+
+// planOutputDiff returns the display representation of the given output value
+// change, or nil if it makes no change.
+func planOutputDiff(oc *plans.OutputChangeSrc) *OutputDiff {
+	od := &OutputDiff{
+		Name:      oc.Addr.OutputValue.Name,
+		Sensitive: oc.Sensitive,
+	}
+	switch oc.Action {
+	case plans.NoOp:
+		return nil
+	case plans.Create:
+		od.Action = terraform.DiffCreate
+	case plans.Delete:
+		od.Action = terraform.DiffDestroy
+	default:
+		od.Action = terraform.DiffUpdate
+	}
+
+	change, err := oc.Decode()
+	if err != nil {
+		// The change is still shown, but without its values.
+		log.Printf("[WARN] NewPlan failed to decode the change for %s: %s", oc.Addr, err)
+		return od
+	}
+	od.OldValue, _ = planOutputValue(change.Before)
+	od.NewValue, od.NewComputed = planOutputValue(change.After)
+	return od
+}
+
+// planOutputValue returns the JSON rendering of the given output value, and
+// whether any of it is not yet known, in which case the rendering is empty.
+// The rendering is also empty for a null value.
+func planOutputValue(val cty.Value) (string, bool) {
+	if !val.IsWhollyKnown() {
+		return "", true
+	}
+	if val.IsNull() {
+		return "", false
+	}
+	src, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return "", false
+	}
+	return string(src), false
+}
+
+// planAttributeDiffs returns the changes to the attributes of the given
+// resource instance change, flattened into a diff for each leaf value that
+// differs, in order of path. It returns nil if the resource's schema isn't
+// available or its values can't be decoded.
+func planAttributeDiffs(rc *plans.ResourceInstanceChangeSrc, schemas *terraform.Schemas) []*AttributeDiff {
+	addr := rc.Addr.Resource.Resource
+	provider := rc.ProviderAddr.ProviderConfig.Type
+	var schema *configschema.Block
+	switch addr.Mode {
+	case addrs.ManagedResourceMode:
+		schema = schemas.ResourceTypeConfig(provider, addr.Type)
+	case addrs.DataResourceMode:
+		schema = schemas.DataSourceConfig(provider, addr.Type)
+	}
+	if schema == nil {
+		log.Printf("[WARN] NewPlan has no schema for %s, so its attributes are not shown", rc.Addr)
+		return nil
+	}
+	change, err := rc.Decode(schema.ImpliedType())
+	if err != nil {
+		log.Printf("[WARN] NewPlan failed to decode the change for %s: %s", rc.Addr, err)
+		return nil
+	}
+
+	// The set of paths requiring replacement may be the zero value, which
+	// can't be queried, so it is copied into a set that can.
+	replace := cty.NewPathSet(change.RequiredReplace.List()...)
+
+	before := map[string]planLeaf{}
+	after := map[string]planLeaf{}
+	flattenPlanBlock(before, "", nil, change.Before, schema)
+	flattenPlanBlock(after, "", nil, change.After, schema)
+
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range after {
+		keys = append(keys, k)
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// Only the attributes of an existing object that is being changed are
+	// kept when they're unchanged, since they're only of interest as
+	// context for the changes.
+	keepUnchanged := rc.Action == plans.Update || rc.Action.IsReplace()
+
+	var ret []*AttributeDiff
+	for _, k := range keys {
+		old, hadOld := before[k]
+		new, hasNew := after[k]
+		if hadOld && hasNew && old.value == new.value && old.unknown == new.unknown {
+			if keepUnchanged {
+				ret = append(ret, &AttributeDiff{
+					Path:        k,
+					Action:      terraform.DiffNone,
+					OldValue:    old.value,
+					NewValue:    new.value,
+					NewComputed: new.unknown,
+					Sensitive:   old.sensitive || new.sensitive,
+				})
+			}
+			continue
+		}
+
+		diff := &AttributeDiff{
+			Path:        k,
+			Action:      terraform.DiffUpdate,
+			OldValue:    old.value,
+			NewValue:    new.value,
+			NewComputed: new.unknown,
+			Sensitive:   old.sensitive || new.sensitive,
+		}
+		switch {
+		case !hadOld:
+			diff.Action = terraform.DiffCreate
+		case !hasNew:
+			diff.Action = terraform.DiffDestroy
+		}
+		path := new.path
+		if !hasNew {
+			path = old.path
+		}
+		for i := len(path); i > 0; i-- {
+			if replace.Has(path[:i]) {
+				diff.ForcesNew = true
+				break
+			}
+		}
+		ret = append(ret, diff)
+	}
 	return ret
 }
 
+// planLeaf is a single primitive value, or a value that is not yet known,
+// within an object value flattened by flattenPlanBlock.
+type planLeaf struct {
+	path      cty.Path
+	value     string
+	unknown   bool
+	sensitive bool
+}
+
+// flattenPlanBlock adds a planLeaf to leaves for each primitive or unknown
+// value within the given object value conforming to the given schema,
+// keyed by its dot-delimited path after the given prefix. Null values are
+// left out.
+func flattenPlanBlock(leaves map[string]planLeaf, prefix string, path cty.Path, val cty.Value, schema *configschema.Block) {
+	if val.IsNull() || !val.IsKnown() {
+		return
+	}
+	for name, attrS := range schema.Attributes {
+		flattenPlanValue(leaves, prefix+name, path.GetAttr(name), val.GetAttr(name), attrS.Sensitive)
+	}
+	for name, blockS := range schema.BlockTypes {
+		bv := val.GetAttr(name)
+		if blockS.Nesting == configschema.NestingSingle {
+			flattenPlanBlock(leaves, prefix+name+".", path.GetAttr(name), bv, &blockS.Block)
+			continue
+		}
+		if bv.IsNull() || !bv.IsKnown() {
+			continue
+		}
+		i := 0
+		for it := bv.ElementIterator(); it.Next(); i++ {
+			k, ev := it.Element()
+			key := planElementKey(k, i)
+			flattenPlanBlock(leaves, prefix+name+"."+key+".", path.GetAttr(name).Index(k), ev, &blockS.Block)
+		}
+	}
+}
+
+// flattenPlanValue adds a planLeaf to leaves for each primitive or unknown
+// value within the given value, as for flattenPlanBlock, marking them
+// sensitive if the attribute they belong to is.
+func flattenPlanValue(leaves map[string]planLeaf, key string, path cty.Path, val cty.Value, sensitive bool) {
+	switch {
+	case !val.IsKnown():
+		leaves[key] = planLeaf{path: path, unknown: true, sensitive: sensitive}
+	case val.IsNull():
+	case val.Type().IsPrimitiveType():
+		var s string
+		switch val.Type() {
+		case cty.String:
+			s = val.AsString()
+		case cty.Number:
+			s = val.AsBigFloat().Text('f', -1)
+		case cty.Bool:
+			s = fmt.Sprintf("%t", val.True())
+		}
+		leaves[key] = planLeaf{path: path, value: s, sensitive: sensitive}
+	default:
+		i := 0
+		for it := val.ElementIterator(); it.Next(); i++ {
+			k, ev := it.Element()
+			flattenPlanValue(leaves, key+"."+planElementKey(k, i), path.Index(k), ev, sensitive)
+		}
+	}
+}
+
+// planElementKey returns the path segment for an element of a collection or
+// structural value with the given key, which is its index for a list, set or
+// tuple, and its key or attribute name otherwise. Set elements are numbered
+// in iteration order, since they have no key of their own.
+func planElementKey(k cty.Value, i int) string {
+	if k.Type() == cty.String {
+		return k.AsString()
+	}
+	if k.Type() == cty.Number {
+		return k.AsBigFloat().Text('f', -1)
+	}
+	return fmt.Sprintf("%d", i)
+}
+
 // Format produces and returns a text representation of the receiving plan
 // intended for display in a terminal.
 //
+// Each resource is prefixed with the symbol for its action, as returned by
+// DiffActionSymbol, so that the kind of change is clear even when color is
+// disabled.
+//
 // If color is not nil, it is used to colorize the output.
 func (p *Plan) Format(color *colorstring.Colorize) string {
 	if p.Empty() {
@@ -154,11 +446,14 @@ func (p *Plan) Format(color *colorstring.Colorize) string {
 		}
 	}
 
-	// Find the longest path length of all the paths that are changing,
-	// so we can align them all.
+	// Find the longest path length of all the paths that are shown, so we
+	// can align them all.
 	keyLen := 0
 	for _, r := range p.Resources {
 		for _, attr := range r.Attributes {
+			if attr.Action == terraform.DiffNone && !p.ShowUnchanged {
+				continue
+			}
 			key := attr.Path
 
 			if len(key) > keyLen {
@@ -168,11 +463,119 @@ func (p *Plan) Format(color *colorstring.Colorize) string {
 	}
 
 	buf := new(bytes.Buffer)
+	if !p.GroupByModule {
+		for _, r := range p.Resources {
+			formatPlanInstanceDiff(buf, r, keyLen, p.ShowUnchanged, color)
+		}
+		// Only trailing space is trimmed, so that the action symbol of the
+		// first resource stays aligned with the others.
+		return p.formatOutputs(strings.TrimRight(buf.String(), " \n"), color)
+	}
+
+	// The resources are sorted by address, but we group them explicitly
+	// here rather than relying on that ordering to keep each module's
+	// resources together.
+	var modules []string
+	groups := map[string][]*InstanceDiff{}
 	for _, r := range p.Resources {
-		formatPlanInstanceDiff(buf, r, keyLen, color)
+		module := planModuleHeader(r.Addr)
+		if _, exists := groups[module]; !exists {
+			modules = append(modules, module)
+		}
+		groups[module] = append(groups[module], r)
+	}
+
+	for _, module := range modules {
+		buf.WriteString(color.Color(fmt.Sprintf("[bold]%s:[reset]\n", module)))
+		diffBuf := new(bytes.Buffer)
+		for _, r := range groups[module] {
+			formatPlanInstanceDiff(diffBuf, r, keyLen, p.ShowUnchanged, color)
+		}
+		for _, line := range strings.SplitAfter(diffBuf.String(), "\n") {
+			if strings.TrimSpace(line) != "" {
+				buf.WriteString("  ")
+			}
+			buf.WriteString(line)
+		}
+	}
+
+	return p.formatOutputs(strings.TrimSpace(buf.String()), color)
+}
+
+// formatOutputs returns the given rendering of the resource diffs followed by
+// a "Changes to Outputs:" section for the output diffs, if there are any.
+//
+// Each output is prefixed with the symbol for its action, as for resources,
+// and sensitive values are redacted.
+func (p *Plan) formatOutputs(resources string, color *colorstring.Colorize) string {
+	if len(p.Outputs) == 0 {
+		return resources
+	}
+
+	nameLen := 0
+	for _, o := range p.Outputs {
+		if len(o.Name) > nameLen {
+			nameLen = len(o.Name)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if resources != "" {
+		buf.WriteString(resources)
+		buf.WriteString("\n\n")
+	}
+	buf.WriteString(color.Color("[reset][bold]Changes to Outputs:[reset]\n"))
+	for _, o := range p.Outputs {
+		oldV := planOutputDisplay(o.OldValue, false, o.Sensitive)
+		newV := planOutputDisplay(o.NewValue, o.NewComputed, o.Sensitive)
+
+		var disp string
+		switch o.Action {
+		case terraform.DiffCreate:
+			disp = newV
+		case terraform.DiffDestroy:
+			disp = oldV
+		default:
+			disp = oldV + " => " + newV
+		}
+
+		buf.WriteString(color.Color(fmt.Sprintf(
+			"%s %s%s = %s\n",
+			DiffActionSymbol(o.Action),
+			o.Name,
+			strings.Repeat(" ", nameLen-len(o.Name)),
+			disp,
+		)))
 	}
+	return strings.TrimRight(buf.String(), "\n")
+}
 
-	return strings.TrimSpace(buf.String())
+// planOutputDisplay returns the text shown for one of the values of an
+// output diff.
+func planOutputDisplay(v string, computed, sensitive bool) string {
+	switch {
+	case sensitive:
+		return "(sensitive value)"
+	case v == "" && computed:
+		return "<computed>"
+	case v == "":
+		return "null"
+	default:
+		return v
+	}
+}
+
+// planModuleHeader returns the header under which the diff for the resource
+// with the given address is grouped when grouping by module.
+func planModuleHeader(addr *terraform.ResourceAddress) string {
+	if len(addr.Path) == 0 {
+		return "Root module"
+	}
+	parts := make([]string, 0, len(addr.Path)*2)
+	for _, name := range addr.Path {
+		parts = append(parts, "module", name)
+	}
+	return strings.Join(parts, ".")
 }
 
 // Stats returns statistics about the plan
@@ -194,6 +597,27 @@ func (p *Plan) Stats() PlanStats {
 	return ret
 }
 
+// Summary returns the line summarizing the counts returned by Stats, in the
+// same form as at the end of "terraform plan", such as "Plan: 3 to add, 1 to
+// change, 2 to destroy." Each replacement counts as both an addition and a
+// destruction.
+//
+// If color is not nil, it is used to colorize the output, as for Format.
+func (p *Plan) Summary(color *colorstring.Colorize) string {
+	if color == nil {
+		color = &colorstring.Colorize{
+			Colors: colorstring.DefaultColors,
+			Reset:  false,
+		}
+	}
+
+	stats := p.Stats()
+	return color.Color(fmt.Sprintf(
+		"[reset][bold]Plan:[reset] %d to add, %d to change, %d to destroy.",
+		stats.ToAdd, stats.ToChange, stats.ToDestroy,
+	))
+}
+
 // ActionCounts returns the number of diffs for each action type
 func (p *Plan) ActionCounts() map[terraform.DiffChangeType]int {
 	ret := map[terraform.DiffChangeType]int{}
@@ -203,9 +627,10 @@ func (p *Plan) ActionCounts() map[terraform.DiffChangeType]int {
 	return ret
 }
 
-// Empty returns true if there is at least one resource diff in the receiving plan.
+// Empty returns true if there are no resource or output diffs in the
+// receiving plan.
 func (p *Plan) Empty() bool {
-	return len(p.Resources) == 0
+	return len(p.Resources) == 0 && len(p.Outputs) == 0
 }
 
 // DiffActionSymbol returns a string that, once passed through a
@@ -228,8 +653,9 @@ func DiffActionSymbol(action terraform.DiffChangeType) string {
 }
 
 // formatPlanInstanceDiff writes the text representation of the given instance diff
-// to the given buffer, using the given colorizer.
-func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colorizer *colorstring.Colorize) {
+// to the given buffer, using the given colorizer. Unless showUnchanged is set,
+// each run of unchanged attributes is written as a single line counting them.
+func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, showUnchanged bool, colorizer *colorstring.Colorize) {
 	addrStr := r.Addr.String()
 
 	// Determine the color for the text (green for adding, yellow
@@ -260,6 +686,14 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 	}
 	if r.Action == terraform.DiffDestroyCreate {
 		extraStr = extraStr + colorizer.Color(" [red][bold](new resource required)")
+
+		// The ordering matters for understanding downtime, or the brief
+		// existence of both objects.
+		if r.CreateBeforeDestroy {
+			extraStr = extraStr + colorizer.Color("[reset] (create before destroy)")
+		} else {
+			extraStr = extraStr + colorizer.Color("[reset] (destroy before create)")
+		}
 	}
 
 	buf.WriteString(
@@ -269,7 +703,36 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 		)),
 	)
 
+	hidden := 0
+	writeHidden := func() {
+		if hidden > 0 {
+			buf.WriteString(colorizer.Color(fmt.Sprintf(
+				"      [dark_gray]# (%s hidden)[reset]\n",
+				Pluralize(hidden, "unchanged attribute"),
+			)))
+			hidden = 0
+		}
+	}
+
 	for _, attr := range r.Attributes {
+		if attr.Action == terraform.DiffNone {
+			if !showUnchanged {
+				hidden++
+				continue
+			}
+			dispV := fmt.Sprintf("%q", attr.NewValue)
+			if attr.Sensitive {
+				dispV = "(sensitive value)"
+			}
+			buf.WriteString(fmt.Sprintf(
+				"      %s:%s %s\n",
+				attr.Path,
+				strings.Repeat(" ", keyLen-len(attr.Path)),
+				dispV,
+			))
+			continue
+		}
+		writeHidden()
 
 		v := attr.NewValue
 		var dispV string
@@ -277,7 +740,7 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 		case v == "" && attr.NewComputed:
 			dispV = "<computed>"
 		case attr.Sensitive:
-			dispV = "<sensitive>"
+			dispV = "(sensitive value)"
 		default:
 			dispV = fmt.Sprintf("%q", v)
 		}
@@ -295,7 +758,7 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 			var dispU string
 			switch {
 			case attr.Sensitive:
-				dispU = "<sensitive>"
+				dispU = "(sensitive value)"
 			default:
 				dispU = fmt.Sprintf("%q", u)
 			}
@@ -316,6 +779,7 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 			))
 		}
 	}
+	writeHidden()
 
 	// Write the reset color so we don't bleed color into later text
 	buf.WriteString(colorizer.Color("[reset]\n"))
@@ -0,0 +1,304 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/colorstring"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestPlanFormat_groupByModule(t *testing.T) {
+	resource := func(module addrs.ModuleInstance, name string) *plans.ResourceInstanceChangeSrc {
+		return &plans.ResourceInstanceChangeSrc{
+			Addr: addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: name,
+			}.Instance(addrs.NoKey).Absolute(module),
+			ChangeSrc: plans.ChangeSrc{
+				Action: plans.Create,
+			},
+		}
+	}
+	changes := &plans.Changes{
+		Resources: []*plans.ResourceInstanceChangeSrc{
+			resource(addrs.RootModuleInstance.Child("network", addrs.NoKey), "subnet"),
+			resource(addrs.RootModuleInstance, "foo"),
+			resource(addrs.RootModuleInstance.Child("network", addrs.NoKey), "vpc"),
+		},
+	}
+	color := &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+	}
+
+	flat := NewPlan(changes, nil)
+	if got, want := flat.Format(color), `  + test_thing.foo
+
+  + module.network.test_thing.subnet
+
+  + module.network.test_thing.vpc`; got != want {
+		t.Errorf("wrong flat output\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	grouped := NewPlan(changes, nil)
+	grouped.GroupByModule = true
+	if got, want := grouped.Format(color), `Root module:
+    + test_thing.foo
+
+module.network:
+    + module.network.test_thing.subnet
+
+    + module.network.test_thing.vpc`; got != want {
+		t.Errorf("wrong grouped output\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestPlanFormat_noColorSymbols(t *testing.T) {
+	tests := map[plans.Action]string{
+		plans.Create:           "  + test_thing.foo",
+		plans.Delete:           "  - test_thing.foo",
+		plans.Update:           "  ~ test_thing.foo",
+		plans.DeleteThenCreate: "-/+ test_thing.foo (new resource required) (destroy before create)",
+		plans.CreateThenDelete: "-/+ test_thing.foo (new resource required) (create before destroy)",
+		plans.Read:             " <= test_thing.foo",
+	}
+
+	for action, want := range tests {
+		t.Run(action.String(), func(t *testing.T) {
+			changes := &plans.Changes{
+				Resources: []*plans.ResourceInstanceChangeSrc{
+					{
+						Addr: addrs.Resource{
+							Mode: addrs.ManagedResourceMode,
+							Type: "test_thing",
+							Name: "foo",
+						}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+						ChangeSrc: plans.ChangeSrc{
+							Action: action,
+						},
+					},
+				},
+			}
+			color := &colorstring.Colorize{
+				Colors:  colorstring.DefaultColors,
+				Disable: true,
+			}
+
+			got := NewPlan(changes, nil).Format(color)
+			if got != want {
+				t.Errorf("wrong output\ngot:  %q\nwant: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPlanSummary(t *testing.T) {
+	change := func(name string, action plans.Action) *plans.ResourceInstanceChangeSrc {
+		return &plans.ResourceInstanceChangeSrc{
+			Addr: addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: name,
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			ChangeSrc: plans.ChangeSrc{
+				Action: action,
+			},
+		}
+	}
+	changes := &plans.Changes{
+		Resources: []*plans.ResourceInstanceChangeSrc{
+			change("create", plans.Create),
+			change("update", plans.Update),
+			change("delete", plans.Delete),
+			change("noop", plans.NoOp),
+			change("read", plans.Read),
+			change("replace", plans.DeleteThenCreate),
+			change("replace_cbd", plans.CreateThenDelete),
+		},
+	}
+	color := &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+	}
+
+	plan := NewPlan(changes, nil)
+	if got, want := plan.Stats(), (PlanStats{ToAdd: 3, ToChange: 1, ToDestroy: 3}); got != want {
+		t.Errorf("wrong stats\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got, want := plan.Summary(color), "Plan: 3 to add, 1 to change, 3 to destroy."; got != want {
+		t.Errorf("wrong summary\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if got, want := NewPlan(nil, nil).Summary(color), "Plan: 0 to add, 0 to change, 0 to destroy."; got != want {
+		t.Errorf("wrong summary for empty plan\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestPlanFormat_attributes(t *testing.T) {
+	schemas := &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				ResourceTypes: map[string]*configschema.Block{
+					"test_thing": {
+						Attributes: map[string]*configschema.Attribute{
+							"id":       {Type: cty.String, Computed: true},
+							"name":     {Type: cty.String, Required: true},
+							"password": {Type: cty.String, Optional: true, Sensitive: true},
+							"tags":     {Type: cty.Map(cty.String), Optional: true},
+						},
+					},
+				},
+			},
+		},
+	}
+	ty := schemas.ResourceTypeConfig("test", "test_thing").ImpliedType()
+	dynamic := func(v cty.Value) plans.DynamicValue {
+		dv, err := plans.NewDynamicValue(v, ty)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dv
+	}
+
+	change := &plans.ResourceInstanceChangeSrc{
+		Addr: addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_thing",
+			Name: "foo",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+		ProviderAddr: addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		ChangeSrc: plans.ChangeSrc{
+			Action: plans.DeleteThenCreate,
+			Before: dynamic(cty.ObjectVal(map[string]cty.Value{
+				"id":       cty.StringVal("foo-1"),
+				"name":     cty.StringVal("before"),
+				"password": cty.StringVal("hunter2"),
+				"tags":     cty.MapVal(map[string]cty.Value{"env": cty.StringVal("prod")}),
+			})),
+			After: dynamic(cty.ObjectVal(map[string]cty.Value{
+				"id":       cty.UnknownVal(cty.String),
+				"name":     cty.StringVal("after"),
+				"password": cty.StringVal("correct-horse"),
+				"tags":     cty.MapVal(map[string]cty.Value{"env": cty.StringVal("prod")}),
+			})),
+		},
+		RequiredReplace: cty.NewPathSet(cty.Path{}.GetAttr("name")),
+	}
+	changes := &plans.Changes{
+		Resources: []*plans.ResourceInstanceChangeSrc{change},
+	}
+	color := &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+	}
+
+	got := NewPlan(changes, schemas).Format(color)
+	want := `-/+ test_thing.foo (new resource required) (destroy before create)
+      id:       "foo-1" => <computed>
+      name:     "before" => "after" (forces new resource)
+      password: (sensitive value) => (sensitive value) (attribute changed)
+      # (1 unchanged attribute hidden)`
+	if got != want {
+		t.Errorf("wrong output\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	all := NewPlan(changes, schemas)
+	all.ShowUnchanged = true
+	if got, want := all.Format(color), `-/+ test_thing.foo (new resource required) (destroy before create)
+      id:       "foo-1" => <computed>
+      name:     "before" => "after" (forces new resource)
+      password: (sensitive value) => (sensitive value) (attribute changed)
+      tags.env: "prod"`; got != want {
+		t.Errorf("wrong output with unchanged attributes\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+	for _, secret := range []string{"hunter2", "correct-horse"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("output includes the sensitive value %q", secret)
+		}
+	}
+
+	// Without schemas, the attributes are left out.
+	if got, want := NewPlan(changes, nil).Format(color), "-/+ test_thing.foo (new resource required) (destroy before create)"; got != want {
+		t.Errorf("wrong output without schemas\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestPlanFormat_outputs(t *testing.T) {
+	output := func(module addrs.ModuleInstance, name string, action plans.Action, sensitive bool, before, after cty.Value) *plans.OutputChangeSrc {
+		oc := &plans.OutputChange{
+			Addr: addrs.OutputValue{Name: name}.Absolute(module),
+			Change: plans.Change{
+				Action: action,
+				Before: before,
+				After:  after,
+			},
+			Sensitive: sensitive,
+		}
+		ocs, err := oc.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ocs
+	}
+	null := cty.NullVal(cty.DynamicPseudoType)
+	changes := &plans.Changes{
+		Resources: []*plans.ResourceInstanceChangeSrc{
+			{
+				Addr: addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_thing",
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				ChangeSrc: plans.ChangeSrc{
+					Action: plans.Create,
+				},
+			},
+		},
+		Outputs: []*plans.OutputChangeSrc{
+			output(addrs.RootModuleInstance, "removed", plans.Delete, false, cty.StringVal("gone"), null),
+			output(addrs.RootModuleInstance, "added", plans.Create, false, null, cty.ListVal([]cty.Value{cty.StringVal("a")})),
+			output(addrs.RootModuleInstance, "changed", plans.Update, false, cty.StringVal("old"), cty.StringVal("new")),
+			output(addrs.RootModuleInstance, "computed", plans.Update, false, cty.NumberIntVal(1), cty.UnknownVal(cty.Number)),
+			output(addrs.RootModuleInstance, "secret", plans.Update, true, cty.StringVal("old"), cty.StringVal("new")),
+			output(addrs.RootModuleInstance, "same", plans.NoOp, false, cty.True, cty.True),
+			output(addrs.RootModuleInstance.Child("child", addrs.NoKey), "inner", plans.Create, false, null, cty.True),
+		},
+	}
+	color := &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+	}
+
+	got := NewPlan(changes, nil).Format(color)
+	want := `  + test_thing.foo
+
+Changes to Outputs:
+  + added    = ["a"]
+  ~ changed  = "old" => "new"
+  ~ computed = 1 => <computed>
+  - removed  = "gone"
+  ~ secret   = (sensitive value) => (sensitive value)`
+	if got != want {
+		t.Errorf("wrong output\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	// A plan that changes only outputs still has something to show.
+	changes.Resources = nil
+	outputsOnly := NewPlan(changes, nil)
+	if outputsOnly.Empty() {
+		t.Fatal("plan with only output changes is empty")
+	}
+	if got := outputsOnly.Format(color); !strings.HasPrefix(got, "Changes to Outputs:\n") {
+		t.Errorf("wrong output for a plan changing only outputs\n%s", got)
+	}
+	if got, want := outputsOnly.Stats(), (PlanStats{}); got != want {
+		t.Errorf("wrong stats %#v; want %#v", got, want)
+	}
+}
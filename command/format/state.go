@@ -3,8 +3,12 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/configs/configschema"
@@ -12,6 +16,7 @@ import (
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/colorstring"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // StateOpts are the options for formatting a state.
@@ -24,10 +29,77 @@ type StateOpts struct {
 
 	// Color is the colorizer. This is optional.
 	Color *colorstring.Colorize
+
+	// Sort, if true, renders the modules in order of their addresses,
+	// starting with the root module, and the resource instances within each
+	// module in order of their addresses, so that the output is
+	// deterministic. Count indexes are in numeric order and for_each keys
+	// in lexical order.
+	Sort bool
+
+	// ShowSensitive, if true, renders the values of sensitive attributes and
+	// output values. By default they are replaced with "(sensitive value)".
+	ShowSensitive bool
+
+	// Summary, if true, adds a final line counting the managed resource
+	// instances, data resource instances and modules in the state.
+	Summary bool
+
+	// HideDataSources, if true, omits data resources from the output, so
+	// that a module containing only data resources is not shown at all. The
+	// summary still counts them.
+	HideDataSources bool
+
+	// MaxValueLen, if greater than zero, is the length in bytes beyond which
+	// string values are truncated, followed by a note of their full length,
+	// so that huge values such as embedded documents don't flood the output.
+	// Zero means no limit.
+	MaxValueLen int
+
+	// Offset and Limit, if either is greater than zero, render only a window
+	// of the resource instance objects: those after the first Offset of
+	// them, and at most Limit of them, counting each deposed object
+	// separately and leaving out any hidden data sources. A footer then
+	// notes which objects are shown, out of how many. A Limit of zero means
+	// no limit. Outputs and the summary are unaffected.
+	Offset int
+	Limit  int
+
+	// Brief, if true, renders each resource instance object as a single
+	// line giving its address and resource type, and whether it is tainted
+	// or deposed, leaving out its attributes, for an overview of what the
+	// state contains. The objects are then not decoded at all, and the
+	// output values are left out too.
+	Brief bool
+
+	// Writer, if set, is where State writes the rendering, as StreamState
+	// would, in which case it returns an empty string. Exactly one of the
+	// two is used: the rendering is either returned or written, never both.
+	// StreamState ignores it, writing to its own argument instead.
+	Writer io.Writer
 }
 
-// State takes a state and returns a string
+// State takes a state and returns a string, or writes it to opts.Writer if
+// that is set and returns an empty string. Any error from opts.Writer is
+// discarded, so callers that need it should call StreamState instead.
 func State(opts *StateOpts) string {
+	if opts.Writer != nil {
+		StreamState(opts.Writer, opts)
+		return ""
+	}
+
+	var buf strings.Builder
+
+	// A strings.Builder never returns an error.
+	StreamState(&buf, opts)
+	return buf.String()
+}
+
+// StreamState writes the same rendering of a state as State to the given
+// writer, but one resource instance at a time, so that the rendering of a
+// large state is never held in memory all at once. It returns the first
+// error returned by the writer, if any.
+func StreamState(w io.Writer, opts *StateOpts) error {
 	if opts.Color == nil {
 		panic("colorize not given")
 	}
@@ -38,49 +110,223 @@ func State(opts *StateOpts) string {
 
 	s := opts.State
 	if len(s.Modules) == 0 {
-		return "The state file is empty. No resources are represented."
+		_, err := io.WriteString(w, "The state file is empty. No resources are represented.")
+		return err
 	}
 
+	sw := newStateWriter(w, opts.Color)
 	buf := bytes.NewBufferString("[reset]")
 	p := blockBodyDiffPrinter{
-		buf:    buf,
-		color:  opts.Color,
-		action: plans.NoOp,
+		buf:         buf,
+		color:       opts.Color,
+		action:      plans.NoOp,
+		maxValueLen: opts.MaxValueLen,
 	}
 
 	// Format all the modules
+	modules := make([]*states.Module, 0, len(s.Modules))
 	for _, m := range s.Modules {
-		formatStateModule(p, m, opts.Schemas)
+		modules = append(modules, m)
+	}
+	if opts.Sort {
+		sort.Slice(modules, func(i, j int) bool {
+			return moduleAddrLess(modules[i].Addr, modules[j].Addr)
+		})
 	}
+	win := &stateWindow{offset: opts.Offset, limit: opts.Limit}
+	for _, m := range modules {
+		formatStateModule(p, m, opts, win, sw)
+	}
+	sw.flush(p.buf)
 
 	// Write the outputs for the root module
 	m := s.RootModule()
 
-	if m.OutputValues != nil {
-		if len(m.OutputValues) > 0 {
-			p.buf.WriteString("Outputs:\n\n")
-		}
+	if len(m.OutputValues) > 0 && !opts.Brief {
+		p.buf.WriteString("Outputs:\n\n")
+		formatStateOutputs(p, m.OutputValues, 0, opts, sw)
+	}
 
-		// Sort the outputs
-		ks := make([]string, 0, len(m.OutputValues))
-		for k := range m.OutputValues {
-			ks = append(ks, k)
-		}
-		sort.Strings(ks)
+	var summary string
+	if opts.Offset > 0 || opts.Limit > 0 {
+		summary = "\n\n" + win.footer()
+	}
+	if opts.Summary {
+		summary += "\n\n" + stateSummary(s)
+	}
+	sw.close(summary)
+	return sw.err
+}
+
+// stateChunkSize is the size of the rendering that StreamState buffers
+// before writing it out.
+const stateChunkSize = 32 * 1024
+
+// stateWriter colorizes the rendering of a state and writes it to an
+// io.Writer in chunks, trimming any trailing whitespace from the rendering
+// as a whole. Chunks must end at line boundaries so that no color code is
+// split between them.
+type stateWriter struct {
+	w     io.Writer
+	color colorstring.Colorize
+	reset bool
+
+	// colored is true once any color code has been written, and space is
+	// the trailing whitespace of the chunks written so far, which is held
+	// back until we know that more content follows it.
+	colored bool
+	space   string
+
+	err error
+}
 
-		// Output each output k/v pair
-		for _, k := range ks {
-			v := m.OutputValues[k]
-			p.buf.WriteString(fmt.Sprintf("%s = ", k))
-			p.writeValue(v.Value, plans.NoOp, 0)
+func newStateWriter(w io.Writer, color *colorstring.Colorize) *stateWriter {
+	sw := &stateWriter{
+		w:     w,
+		color: *color,
+		reset: color.Reset,
+	}
+
+	// The reset code is written once at the end, as if the rendering were
+	// colorized as a whole, rather than after every chunk.
+	sw.color.Reset = false
+	return sw
+}
+
+// flush writes the contents of the given buffer as a chunk and empties it.
+func (sw *stateWriter) flush(buf *bytes.Buffer) {
+	chunk := buf.String()
+	buf.Reset()
+
+	content := strings.TrimRightFunc(chunk, unicode.IsSpace)
+	if content == "" {
+		sw.space += chunk
+		return
+	}
+
+	colored := sw.color.Color(content)
+	if colored != content {
+		sw.colored = true
+	}
+	sw.write(sw.space)
+	sw.write(colored)
+	sw.space = chunk[len(content):]
+}
+
+// close drops the trailing whitespace and writes the given suffix, which
+// must not contain color codes, after the last chunk.
+func (sw *stateWriter) close(suffix string) {
+	sw.space = ""
+	sw.write(suffix)
+	if sw.colored && sw.reset && !sw.color.Disable {
+		sw.write("\033[0m")
+	}
+}
+
+func (sw *stateWriter) write(s string) {
+	if sw.err != nil || s == "" {
+		return
+	}
+	_, sw.err = io.WriteString(sw.w, s)
+}
+
+// stateSummary returns a sentence counting the managed and data resource
+// instances in the given state and the modules that contain them. Each
+// instance of a resource using count or for_each is counted separately.
+func stateSummary(s *states.State) string {
+	var managed, data, modules int
+	for _, m := range s.Modules {
+		if len(m.Resources) == 0 {
+			continue
+		}
+		modules++
+		for _, rs := range m.Resources {
+			switch rs.Addr.Mode {
+			case addrs.ManagedResourceMode:
+				managed += len(rs.Instances)
+			case addrs.DataResourceMode:
+				data += len(rs.Instances)
+			}
 		}
 	}
 
-	return opts.Color.Color(strings.TrimSpace(p.buf.String()))
+	return fmt.Sprintf(
+		"%s and %s across %s.",
+		Pluralize(managed, "managed resource"),
+		Pluralize(data, "data source"),
+		Pluralize(modules, "module"),
+	)
+}
 
+// stateWindow selects the resource instance objects to render for the
+// Offset and Limit options, as the objects of each module are offered to it
+// in turn, and counts them all for the footer.
+type stateWindow struct {
+	offset, limit int
+	total, shown  int
+}
+
+// take returns those of the given objects, which follow all the objects
+// offered before them, that are within the window.
+func (w *stateWindow) take(objs []stateObject) []stateObject {
+	start := w.offset - w.total
+	w.total += len(objs)
+	switch {
+	case start < 0:
+		start = 0
+	case start > len(objs):
+		start = len(objs)
+	}
+	objs = objs[start:]
+	if w.limit > 0 && len(objs) > w.limit-w.shown {
+		objs = objs[:w.limit-w.shown]
+	}
+	w.shown += len(objs)
+	return objs
 }
 
-func formatStateModule(p blockBodyDiffPrinter, m *states.Module, schemas *terraform.Schemas) {
+// footer returns a sentence noting which of the objects offered to the
+// window were rendered, counting from one.
+func (w *stateWindow) footer() string {
+	switch {
+	case w.total == 0:
+		return "Showing no resource instances, since there are none."
+	case w.shown == 0:
+		return fmt.Sprintf("Showing no resource instances, since the offset %d is past the last of %d.", w.offset, w.total)
+	}
+	first := 1
+	if w.offset > 0 {
+		first = w.offset + 1
+	}
+	return fmt.Sprintf("Showing resource instances %d-%d of %d.", first, first+w.shown-1, w.total)
+}
+
+// Pluralize returns the given count followed by the given noun, adding an
+// "s" to the noun unless the count is one.
+func Pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// moduleAddrLess returns true if module instance address a sorts before b in
+// the order of their string representations, except that instance keys of
+// the same type are compared by value so that integer keys are in numeric
+// order.
+func moduleAddrLess(a, b addrs.ModuleInstance) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i].Name != b[i].Name:
+			return a[i].Name < b[i].Name
+		case a[i].InstanceKey != b[i].InstanceKey:
+			return addrs.InstanceKeyLess(a[i].InstanceKey, b[i].InstanceKey)
+		}
+	}
+	return len(a) < len(b)
+}
+
+func formatStateModule(p blockBodyDiffPrinter, m *states.Module, opts *StateOpts, win *stateWindow, sw *stateWriter) {
 	// First get the names of all the resources so we can show them
 	// in alphabetical order.
 	names := make([]string, 0, len(m.Resources))
@@ -89,87 +335,349 @@ func formatStateModule(p blockBodyDiffPrinter, m *states.Module, schemas *terraf
 	}
 	sort.Strings(names)
 
-	// Go through each resource and begin building up the output.
+	// Go through each resource and list the objects to render, in order.
+	var objs []stateObject
 	for _, key := range names {
-		for k, v := range m.Resources[key].Instances {
-			addr := m.Resources[key].Addr
+		if opts.HideDataSources && m.Resources[key].Addr.Mode == addrs.DataResourceMode {
+			continue
+		}
+
+		keys := make([]addrs.InstanceKey, 0, len(m.Resources[key].Instances))
+		for k := range m.Resources[key].Instances {
+			keys = append(keys, k)
+		}
+		if opts.Sort {
+			sort.Slice(keys, func(i, j int) bool {
+				return addrs.InstanceKeyLess(keys[i], keys[j])
+			})
+		}
+
+		for _, k := range keys {
+			rs := m.Resources[key]
+			is := rs.Instances[k]
+			addr := rs.Addr.Absolute(m.Addr).Instance(k)
+			if is.Current != nil {
+				objs = append(objs, stateObject{rs: rs, addr: addr, obj: is.Current})
+			}
 
-			taintStr := ""
-			if v.Current.Status == 'T' {
-				taintStr = "(tainted)"
+			// Deposed objects are those left over from create-before-destroy
+			// replacements that couldn't be destroyed, which the next apply
+			// will try to destroy again.
+			deposed := make([]string, 0, len(is.Deposed))
+			for dk := range is.Deposed {
+				deposed = append(deposed, string(dk))
 			}
-			p.buf.WriteString(fmt.Sprintf("# %s: %s\n", addr.Absolute(m.Addr).Instance(k), taintStr))
-
-			var schema *configschema.Block
-			provider := m.Resources[key].ProviderConfig.ProviderConfig.StringCompact()
-			if _, exists := schemas.Providers[provider]; !exists {
-				// This should never happen in normal use because we should've
-				// loaded all of the schemas and checked things prior to this
-				// point. We can't return errors here, but since this is UI code
-				// we will try to do _something_ reasonable.
-				p.buf.WriteString(fmt.Sprintf("# missing schema for provider %q\n\n", provider))
-				continue
+			sort.Strings(deposed)
+			for _, dk := range deposed {
+				objs = append(objs, stateObject{rs: rs, addr: addr, obj: is.Deposed[states.DeposedKey(dk)], deposed: states.DeposedKey(dk)})
 			}
+		}
+	}
 
-			switch addr.Mode {
-			case addrs.ManagedResourceMode:
-				if _, exists := schemas.Providers[provider].ResourceTypes[addr.Type]; !exists {
-					p.buf.WriteString(fmt.Sprintf(
-						"# missing schema for provider %q resource type %s\n\n", provider, addr.Type))
+	objs = win.take(objs)
+
+	if opts.Brief {
+		for i := range objs {
+			if p.buf.Len() >= stateChunkSize {
+				sw.flush(p.buf)
+			}
+			formatStateObjectBrief(p, &objs[i])
+		}
+		// A blank line separates the modules, but a module with nothing
+		// to show adds nothing.
+		if len(objs) > 0 {
+			p.buf.WriteString("\n")
+		}
+		return
+	}
+
+	// The objects are decoded a batch at a time, concurrently, and then
+	// rendered in order, so that only one batch of decoded values is held
+	// in memory at once.
+	for len(objs) > 0 {
+		batch := objs
+		if len(batch) > stateDecodeBatchSize {
+			batch = batch[:stateDecodeBatchSize]
+		}
+		objs = objs[len(batch):]
+
+		decodeStateObjects(batch, opts.Schemas, 0)
+		for i := range batch {
+			// Write out whatever came before this object once there's
+			// enough of it, so that the rendering is never buffered whole.
+			if p.buf.Len() >= stateChunkSize {
+				sw.flush(p.buf)
+			}
+			formatStateObject(p, &batch[i], opts)
+		}
+	}
+
+	// Only the root module outputs are kept in a state file, but a state in
+	// memory may also have those of the child modules, which are shown
+	// along with the module's resources, indented like their attributes.
+	if !m.Addr.IsRoot() && len(m.OutputValues) > 0 {
+		p.buf.WriteString(fmt.Sprintf("Outputs of %s:\n\n", m.Addr))
+		formatStateOutputs(p, m.OutputValues, 4, opts, sw)
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString("[reset]\n")
+}
+
+// stateDecodeBatchSize is the number of objects that formatStateModule
+// decodes at once.
+const stateDecodeBatchSize = 256
+
+// stateObject is a single object of a resource instance to render, which is
+// its current object unless a deposed key is given.
+type stateObject struct {
+	rs      *states.Resource
+	addr    addrs.AbsResourceInstance
+	obj     *states.ResourceInstanceObjectSrc
+	deposed states.DeposedKey
+
+	// val and err are the result of decoding obj against the schema of its
+	// resource type, as set by decodeStateObjects. Both are unset if the
+	// schema isn't available.
+	val cty.Value
+	err error
+}
+
+// decodeStateObjects decodes each of the given objects against the schema of
+// its resource type, setting its val and err, using the given number of
+// workers concurrently, or GOMAXPROCS workers if it is zero.
+//
+// Decoding depends only on the object and the schema, and each worker
+// writes only the objects it decodes, so the results are the same whatever
+// the number of workers.
+func decodeStateObjects(objs []stateObject, schemas *terraform.Schemas, workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				so := &objs[i]
+				schema, ok := stateObjectSchema(so.rs, schemas)
+				if !ok {
 					continue
 				}
-
-				p.buf.WriteString(fmt.Sprintf(
-					"resource %q %q {\n",
-					addr.Type,
-					addr.Name,
-				))
-				schema = schemas.Providers[provider].ResourceTypes[addr.Type]
-			case addrs.DataResourceMode:
-				if _, exists := schemas.Providers[provider].ResourceTypes[addr.Type]; !exists {
-					p.buf.WriteString(fmt.Sprintf(
-						"# missing schema for provider %q data source %s\n\n", provider, addr.Type))
+				val, err := so.obj.Decode(schema.ImpliedType())
+				if err != nil {
+					so.err = err
 					continue
 				}
+				so.val = val.Value
+			}
+		}()
+	}
+	for i := range objs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// stateObjectSchema returns the schema that formatStateObject renders the
+// objects of the given resource with, or false if it reports the schema as
+// missing instead.
+func stateObjectSchema(rs *states.Resource, schemas *terraform.Schemas) (*configschema.Block, bool) {
+	provider := rs.ProviderConfig.ProviderConfig.StringCompact()
+	ps, exists := schemas.Providers[provider]
+	if !exists {
+		return nil, false
+	}
+	switch rs.Addr.Mode {
+	case addrs.ManagedResourceMode:
+		schema, exists := ps.ResourceTypes[rs.Addr.Type]
+		return schema, exists
+	case addrs.DataResourceMode:
+		if _, exists := ps.ResourceTypes[rs.Addr.Type]; !exists {
+			return nil, false
+		}
+		return ps.DataSources[rs.Addr.Type], true
+	default:
+		return nil, true
+	}
+}
 
-				p.buf.WriteString(fmt.Sprintf(
-					"data %q %q {\n",
-					addr.Type,
-					addr.Name,
-				))
-				schema = schemas.Providers[provider].DataSources[addr.Type]
-			default:
-				// should never happen, since the above is exhaustive
-				p.buf.WriteString(addr.String())
+// formatStateObject writes a single object, as decoded by
+// decodeStateObjects. The header notes whether the object is tainted or
+// deposed, since either will be replaced or destroyed by the next apply.
+func formatStateObject(p blockBodyDiffPrinter, so *stateObject, opts *StateOpts) {
+	schemas := opts.Schemas
+	rs, addr, obj, deposed := so.rs, so.addr, so.obj, so.deposed
+
+	var statusStr string
+	switch {
+	case deposed != states.NotDeposed:
+		statusStr = fmt.Sprintf("(deposed object %s)", deposed)
+	case obj.Status == states.ObjectTainted:
+		statusStr = "(tainted)"
+	}
+	p.buf.WriteString(fmt.Sprintf("# %s: %s\n", addr, statusStr))
+
+	var schema *configschema.Block
+	provider := rs.ProviderConfig.ProviderConfig.StringCompact()
+	if _, exists := schemas.Providers[provider]; !exists {
+		// This should never happen in normal use because we should've
+		// loaded all of the schemas and checked things prior to this
+		// point. We can't return errors here, but since this is UI code
+		// we will try to do _something_ reasonable.
+		p.buf.WriteString(fmt.Sprintf("# missing schema for provider %q\n\n", provider))
+		return
+	}
+
+	switch rs.Addr.Mode {
+	case addrs.ManagedResourceMode:
+		if _, exists := schemas.Providers[provider].ResourceTypes[rs.Addr.Type]; !exists {
+			p.buf.WriteString(fmt.Sprintf(
+				"# missing schema for provider %q resource type %s\n\n", provider, rs.Addr.Type))
+			return
+		}
+
+		p.buf.WriteString(fmt.Sprintf(
+			"resource %q %q {\n",
+			rs.Addr.Type,
+			rs.Addr.Name,
+		))
+		schema = schemas.Providers[provider].ResourceTypes[rs.Addr.Type]
+	case addrs.DataResourceMode:
+		if _, exists := schemas.Providers[provider].ResourceTypes[rs.Addr.Type]; !exists {
+			p.buf.WriteString(fmt.Sprintf(
+				"# missing schema for provider %q data source %s\n\n", provider, rs.Addr.Type))
+			return
+		}
+
+		p.buf.WriteString(fmt.Sprintf(
+			"data %q %q {\n",
+			rs.Addr.Type,
+			rs.Addr.Name,
+		))
+		schema = schemas.Providers[provider].DataSources[rs.Addr.Type]
+	default:
+		// should never happen, since the above is exhaustive
+		p.buf.WriteString(rs.Addr.String())
+	}
+
+	if so.err != nil {
+		// An object that can't be decoded, such as one that a failed apply
+		// left incomplete, is flagged in place so that the rest of the
+		// state is still shown.
+		p.buf.WriteString(fmt.Sprintf("    # error decoding object: %s\n}\n\n", so.err))
+		return
+	}
+
+	formatStateBlockBody(p, schema, so.val, 4, opts.ShowSensitive)
+	p.buf.WriteString("}\n\n")
+}
+
+// formatStateObjectBrief writes the single line that represents an object
+// for the Brief option, such as "test_instance.foo (test_instance, tainted)".
+func formatStateObjectBrief(p blockBodyDiffPrinter, so *stateObject) {
+	notes := []string{so.rs.Addr.Type}
+	switch {
+	case so.deposed != states.NotDeposed:
+		notes = append(notes, fmt.Sprintf("deposed object %s", so.deposed))
+	case so.obj.Status == states.ObjectTainted:
+		notes = append(notes, "tainted")
+	}
+	p.buf.WriteString(fmt.Sprintf("%s (%s)\n", so.addr, strings.Join(notes, ", ")))
+}
+
+// formatStateOutputs writes the given output values in order of their
+// names, one per line at the given indent, flushing after each. The values
+// of sensitive outputs are replaced with "(sensitive value)" unless
+// opts.ShowSensitive is set.
+func formatStateOutputs(p blockBodyDiffPrinter, outputs map[string]*states.OutputValue, indent int, opts *StateOpts, sw *stateWriter) {
+	ks := make([]string, 0, len(outputs))
+	for k := range outputs {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+
+	for _, k := range ks {
+		v := outputs[k]
+		p.buf.WriteString(fmt.Sprintf("%s%s = ", strings.Repeat(" ", indent), k))
+		if v.Sensitive && !opts.ShowSensitive {
+			p.buf.WriteString("(sensitive value)")
+		} else {
+			p.writeValue(v.Value, plans.NoOp, indent)
+		}
+		p.buf.WriteString("\n")
+		sw.flush(p.buf)
+	}
+}
+
+// formatStateBlockBody writes the non-null attributes of the given object,
+// followed by its nested blocks, at the given indent. The values of sensitive
+// attributes are replaced with "(sensitive value)" unless showSensitive is
+// set.
+func formatStateBlockBody(p blockBodyDiffPrinter, schema *configschema.Block, val cty.Value, indent int, showSensitive bool) {
+	// First get the names of all the attributes so we can show them
+	// in alphabetical order.
+	names := make([]string, 0, len(schema.Attributes))
+	for name := range schema.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		attr := ctyGetAttrMaybeNull(val, name)
+		if !attr.IsNull() {
+			p.buf.WriteString(fmt.Sprintf("%s%s = ", strings.Repeat(" ", indent), name))
+			if schema.Attributes[name].Sensitive && !showSensitive {
+				p.buf.WriteString("(sensitive value)")
+			} else {
+				p.writeValue(attr, plans.NoOp, indent)
 			}
+			p.buf.WriteString("\n")
+		}
+	}
+
+	blockTypeNames := make([]string, 0, len(schema.BlockTypes))
+	for name := range schema.BlockTypes {
+		blockTypeNames = append(blockTypeNames, name)
+	}
+	sort.Strings(blockTypeNames)
+
+	for _, name := range blockTypeNames {
+		blockS := schema.BlockTypes[name]
+		blockV := ctyGetAttrMaybeNull(val, name)
+		if blockV.IsNull() || !blockV.IsKnown() {
+			continue
+		}
 
-			val, err := v.Current.Decode(schema.ImpliedType())
-			if err != nil {
-				fmt.Println(err.Error())
-				break
+		writeBlock := func(label string, v cty.Value) {
+			p.buf.WriteString(strings.Repeat(" ", indent))
+			if label != "" {
+				p.buf.WriteString(fmt.Sprintf("%s %q {\n", name, label))
+			} else {
+				p.buf.WriteString(fmt.Sprintf("%s {\n", name))
 			}
+			formatStateBlockBody(p, &blockS.Block, v, indent+4, showSensitive)
+			p.buf.WriteString(strings.Repeat(" ", indent))
+			p.buf.WriteString("}\n")
+		}
 
-			// First get the names of all the attributes so we can show them
-			// in alphabetical order.
-			names := make([]string, 0, len(schema.Attributes))
-			for name := range schema.Attributes {
-				names = append(names, name)
+		switch blockS.Nesting {
+		case configschema.NestingSingle:
+			writeBlock("", blockV)
+		case configschema.NestingList, configschema.NestingSet:
+			for it := blockV.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				writeBlock("", v)
 			}
-			sort.Strings(names)
-
-			for _, name := range names {
-				attr := ctyGetAttrMaybeNull(val.Value, name)
-				if !attr.IsNull() {
-					p.buf.WriteString(fmt.Sprintf("    %s = ", name))
-					attr := ctyGetAttrMaybeNull(val.Value, name)
-					p.writeValue(attr, plans.NoOp, 4)
-					p.buf.WriteString("\n")
-				}
+		case configschema.NestingMap:
+			for it := blockV.ElementIterator(); it.Next(); {
+				k, v := it.Element()
+				writeBlock(k.AsString(), v)
 			}
-			p.buf.WriteString("}\n\n")
 		}
 	}
-	p.buf.WriteString("[reset]\n")
 }
 
 func formatNestedList(indent string, outputList []interface{}) string {
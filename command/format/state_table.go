@@ -0,0 +1,164 @@
+package format
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/states"
+)
+
+// StateTable returns a rendering of the given state as a table with a row
+// for each resource instance object, giving its address, resource type, id
+// and status, for a quick inventory of a state. The rows are in order of
+// their addresses, with any deposed objects of an instance following its
+// current object.
+//
+// The id is the object's "id" attribute, if its resource type has one and
+// it's set. The status is "ready", or is highlighted for an object that the
+// next apply will replace or destroy: "tainted", or "deposed" followed by
+// the deposed key.
+//
+// Only the State, Schemas, Color and HideDataSources options apply.
+func StateTable(opts *StateOpts) string {
+	if opts.Color == nil {
+		panic("colorize not given")
+	}
+
+	if opts.Schemas == nil {
+		panic("schemas not given")
+	}
+
+	rows := [][]string{{"ADDRESS", "TYPE", "ID", "STATUS"}}
+	s := opts.State
+	modules := make([]*states.Module, 0, len(s.Modules))
+	for _, m := range s.Modules {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return moduleAddrLess(modules[i].Addr, modules[j].Addr)
+	})
+
+	for _, m := range modules {
+		names := make([]string, 0, len(m.Resources))
+		for name := range m.Resources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			rs := m.Resources[name]
+			if opts.HideDataSources && rs.Addr.Mode == addrs.DataResourceMode {
+				continue
+			}
+			schema := stateTableSchema(rs, opts)
+
+			keys := make([]addrs.InstanceKey, 0, len(rs.Instances))
+			for k := range rs.Instances {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return addrs.InstanceKeyLess(keys[i], keys[j])
+			})
+
+			for _, k := range keys {
+				is := rs.Instances[k]
+				addr := rs.Addr.Absolute(m.Addr).Instance(k).String()
+				if is.Current != nil {
+					status := "ready"
+					if is.Current.Status == states.ObjectTainted {
+						status = "tainted"
+					}
+					rows = append(rows, []string{addr, rs.Addr.Type, stateTableID(is.Current, schema), status})
+				}
+
+				deposed := make([]string, 0, len(is.Deposed))
+				for dk := range is.Deposed {
+					deposed = append(deposed, string(dk))
+				}
+				sort.Strings(deposed)
+				for _, dk := range deposed {
+					obj := is.Deposed[states.DeposedKey(dk)]
+					rows = append(rows, []string{addr, rs.Addr.Type, stateTableID(obj, schema), "deposed " + dk})
+				}
+			}
+		}
+	}
+
+	if len(rows) == 1 {
+		return "The state file is empty. No resources are represented."
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	// The cells are padded before they are colorized, so that the color
+	// codes don't count towards the widths of the columns.
+	var buf bytes.Buffer
+	for r, row := range rows {
+		var line bytes.Buffer
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			switch {
+			case r == 0:
+				line.WriteString("[bold]" + cell + "[reset]")
+			case i == len(row)-1 && cell != "ready":
+				line.WriteString("[yellow]" + cell + "[reset]")
+			default:
+				line.WriteString(cell)
+			}
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-len(cell)))
+			}
+		}
+		buf.WriteString(opts.Color.Color("[reset]" + line.String()))
+		buf.WriteString("\n")
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// stateTableSchema returns the schema of the given resource, or nil if it
+// isn't available.
+func stateTableSchema(rs *states.Resource, opts *StateOpts) *configschema.Block {
+	provider := rs.ProviderConfig.ProviderConfig.Type
+	switch rs.Addr.Mode {
+	case addrs.ManagedResourceMode:
+		return opts.Schemas.ResourceTypeConfig(provider, rs.Addr.Type)
+	case addrs.DataResourceMode:
+		return opts.Schemas.DataSourceConfig(provider, rs.Addr.Type)
+	default:
+		return nil
+	}
+}
+
+// stateTableID returns the "id" attribute of the given object, or an empty
+// string if it has none or its schema isn't available.
+func stateTableID(obj *states.ResourceInstanceObjectSrc, schema *configschema.Block) string {
+	if schema == nil {
+		return ""
+	}
+	if attr, ok := schema.Attributes["id"]; !ok || attr.Type != cty.String {
+		return ""
+	}
+	val, err := obj.Decode(schema.ImpliedType())
+	if err != nil {
+		return ""
+	}
+	id := val.Value.GetAttr("id")
+	if !id.IsKnown() || id.IsNull() {
+		return ""
+	}
+	return id.AsString()
+}
@@ -1,6 +1,11 @@
 package format
 
 import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/addrs"
@@ -76,6 +81,654 @@ func TestState(t *testing.T) {
 	}
 }
 
+func TestState_sort(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, name string, key addrs.InstanceKey) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_resource",
+					Name: name,
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{"woozles":"confuzles"}`),
+				},
+				addrs.ProviderConfig{
+					Type: "test",
+				}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		for _, key := range []addrs.InstanceKey{addrs.IntKey(10), addrs.IntKey(2), addrs.IntKey(1)} {
+			set(addrs.RootModuleInstance, "count", key)
+			set(addrs.RootModuleInstance.Child("child", key), "single", addrs.NoKey)
+		}
+		for _, key := range []addrs.InstanceKey{addrs.StringKey("b"), addrs.StringKey("a10"), addrs.StringKey("a2")} {
+			set(addrs.RootModuleInstance, "each", key)
+		}
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+	})
+
+	want := []string{
+		`test_resource.count[1]`,
+		`test_resource.count[2]`,
+		`test_resource.count[10]`,
+		`test_resource.each["a10"]`,
+		`test_resource.each["a2"]`,
+		`test_resource.each["b"]`,
+		`module.child[1].test_resource.single`,
+		`module.child[2].test_resource.single`,
+		`module.child[10].test_resource.single`,
+	}
+	var gotAddrs []string
+	for _, line := range strings.Split(got, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			gotAddrs = append(gotAddrs, strings.TrimSuffix(strings.TrimPrefix(line, "# "), ": "))
+		}
+	}
+	if !reflect.DeepEqual(gotAddrs, want) {
+		t.Errorf("wrong order\ngot:  %#v\nwant: %#v", gotAddrs, want)
+	}
+}
+
+func TestState_sensitive(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_secret",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"name":"foo","password":"hunter2","credentials":[{"user":"admin","token":"s3cr3t"}]}`),
+			},
+			addrs.ProviderConfig{
+				Type: "test",
+			}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetOutputValue(addrs.OutputValue{Name: "secret"}.Absolute(addrs.RootModuleInstance), cty.StringVal("hunter2"), true)
+	})
+
+	schemas := &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				ResourceTypes: map[string]*configschema.Block{
+					"test_secret": {
+						Attributes: map[string]*configschema.Attribute{
+							"name":     {Type: cty.String, Optional: true},
+							"password": {Type: cty.String, Optional: true, Sensitive: true},
+						},
+						BlockTypes: map[string]*configschema.NestedBlock{
+							"credentials": {
+								Block: configschema.Block{
+									Attributes: map[string]*configschema.Attribute{
+										"user":  {Type: cty.String, Optional: true},
+										"token": {Type: cty.String, Optional: true, Sensitive: true},
+									},
+								},
+								Nesting: configschema.NestingList,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		ShowSensitive bool
+		Want          string
+	}{
+		"redacted": {
+			false,
+			`# test_secret.foo: 
+resource "test_secret" "foo" {
+    name = "foo"
+    password = (sensitive value)
+    credentials {
+        token = (sensitive value)
+        user = "admin"
+    }
+}
+
+
+Outputs:
+
+secret = (sensitive value)`,
+		},
+		"shown": {
+			true,
+			`# test_secret.foo: 
+resource "test_secret" "foo" {
+    name = "foo"
+    password = "hunter2"
+    credentials {
+        token = "s3cr3t"
+        user = "admin"
+    }
+}
+
+
+Outputs:
+
+secret = "hunter2"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := State(&StateOpts{
+				State:         state,
+				Color:         disabledColorize,
+				Schemas:       schemas,
+				ShowSensitive: tc.ShowSensitive,
+			})
+			if got != tc.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestState_moduleOutputs(t *testing.T) {
+	child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_resource",
+				Name: "baz",
+			}.Instance(addrs.NoKey).Absolute(child),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"confuzles"}`),
+			},
+			addrs.ProviderConfig{
+				Type: "test",
+			}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetOutputValue(addrs.OutputValue{Name: "name"}.Absolute(child), cty.StringVal("baz"), false)
+		s.SetOutputValue(addrs.OutputValue{Name: "ids"}.Absolute(child), cty.ListVal([]cty.Value{
+			cty.StringVal("a"),
+			cty.StringVal("b"),
+		}), false)
+		s.SetOutputValue(addrs.OutputValue{Name: "secret"}.Absolute(child), cty.StringVal("hunter2"), true)
+		s.SetOutputValue(addrs.OutputValue{Name: "bar"}.Absolute(addrs.RootModuleInstance), cty.StringVal("bar value"), false)
+		s.SetOutputValue(addrs.OutputValue{Name: "foo"}.Absolute(addrs.RootModuleInstance), cty.StringVal("foo value"), false)
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+	})
+	// The empty root module comes first, leaving only a blank line.
+	want := `
+# module.child.test_resource.baz: 
+resource "test_resource" "baz" {
+    woozles = "confuzles"
+}
+
+Outputs of module.child:
+
+    ids = [
+        "a",
+        "b",
+    ]
+    name = "baz"
+    secret = (sensitive value)
+
+
+Outputs:
+
+bar = "bar value"
+foo = "foo value"`
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestState_objectStatus(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		foo := addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "foo",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+		bar := addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "bar",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+		provider := addrs.ProviderConfig{
+			Type: "test",
+		}.Absolute(addrs.RootModuleInstance)
+
+		s.SetResourceInstanceCurrent(foo, &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectTainted,
+			AttrsJSON: []byte(`{"woozles":"tainted"}`),
+		}, provider)
+		s.SetResourceInstanceCurrent(bar, &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"woozles":"current"}`),
+		}, provider)
+		s.SetResourceInstanceDeposed(bar, states.DeposedKey("deadbeef"), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"woozles":"deposed"}`),
+		}, provider)
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+	})
+	want := `# test_resource.bar: 
+resource "test_resource" "bar" {
+    woozles = "current"
+}
+
+# test_resource.bar: (deposed object deadbeef)
+resource "test_resource" "bar" {
+    woozles = "deposed"
+}
+
+# test_resource.foo: (tainted)
+resource "test_resource" "foo" {
+    woozles = "tainted"
+}
+
+`
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestState_brief(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		provider := addrs.ProviderConfig{
+			Type: "test",
+		}.Absolute(addrs.RootModuleInstance)
+		foo := addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "foo",
+		}.Instance(addrs.IntKey(0)).Absolute(addrs.RootModuleInstance)
+
+		s.SetResourceInstanceCurrent(foo, &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectTainted,
+			AttrsJSON: []byte(`{"woozles":"tainted"}`),
+		}, provider)
+		s.SetResourceInstanceDeposed(foo, states.DeposedKey("deadbeef"), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"woozles":"deposed"}`),
+		}, provider)
+		s.SetResourceInstanceCurrent(addrs.Resource{
+			Mode: addrs.DataResourceMode,
+			Type: "test_data_source",
+			Name: "bar",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"compute":"data"}`),
+		}, provider)
+		child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+		s.SetResourceInstanceCurrent(addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "baz",
+		}.Instance(addrs.NoKey).Absolute(child), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"woozles":"child"}`),
+		}, provider)
+		s.SetOutputValue(addrs.OutputValue{Name: "out"}.Absolute(addrs.RootModuleInstance), cty.StringVal("output"), false)
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+		Summary: true,
+		Brief:   true,
+	})
+	want := `data.test_data_source.bar (test_data_source)
+test_resource.foo[0] (test_resource, tainted)
+test_resource.foo[0] (test_resource, deposed object deadbeef)
+
+module.child.test_resource.baz (test_resource)
+
+2 managed resources and 1 data source across 2 modules.`
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestState_undecodable(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		provider := addrs.ProviderConfig{
+			Type: "test",
+		}.Absolute(addrs.RootModuleInstance)
+
+		// An object whose attributes were cut short, as if a failed apply
+		// left it incomplete.
+		s.SetResourceInstanceCurrent(addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "bar",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectTainted,
+			AttrsJSON: []byte(`{"woozles":`),
+		}, provider)
+		s.SetResourceInstanceCurrent(addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_resource",
+			Name: "foo",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance), &states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"woozles":"ready"}`),
+		}, provider)
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+	})
+	want := `# test_resource.bar: (tainted)
+resource "test_resource" "bar" {
+    # error decoding object: `
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("undecodable object not flagged\n%s", got)
+	}
+	want = `}
+
+# test_resource.foo: 
+resource "test_resource" "foo" {
+    woozles = "ready"
+}
+
+`
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("rest of the state not shown\n%s", got)
+	}
+}
+
+func TestState_maxValueLen(t *testing.T) {
+	big := strings.Repeat("x", 100*1024)
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_resource",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"` + big + `"}`),
+			},
+			addrs.ProviderConfig{
+				Type: "test",
+			}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetOutputValue(addrs.OutputValue{Name: "short"}.Absolute(addrs.RootModuleInstance), cty.StringVal("short"), false)
+		s.SetOutputValue(addrs.OutputValue{Name: "utf8"}.Absolute(addrs.RootModuleInstance), cty.StringVal("abcdéf"), false)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		got := State(&StateOpts{
+			State:       state,
+			Color:       disabledColorize,
+			Schemas:     testSchemas(),
+			MaxValueLen: 5,
+		})
+		want := `# test_resource.foo: 
+resource "test_resource" "foo" {
+    woozles = "xxxxx"… (truncated, 102400 bytes)
+}
+
+
+Outputs:
+
+short = "short"
+utf8 = "abcd"… (truncated, 7 bytes)`
+		if got != want {
+			t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		got := State(&StateOpts{
+			State:   state,
+			Color:   disabledColorize,
+			Schemas: testSchemas(),
+		})
+		if !strings.Contains(got, `woozles = "`+big+`"`) {
+			t.Errorf("value was truncated")
+		}
+	})
+}
+
+func TestStateTable(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		provider := addrs.ProviderConfig{
+			Type: "test",
+		}.Absolute(addrs.RootModuleInstance)
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, name string, key addrs.InstanceKey, status states.ObjectStatus, attrs string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: "test_resource",
+					Name: name,
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    status,
+					AttrsJSON: []byte(attrs),
+				},
+				provider,
+			)
+		}
+		child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "foo", addrs.IntKey(10), states.ObjectReady, `{"id":"foo-10","woozles":"a"}`)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "foo", addrs.IntKey(2), states.ObjectTainted, `{"id":"foo-2","woozles":"b"}`)
+		set(addrs.RootModuleInstance, addrs.DataResourceMode, "bar", addrs.NoKey, states.ObjectReady, `{"id":"bar","woozles":"c"}`)
+		set(child, addrs.ManagedResourceMode, "baz", addrs.NoKey, states.ObjectReady, `{"id":null,"woozles":"d"}`)
+		s.SetResourceInstanceDeposed(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_resource",
+				Name: "foo",
+			}.Instance(addrs.IntKey(2)).Absolute(addrs.RootModuleInstance),
+			states.DeposedKey("deadbeef"),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"foo-2-old","woozles":"e"}`),
+			},
+			provider,
+		)
+	})
+
+	got := StateTable(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+	})
+	want := strings.Join([]string{
+		"ADDRESS                         TYPE           ID         STATUS",
+		"data.test_resource.bar          test_resource             ready",
+		"test_resource.foo[2]            test_resource  foo-2      tainted",
+		"test_resource.foo[2]            test_resource  foo-2-old  deposed deadbeef",
+		"test_resource.foo[10]           test_resource  foo-10     ready",
+		"module.child.test_resource.baz  test_resource             ready",
+	}, "\n")
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = StateTable(&StateOpts{
+		State:   states.NewState(),
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+	})
+	if want := "The state file is empty. No resources are represented."; got != want {
+		t.Errorf("wrong result for an empty state\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestState_summary(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, typeName string, key addrs.InstanceKey) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: typeName,
+					Name: "foo",
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{}`),
+				},
+				addrs.ProviderConfig{
+					Type: "test",
+				}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "test_resource", addrs.IntKey(0))
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "test_resource", addrs.IntKey(1))
+		set(child, addrs.ManagedResourceMode, "test_resource", addrs.StringKey("a"))
+		set(child, addrs.DataResourceMode, "test_data_source", addrs.NoKey)
+	})
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Summary: true,
+	})
+	want := "3 managed resources and 1 data source across 2 modules."
+	if !strings.HasSuffix(got, "\n\n"+want) {
+		t.Errorf("wrong summary\ngot:\n%s\nwant suffix: %s", got, want)
+	}
+
+	got = State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+	})
+	if strings.Contains(got, "across") {
+		t.Errorf("summary included without Summary option\n%s", got)
+	}
+}
+
+func TestState_window(t *testing.T) {
+	child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, key addrs.InstanceKey) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_resource",
+					Name: "foo",
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{}`),
+				},
+				addrs.ProviderConfig{
+					Type: "test",
+				}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		set(addrs.RootModuleInstance, addrs.IntKey(0))
+		set(addrs.RootModuleInstance, addrs.IntKey(1))
+		set(addrs.RootModuleInstance, addrs.IntKey(2))
+		set(child, addrs.IntKey(0))
+		set(child, addrs.IntKey(1))
+	})
+
+	tests := map[string]struct {
+		offset, limit int
+		want          []string
+		footer        string
+	}{
+		"first page": {
+			0, 2,
+			[]string{"test_resource.foo[0]", "test_resource.foo[1]"},
+			"Showing resource instances 1-2 of 5.",
+		},
+		"across modules": {
+			2, 2,
+			[]string{"test_resource.foo[2]", "module.child.test_resource.foo[0]"},
+			"Showing resource instances 3-4 of 5.",
+		},
+		"last page": {
+			4, 2,
+			[]string{"module.child.test_resource.foo[1]"},
+			"Showing resource instances 5-5 of 5.",
+		},
+		"offset only": {
+			3, 0,
+			[]string{"module.child.test_resource.foo[0]", "module.child.test_resource.foo[1]"},
+			"Showing resource instances 4-5 of 5.",
+		},
+		"past the end": {
+			10, 2,
+			nil,
+			"Showing no resource instances, since the offset 10 is past the last of 5.",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := State(&StateOpts{
+				State:   state,
+				Color:   disabledColorize,
+				Schemas: testSchemas(),
+				Sort:    true,
+				Offset:  test.offset,
+				Limit:   test.limit,
+				Summary: true,
+			})
+
+			var shown []string
+			for _, line := range strings.Split(got, "\n") {
+				if strings.HasPrefix(line, "# ") {
+					shown = append(shown, strings.TrimSuffix(strings.TrimPrefix(line, "# "), ": "))
+				}
+			}
+			if !reflect.DeepEqual(shown, test.want) {
+				t.Errorf("wrong resource instances\ngot:  %#v\nwant: %#v\n%s", shown, test.want, got)
+			}
+
+			// The footer comes before the summary, which counts the whole
+			// state.
+			want := test.footer + "\n\n5 managed resources and 0 data sources across 2 modules."
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("wrong footer\ngot:\n%s\nwant suffix:\n%s", got, want)
+			}
+		})
+	}
+
+	got := State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+	})
+	if strings.Contains(got, "Showing") {
+		t.Errorf("footer included without Offset or Limit\n%s", got)
+	}
+}
+
 func testProvider() *terraform.MockProvider {
 	p := new(terraform.MockProvider)
 	p.ReadResourceFn = func(req providers.ReadResourceRequest) providers.ReadResourceResponse {
@@ -132,3 +785,241 @@ resource "test_resource" "baz" {
 Outputs:
 
 bar = "bar value"`
+
+func TestState_hideDataSources(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, typeName string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: typeName,
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{}`),
+				},
+				addrs.ProviderConfig{
+					Type: "test",
+				}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "test_resource")
+		set(addrs.RootModuleInstance, addrs.DataResourceMode, "test_data_source")
+
+		// This module contains only a data resource, so it should disappear
+		// altogether.
+		set(addrs.RootModuleInstance.Child("lookup", addrs.NoKey), addrs.DataResourceMode, "test_data_source")
+	})
+
+	got := State(&StateOpts{
+		State:           state,
+		Color:           disabledColorize,
+		Schemas:         testSchemas(),
+		HideDataSources: true,
+	})
+	if !strings.Contains(got, "# test_resource.foo:") {
+		t.Errorf("managed resource is missing\n%s", got)
+	}
+	if strings.Contains(got, "data.") || strings.Contains(got, "module.lookup") {
+		t.Errorf("data resources are not hidden\n%s", got)
+	}
+
+	got = State(&StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+	})
+	if !strings.Contains(got, "# module.lookup.data.test_data_source.foo:") {
+		t.Errorf("data resource is missing without HideDataSources\n%s", got)
+	}
+}
+
+func TestStreamState(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_resource",
+				Name: "baz",
+			}.Instance(addrs.IntKey(0)).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"confuzles"}`),
+			},
+			addrs.ProviderConfig{
+				Type: "test",
+			}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetOutputValue(addrs.OutputValue{Name: "bar"}.Absolute(addrs.RootModuleInstance), cty.StringVal("bar value"), false)
+	})
+	color := &colorstring.Colorize{
+		Colors: colorstring.DefaultColors,
+		Reset:  true,
+	}
+
+	var buf bytes.Buffer
+	if err := StreamState(&buf, &StateOpts{
+		State:   state,
+		Color:   color,
+		Schemas: testSchemas(),
+		Summary: true,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The trailing whitespace is trimmed and the reset code is written just
+	// once at the end, as if the rendering were colorized as a whole.
+	want := color.Color(`[reset]# test_resource.baz[0]: 
+resource "test_resource" "baz" {
+    woozles = "confuzles"
+}
+
+[reset]
+Outputs:
+
+bar = "bar value"
+
+1 managed resource and 0 data sources across 1 module.`)
+	if got := buf.String(); got != want {
+		t.Errorf("wrong result\ngot:  %q\nwant: %q", got, want)
+	}
+
+	err := StreamState(errWriter{}, &StateOpts{
+		State:   state,
+		Color:   color,
+		Schemas: testSchemas(),
+	})
+	if err != errWrite {
+		t.Errorf("wrong error %v; want %v", err, errWrite)
+	}
+}
+
+func TestState_writer(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetOutputValue(addrs.OutputValue{Name: "bar"}.Absolute(addrs.RootModuleInstance), cty.StringVal("bar value"), false)
+	})
+	opts := &StateOpts{
+		State: state,
+		Color: &colorstring.Colorize{
+			Colors:  colorstring.DefaultColors,
+			Disable: true,
+		},
+		Schemas: testSchemas(),
+	}
+	want := State(opts)
+
+	var buf bytes.Buffer
+	opts.Writer = &buf
+	if got := State(opts); got != "" {
+		t.Errorf("State returned %q; want empty string when Writer is set", got)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("wrong result written\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+var errWrite = errors.New("write failed")
+
+// errWriter is an io.Writer that always fails.
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+// The two benchmarks below render the same state of 10,000 resource
+// instances, one building the whole rendering as a string and the other
+// streaming it. Compare them with -benchmem to see the difference in the
+// memory allocated per rendering.
+func BenchmarkState(b *testing.B) {
+	opts := benchmarkStateOpts(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		State(opts)
+	}
+}
+
+func BenchmarkStreamState(b *testing.B) {
+	opts := benchmarkStateOpts(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StreamState(ioutil.Discard, opts)
+	}
+}
+
+func BenchmarkDecodeStateObjects(b *testing.B) {
+	opts := benchmarkStateOpts(10000)
+	objs := testStateObjects(opts.State)
+
+	for name, workers := range map[string]int{"sequential": 1, "parallel": 0} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				decodeStateObjects(objs, opts.Schemas, workers)
+			}
+		})
+	}
+}
+
+func TestDecodeStateObjects_workers(t *testing.T) {
+	opts := benchmarkStateOpts(1000)
+	opts.State.ResourceInstance(addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_resource",
+		Name: "foo",
+	}.Instance(addrs.IntKey(10)).Absolute(addrs.RootModuleInstance)).Current.AttrsJSON = []byte(`not json`)
+
+	objs := testStateObjects(opts.State)
+	want := append([]stateObject(nil), objs...)
+	decodeStateObjects(want, opts.Schemas, 1)
+	for _, workers := range []int{2, 7, 64} {
+		got := append([]stateObject(nil), objs...)
+		decodeStateObjects(got, opts.Schemas, workers)
+		for i := range got {
+			if (got[i].err == nil) != (want[i].err == nil) || (want[i].err == nil && !got[i].val.RawEquals(want[i].val)) {
+				t.Fatalf("result for %s with %d workers differs from the sequential result", got[i].addr, workers)
+			}
+		}
+	}
+}
+
+// testStateObjects returns the current objects of the root module of the
+// given state, undecoded.
+func testStateObjects(s *states.State) []stateObject {
+	var objs []stateObject
+	for _, rs := range s.RootModule().Resources {
+		for k, is := range rs.Instances {
+			objs = append(objs, stateObject{rs: rs, addr: rs.Addr.Absolute(addrs.RootModuleInstance).Instance(k), obj: is.Current})
+		}
+	}
+	return objs
+}
+
+func benchmarkStateOpts(n int) *StateOpts {
+	state := states.BuildState(func(s *states.SyncState) {
+		for i := 0; i < n; i++ {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_resource",
+					Name: "foo",
+				}.Instance(addrs.IntKey(i)).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{"id":"foo","foo":"bar","woozles":"confuzles"}`),
+				},
+				addrs.ProviderConfig{
+					Type: "test",
+				}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+	return &StateOpts{
+		State:   state,
+		Color:   disabledColorize,
+		Schemas: testSchemas(),
+		Sort:    true,
+	}
+}
@@ -0,0 +1,297 @@
+package jsonconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// config is the top-level representation of the json format of a
+// configuration.
+type config struct {
+	// ProviderConfigs are the provider configurations of all of the modules,
+	// keyed by their addresses, such as "provider.aws.east" in the root
+	// module or "module.child.provider.aws" in a child module.
+	ProviderConfigs map[string]providerConfig `json:"provider_config,omitempty"`
+
+	RootModule module `json:"root_module"`
+}
+
+// providerConfig is the representation of a provider block.
+type providerConfig struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+
+	// ModuleAddress is the address of the module containing the block,
+	// omitted for the root module.
+	ModuleAddress string `json:"module_address,omitempty"`
+
+	VersionConstraint string      `json:"version_constraint,omitempty"`
+	Expressions       expressions `json:"expressions,omitempty"`
+}
+
+// module is the representation of a module in the configuration, which can
+// be the root module or a module called by another.
+type module struct {
+	Outputs     map[string]output     `json:"outputs,omitempty"`
+	Resources   []resource            `json:"resources,omitempty"`
+	ModuleCalls map[string]moduleCall `json:"module_calls,omitempty"`
+	Variables   map[string]variable   `json:"variables,omitempty"`
+}
+
+// moduleCall is the representation of a module block, along with the module
+// that it calls.
+type moduleCall struct {
+	Source            string      `json:"source"`
+	VersionConstraint string      `json:"version_constraint,omitempty"`
+	Expressions       expressions `json:"expressions,omitempty"`
+	CountExpression   *expression `json:"count_expression,omitempty"`
+	ForEachExpression *expression `json:"for_each_expression,omitempty"`
+	DependsOn         []string    `json:"depends_on,omitempty"`
+
+	// Module is the called module, which is omitted if it isn't part of the
+	// configuration, such as if it hasn't been installed.
+	Module *module `json:"module,omitempty"`
+}
+
+// variable is the representation of a variable block.
+type variable struct {
+	// Default is the default value, which is omitted if there is none.
+	Default     json.RawMessage `json:"default,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
+
+// output is the representation of an output block.
+type output struct {
+	Sensitive   bool       `json:"sensitive,omitempty"`
+	Expression  expression `json:"expression"`
+	Description string     `json:"description,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+}
+
+// resource is the representation of a resource or data block.
+type resource struct {
+	// Address is the address of the resource relative to the module that
+	// it's in, such as "aws_instance.foo", since the configuration of a
+	// module is the same for each of its instances.
+	Address string `json:"address"`
+
+	// Mode can be "managed" or "data".
+	Mode string `json:"mode"`
+
+	Type string `json:"type"`
+	Name string `json:"name"`
+
+	// ProviderConfigKey is the address of the provider configuration that
+	// the resource uses, relative to its module, such as "provider.aws".
+	ProviderConfigKey string `json:"provider_config_key"`
+
+	// SchemaVersion is the version of the resource type schema that the
+	// expressions were decoded with. It is omitted if the schema isn't
+	// available, in which case Expressions has only the attributes.
+	SchemaVersion *uint64 `json:"schema_version,omitempty"`
+
+	Expressions       expressions `json:"expressions,omitempty"`
+	CountExpression   *expression `json:"count_expression,omitempty"`
+	ForEachExpression *expression `json:"for_each_expression,omitempty"`
+	DependsOn         []string    `json:"depends_on,omitempty"`
+}
+
+// Marshal returns the json encoding of the given configuration: its
+// modules, starting with the root module and following the module calls of
+// each, with the arguments of each block as unevaluated expressions.
+//
+// The expressions of resource and provider blocks are decoded with the
+// given schemas, so that nested blocks are included and the constant values
+// of sensitive attributes are left out, as are those of sensitive outputs.
+//
+// If noValues is set, all of the constant values and the defaults of the
+// variables are left out, keeping only the structure of the configuration
+// and the references between its objects.
+func Marshal(c *configs.Config, schemas *terraform.Schemas, noValues bool) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no configuration given")
+	}
+
+	output := config{
+		ProviderConfigs: make(map[string]providerConfig),
+	}
+	marshalProviderConfigs(c, schemas, noValues, output.ProviderConfigs)
+	output.RootModule = marshalModule(c, schemas, noValues)
+	if len(output.ProviderConfigs) == 0 {
+		output.ProviderConfigs = nil
+	}
+
+	return json.Marshal(output)
+}
+
+// marshalProviderConfigs adds the provider configurations of the given
+// module and its descendents to m, keyed as described for ProviderConfigs.
+func marshalProviderConfigs(c *configs.Config, schemas *terraform.Schemas, noValues bool, m map[string]providerConfig) {
+	for _, p := range c.Module.ProviderConfigs {
+		var schema *configschema.Block
+		if schemas != nil {
+			schema = schemas.ProviderConfig(p.Name)
+		}
+		key := p.Addr().String()
+		modAddr := moduleAddress(c.Path)
+		if modAddr != "" {
+			key = modAddr + "." + key
+		}
+		m[key] = providerConfig{
+			Name:              p.Name,
+			Alias:             p.Alias,
+			ModuleAddress:     modAddr,
+			VersionConstraint: p.Version.Required.String(),
+			Expressions:       marshalExpressions(p.Config, schema, noValues),
+		}
+	}
+	for _, child := range c.Children {
+		marshalProviderConfigs(child, schemas, noValues, m)
+	}
+}
+
+// moduleAddress returns the address of the module at the given path, such as
+// "module.child.module.grandchild", or an empty string for the root module.
+// The path has no instance keys, since every instance of a module has the
+// same configuration.
+func moduleAddress(path addrs.Module) string {
+	parts := make([]string, 0, len(path)*2)
+	for _, name := range path {
+		parts = append(parts, "module", name)
+	}
+	return strings.Join(parts, ".")
+}
+
+func marshalModule(c *configs.Config, schemas *terraform.Schemas, noValues bool) module {
+	var ret module
+	mod := c.Module
+
+	if len(mod.Variables) > 0 {
+		ret.Variables = make(map[string]variable, len(mod.Variables))
+		for name, v := range mod.Variables {
+			var def json.RawMessage
+			if v.Default != cty.NilVal && !v.Default.IsNull() && !noValues {
+				def, _ = ctyjson.Marshal(v.Default, v.Default.Type())
+			}
+			ret.Variables[name] = variable{
+				Default:     def,
+				Description: v.Description,
+			}
+		}
+	}
+
+	if len(mod.Outputs) > 0 {
+		ret.Outputs = make(map[string]output, len(mod.Outputs))
+		for name, o := range mod.Outputs {
+			ret.Outputs[name] = output{
+				Sensitive:   o.Sensitive,
+				Expression:  marshalExpression(o.Expr, o.Sensitive || noValues),
+				Description: o.Description,
+				DependsOn:   marshalDependsOn(o.DependsOn),
+			}
+		}
+	}
+
+	for _, r := range mod.ManagedResources {
+		ret.Resources = append(ret.Resources, marshalResource(r, schemas, noValues))
+	}
+	for _, r := range mod.DataResources {
+		ret.Resources = append(ret.Resources, marshalResource(r, schemas, noValues))
+	}
+	sort.Slice(ret.Resources, func(i, j int) bool {
+		return ret.Resources[i].Address < ret.Resources[j].Address
+	})
+
+	if len(mod.ModuleCalls) > 0 {
+		ret.ModuleCalls = make(map[string]moduleCall, len(mod.ModuleCalls))
+		for name, mc := range mod.ModuleCalls {
+			call := moduleCall{
+				Source:            mc.SourceAddr,
+				VersionConstraint: mc.Version.Required.String(),
+				Expressions:       marshalExpressions(mc.Config, nil, noValues),
+				CountExpression:   marshalOptionalExpression(mc.Count, noValues),
+				ForEachExpression: marshalOptionalExpression(mc.ForEach, noValues),
+				DependsOn:         marshalDependsOn(mc.DependsOn),
+			}
+			if child, ok := c.Children[name]; ok {
+				childModule := marshalModule(child, schemas, noValues)
+				call.Module = &childModule
+			}
+			ret.ModuleCalls[name] = call
+		}
+	}
+
+	return ret
+}
+
+func marshalResource(r *configs.Resource, schemas *terraform.Schemas, noValues bool) resource {
+	ret := resource{
+		Address:           r.Addr().String(),
+		Type:              r.Type,
+		Name:              r.Name,
+		ProviderConfigKey: r.ProviderConfigAddr().String(),
+		CountExpression:   marshalOptionalExpression(r.Count, noValues),
+		ForEachExpression: marshalOptionalExpression(r.ForEach, noValues),
+		DependsOn:         marshalDependsOn(r.DependsOn),
+	}
+
+	var schema *configschema.Block
+	provider := r.ProviderConfigAddr().Type
+	switch r.Mode {
+	case addrs.ManagedResourceMode:
+		ret.Mode = "managed"
+		if schemas != nil {
+			schema = schemas.ResourceTypeConfig(provider, r.Type)
+		}
+	case addrs.DataResourceMode:
+		ret.Mode = "data"
+		if schemas != nil {
+			schema = schemas.DataSourceConfig(provider, r.Type)
+		}
+	}
+	if schema != nil {
+		var version uint64
+		if ps := schemas.ProviderSchema(provider); ps != nil && r.Mode == addrs.ManagedResourceMode {
+			version = ps.ResourceTypeSchemaVersions[r.Type]
+		}
+		ret.SchemaVersion = &version
+	}
+
+	ret.Expressions = marshalExpressions(r.Config, schema, noValues)
+	return ret
+}
+
+// marshalOptionalExpression returns the representation of the given
+// expression, as for marshalExpression, or nil if the argument wasn't set.
+func marshalOptionalExpression(expr hcl.Expression, omitValue bool) *expression {
+	if expr == nil {
+		return nil
+	}
+	ret := marshalExpression(expr, omitValue)
+	return &ret
+}
+
+// marshalDependsOn returns the addresses that the given depends_on
+// traversals refer to, leaving out any that are invalid.
+func marshalDependsOn(traversals []hcl.Traversal) []string {
+	var ret []string
+	for _, traversal := range traversals {
+		ref, diags := addrs.ParseRef(traversal)
+		if diags.HasErrors() {
+			continue
+		}
+		ret = append(ret, ref.Subject.String())
+	}
+	return ret
+}
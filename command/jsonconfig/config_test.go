@@ -0,0 +1,246 @@
+package jsonconfig
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestMarshal(t *testing.T) {
+	src, err := Marshal(testConfig(t, "testdata/basic"), testSchemas(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// The constant values of the sensitive attribute and output are left
+	// out, but not those of the other arguments.
+	wantSrc := `{
+  "provider_config": {
+    "module.child.provider.test.other": {
+      "alias": "other",
+      "expressions": {
+        "region": {
+          "constant_value": "eu-west-1"
+        }
+      },
+      "module_address": "module.child",
+      "name": "test"
+    },
+    "provider.test": {
+      "expressions": {
+        "region": {
+          "constant_value": "us-east-1"
+        }
+      },
+      "name": "test"
+    }
+  },
+  "root_module": {
+    "module_calls": {
+      "child": {
+        "expressions": {
+          "input": {
+            "references": [
+              "test_thing.foo[0]"
+            ]
+          }
+        },
+        "module": {
+          "resources": [
+            {
+              "address": "test_thing.baz",
+              "expressions": {
+                "woozles": {
+                  "references": [
+                    "var.input"
+                  ]
+                }
+              },
+              "mode": "managed",
+              "name": "baz",
+              "provider_config_key": "provider.test.other",
+              "schema_version": 2,
+              "type": "test_thing"
+            }
+          ],
+          "variables": {
+            "input": {}
+          }
+        },
+        "source": "./child"
+      }
+    },
+    "outputs": {
+      "id": {
+        "expression": {
+          "references": [
+            "test_thing.foo[0]"
+          ]
+        }
+      },
+      "secret": {
+        "expression": {},
+        "sensitive": true
+      }
+    },
+    "resources": [
+      {
+        "address": "data.test_data.bar",
+        "depends_on": [
+          "test_thing.foo"
+        ],
+        "expressions": {
+          "id": {
+            "references": [
+              "test_thing.foo[0]"
+            ]
+          }
+        },
+        "mode": "data",
+        "name": "bar",
+        "provider_config_key": "provider.test",
+        "schema_version": 0,
+        "type": "test_data"
+      },
+      {
+        "address": "test_thing.foo",
+        "count_expression": {
+          "constant_value": 2
+        },
+        "expressions": {
+          "nested": [
+            {
+              "value": {
+                "constant_value": "a"
+              }
+            }
+          ],
+          "secret": {},
+          "woozles": {
+            "references": [
+              "var.name",
+              "count.index"
+            ]
+          }
+        },
+        "mode": "managed",
+        "name": "foo",
+        "provider_config_key": "provider.test",
+        "schema_version": 2,
+        "type": "test_thing"
+      }
+    ],
+    "variables": {
+      "name": {
+        "default": "web",
+        "description": "The name of the things."
+      }
+    }
+  }
+}`
+	if err := json.Unmarshal([]byte(wantSrc), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMarshal_noValues(t *testing.T) {
+	src, err := Marshal(testConfig(t, "testdata/basic"), testSchemas(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(string(src), "constant_value") || strings.Contains(string(src), "default") {
+		t.Errorf("values included with noValues\n%s", src)
+	}
+	if !strings.Contains(string(src), `"references":["var.input"]`) {
+		t.Errorf("references not included with noValues\n%s", src)
+	}
+}
+
+func TestMarshal_noConfig(t *testing.T) {
+	if _, err := Marshal(nil, testSchemas(), false); err == nil {
+		t.Fatal("succeeded; want error")
+	}
+}
+
+// testConfig returns the configuration in the given directory, loading each
+// of its child modules from the local path given as its source.
+func testConfig(t *testing.T, dir string) *configs.Config {
+	t.Helper()
+
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	walker := configs.ModuleWalkerFunc(func(req *configs.ModuleRequest) (*configs.Module, *version.Version, hcl.Diagnostics) {
+		mod, diags := parser.LoadConfigDir(filepath.Join(dir, req.SourceAddr))
+		return mod, nil, diags
+	})
+	config, moreDiags := configs.BuildConfig(mod, walker)
+	if moreDiags.HasErrors() {
+		t.Fatal(moreDiags.Error())
+	}
+	return config
+}
+
+func testSchemas() *terraform.Schemas {
+	return &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				Provider: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"region": {Type: cty.String, Optional: true},
+					},
+				},
+				ResourceTypes: map[string]*configschema.Block{
+					"test_thing": {
+						Attributes: map[string]*configschema.Attribute{
+							"id":      {Type: cty.String, Computed: true},
+							"woozles": {Type: cty.String, Optional: true},
+							"secret":  {Type: cty.String, Optional: true, Sensitive: true},
+						},
+						BlockTypes: map[string]*configschema.NestedBlock{
+							"nested": {
+								Nesting: configschema.NestingList,
+								Block: configschema.Block{
+									Attributes: map[string]*configschema.Attribute{
+										"value": {Type: cty.String, Optional: true},
+									},
+								},
+							},
+						},
+					},
+				},
+				DataSources: map[string]*configschema.Block{
+					"test_data": {
+						Attributes: map[string]*configschema.Attribute{
+							"id": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+				ResourceTypeSchemaVersions: map[string]uint64{
+					"test_thing": 2,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,3 @@
+// Package jsonconfig implements methods for outputting a configuration in a
+// machine-readable json format
+package jsonconfig
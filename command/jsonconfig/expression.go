@@ -0,0 +1,122 @@
+package jsonconfig
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcldec"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/lang"
+)
+
+// expression is the representation of an expression in the configuration,
+// which is left unevaluated.
+type expression struct {
+	// ConstantValue is the value of an expression that refers to nothing,
+	// in which case its value is known without evaluating it. It is omitted
+	// for other expressions, and for values that are left out.
+	ConstantValue json.RawMessage `json:"constant_value,omitempty"`
+
+	// References are the addresses of the objects that the expression
+	// refers to, such as "var.name" or "aws_instance.foo", relative to the
+	// module the expression is in.
+	References []string `json:"references,omitempty"`
+}
+
+// marshalExpression returns the representation of the given expression,
+// leaving out its constant value if omitValue is set.
+func marshalExpression(expr hcl.Expression, omitValue bool) expression {
+	var ret expression
+	if expr == nil {
+		return ret
+	}
+
+	refs, _ := lang.ReferencesInExpr(expr)
+	for _, ref := range refs {
+		ret.References = append(ret.References, ref.Subject.String())
+	}
+
+	if len(refs) == 0 && !omitValue {
+		val, diags := expr.Value(nil)
+		if !diags.HasErrors() && val.IsWhollyKnown() {
+			if src, err := ctyjson.Marshal(val, val.Type()); err == nil {
+				ret.ConstantValue = src
+			}
+		}
+	}
+	return ret
+}
+
+// expressions is the representation of the arguments of a block, keyed by
+// argument name. Each value is an expression for an attribute, or for a
+// nested block, another expressions value, or a list or map of them
+// depending on how the block type is nested.
+type expressions map[string]interface{}
+
+// marshalExpressions returns the representation of the arguments of the
+// given body, as decoded with the given schema. The constant values of
+// attributes that the schema marks as sensitive are left out, as are all
+// constant values if noValues is set.
+//
+// Without a schema, only the attributes of the body are included, none of
+// them sensitive. Nested blocks of types the schema doesn't describe, such
+// as dynamic blocks, are left out.
+func marshalExpressions(body hcl.Body, schema *configschema.Block, noValues bool) expressions {
+	if body == nil {
+		return nil
+	}
+
+	ret := make(expressions)
+	if schema == nil {
+		attrs, _ := body.JustAttributes()
+		for name, attr := range attrs {
+			ret[name] = marshalExpression(attr.Expr, noValues)
+		}
+		return ret
+	}
+
+	// The expressions are wanted unevaluated, so the body is decoded with
+	// the low-level schema rather than with the decoder spec itself.
+	content, _, _ := body.PartialContent(hcldec.ImpliedSchema(schema.DecoderSpec()))
+	if content == nil {
+		return nil
+	}
+
+	for name, attr := range content.Attributes {
+		omitValue := noValues
+		if attrS, ok := schema.Attributes[name]; ok && attrS.Sensitive {
+			omitValue = true
+		}
+		ret[name] = marshalExpression(attr.Expr, omitValue)
+	}
+
+	for _, block := range content.Blocks {
+		blockS, ok := schema.BlockTypes[block.Type]
+		if !ok {
+			continue
+		}
+		nested := marshalExpressions(block.Body, &blockS.Block, noValues)
+
+		switch blockS.Nesting {
+		case configschema.NestingSingle:
+			ret[block.Type] = nested
+		case configschema.NestingList, configschema.NestingSet:
+			list, _ := ret[block.Type].([]expressions)
+			ret[block.Type] = append(list, nested)
+		case configschema.NestingMap:
+			m, ok := ret[block.Type].(map[string]expressions)
+			if !ok {
+				m = make(map[string]expressions)
+				ret[block.Type] = m
+			}
+			// A block of a map nesting type always has its key as its only
+			// label.
+			if len(block.Labels) > 0 {
+				m[block.Labels[0]] = nested
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,112 @@
+package jsonconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+func TestMarshalExpressions(t *testing.T) {
+	src := `
+plain = "a"
+ref   = var.name
+token = "secret"
+
+sub {
+  n = 1
+}
+
+labeled "x" {
+  n = 2
+}
+labeled "y" {
+  n = local.n
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(src), "test.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	nested := configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"n": {Type: cty.Number, Optional: true},
+		},
+	}
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"plain": {Type: cty.String, Optional: true},
+			"ref":   {Type: cty.String, Optional: true},
+			"token": {Type: cty.String, Optional: true, Sensitive: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"sub":     {Nesting: configschema.NestingSingle, Block: nested},
+			"labeled": {Nesting: configschema.NestingMap, Block: nested},
+		},
+	}
+
+	tests := map[string]struct {
+		schema   *configschema.Block
+		noValues bool
+		want     string
+	}{
+		"schema": {
+			schema, false,
+			`{
+				"plain": {"constant_value": "a"},
+				"ref": {"references": ["var.name"]},
+				"token": {},
+				"sub": {"n": {"constant_value": 1}},
+				"labeled": {
+					"x": {"n": {"constant_value": 2}},
+					"y": {"n": {"references": ["local.n"]}}
+				}
+			}`,
+		},
+		"no values": {
+			schema, true,
+			`{
+				"plain": {},
+				"ref": {"references": ["var.name"]},
+				"token": {},
+				"sub": {"n": {}},
+				"labeled": {
+					"x": {"n": {}},
+					"y": {"n": {"references": ["local.n"]}}
+				}
+			}`,
+		},
+		"no schema": {
+			nil, false,
+			`{
+				"plain": {"constant_value": "a"},
+				"ref": {"references": ["var.name"]},
+				"token": {"constant_value": "secret"}
+			}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			src, err := json.Marshal(marshalExpressions(file.Body, test.schema, test.noValues))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(src, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", src, test.want)
+			}
+		})
+	}
+}
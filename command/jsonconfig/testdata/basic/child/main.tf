@@ -0,0 +1,12 @@
+variable "input" {
+}
+
+provider "test" {
+  alias  = "other"
+  region = "eu-west-1"
+}
+
+resource "test_thing" "baz" {
+  provider = test.other
+  woozles  = var.input
+}
@@ -0,0 +1,37 @@
+variable "name" {
+  default     = "web"
+  description = "The name of the things."
+}
+
+provider "test" {
+  region = "us-east-1"
+}
+
+resource "test_thing" "foo" {
+  count   = 2
+  woozles = "${var.name}-${count.index}"
+  secret  = "hunter2"
+
+  nested {
+    value = "a"
+  }
+}
+
+data "test_data" "bar" {
+  id         = test_thing.foo[0].id
+  depends_on = [test_thing.foo]
+}
+
+module "child" {
+  source = "./child"
+  input  = test_thing.foo[0].id
+}
+
+output "id" {
+  value = test_thing.foo[0].id
+}
+
+output "secret" {
+  value     = "shh"
+  sensitive = true
+}
@@ -0,0 +1,157 @@
+package jsonplan
+
+import (
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// attributeChange describes the change to a single leaf value within an
+// object, for UIs that want to show only the parts of an object that are
+// changing.
+type attributeChange struct {
+	// Path is the path to the value within the object, as a sequence of
+	// steps in the manner of cty.Path: a string for each attribute name or
+	// map key, and a number for each list or tuple index.
+	Path []interface{} `json:"path"`
+
+	// Action is "add" if the value is absent or null before the change,
+	// "remove" if it is absent or null after the change, or "update"
+	// otherwise.
+	Action string `json:"action"`
+
+	// Unknown is true if the value after the change will not be known until
+	// after apply, in which case the action is always "update".
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// marshalAttributeChanges returns the changes to each of the leaf values
+// that differ between the given before and after values, either of which
+// may be null or cty.NilVal, sorted by path.
+//
+// Objects, maps, lists and tuples are traversed so that each changing leaf
+// is reported separately. A set has no stable way to identify its elements,
+// so a changing set is reported as a whole.
+func marshalAttributeChanges(before, after cty.Value) []attributeChange {
+	return attributeChanges([]interface{}{}, before, after)
+}
+
+func attributeChanges(path []interface{}, before, after cty.Value) []attributeChange {
+	beforeNull := before == cty.NilVal || before.IsNull()
+	afterNull := after == cty.NilVal || after.IsNull()
+
+	switch {
+	case after != cty.NilVal && !after.IsKnown():
+		return []attributeChange{{Path: path, Action: "update", Unknown: true}}
+	case beforeNull && afterNull:
+		return nil
+	}
+
+	ty := after.Type()
+	if afterNull {
+		ty = before.Type()
+	}
+	if !beforeNull && !afterNull && !before.Type().Equals(after.Type()) {
+		// The type of a dynamically-typed attribute may change, in which
+		// case there's no common structure to traverse.
+		return leafAttributeChange(path, before, after)
+	}
+
+	var ret []attributeChange
+	switch {
+	case ty.IsObjectType():
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ret = append(ret, attributeChanges(
+				appendPathStep(path, name),
+				attributeValue(before, name),
+				attributeValue(after, name),
+			)...)
+		}
+	case ty.IsMapType():
+		beforeElems, afterElems := elementValues(before), elementValues(after)
+		keys := make([]string, 0, len(beforeElems)+len(afterElems))
+		for k := range beforeElems {
+			keys = append(keys, k.(string))
+		}
+		for k := range afterElems {
+			if _, exists := beforeElems[k]; !exists {
+				keys = append(keys, k.(string))
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ret = append(ret, attributeChanges(appendPathStep(path, k), beforeElems[k], afterElems[k])...)
+		}
+	case ty.IsListType() || ty.IsTupleType():
+		beforeElems, afterElems := elementValues(before), elementValues(after)
+		n := len(beforeElems)
+		if len(afterElems) > n {
+			n = len(afterElems)
+		}
+		for i := 0; i < n; i++ {
+			ret = append(ret, attributeChanges(appendPathStep(path, i), beforeElems[i], afterElems[i])...)
+		}
+	default:
+		ret = leafAttributeChange(path, before, after)
+	}
+	return ret
+}
+
+// leafAttributeChange returns the change to the value at the given path,
+// treated as a whole, or nil if it is unchanged.
+func leafAttributeChange(path []interface{}, before, after cty.Value) []attributeChange {
+	switch {
+	case before == cty.NilVal || before.IsNull():
+		return []attributeChange{{Path: path, Action: "add"}}
+	case after == cty.NilVal || after.IsNull():
+		return []attributeChange{{Path: path, Action: "remove"}}
+	case !after.IsWhollyKnown():
+		return []attributeChange{{Path: path, Action: "update", Unknown: true}}
+	case before.RawEquals(after):
+		return nil
+	default:
+		return []attributeChange{{Path: path, Action: "update"}}
+	}
+}
+
+// appendPathStep returns a copy of the given path with the given step
+// added, so that sibling paths never share a backing array.
+func appendPathStep(path []interface{}, step interface{}) []interface{} {
+	ret := make([]interface{}, len(path), len(path)+1)
+	copy(ret, path)
+	return append(ret, step)
+}
+
+// attributeValue returns the value of the named attribute of the given
+// object value, or cty.NilVal if the object is null or cty.NilVal.
+func attributeValue(obj cty.Value, name string) cty.Value {
+	if obj == cty.NilVal || obj.IsNull() {
+		return cty.NilVal
+	}
+	return obj.GetAttr(name)
+}
+
+// elementValues returns the elements of the given map, list or tuple value
+// keyed by map key string or by int index, or an empty map if the value is
+// null or cty.NilVal.
+func elementValues(coll cty.Value) map[interface{}]cty.Value {
+	ret := map[interface{}]cty.Value{}
+	if coll == cty.NilVal || coll.IsNull() {
+		return ret
+	}
+	i := 0
+	for it := coll.ElementIterator(); it.Next(); i++ {
+		k, v := it.Element()
+		if k.Type() == cty.String {
+			ret[k.AsString()] = v
+		} else {
+			ret[i] = v
+		}
+	}
+	return ret
+}
@@ -0,0 +1,74 @@
+package jsonplan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMarshalAttributeChanges(t *testing.T) {
+	tests := map[string]struct {
+		Before, After cty.Value
+		Want          []attributeChange
+	}{
+		"no change": {
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			nil,
+		},
+		"unknown object": {
+			cty.NullVal(cty.Object(map[string]cty.Type{"a": cty.String})),
+			cty.UnknownVal(cty.Object(map[string]cty.Type{"a": cty.String})),
+			[]attributeChange{
+				{Path: []interface{}{}, Action: "update", Unknown: true},
+			},
+		},
+		"nested": {
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.MapVal(map[string]cty.Value{
+					"Name": cty.StringVal("a"),
+					"Old":  cty.StringVal("b"),
+				}),
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("b"),
+				}),
+				"set":  cty.SetVal([]cty.Value{cty.StringVal("a")}),
+				"gone": cty.StringVal("x"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.MapVal(map[string]cty.Value{
+					"Name": cty.StringVal("b"),
+					"New":  cty.UnknownVal(cty.String),
+				}),
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("c"),
+					cty.StringVal("d"),
+				}),
+				"set":  cty.SetVal([]cty.Value{cty.StringVal("b")}),
+				"gone": cty.NullVal(cty.String),
+			}),
+			[]attributeChange{
+				{Path: []interface{}{"gone"}, Action: "remove"},
+				{Path: []interface{}{"list", 1}, Action: "update"},
+				{Path: []interface{}{"list", 2}, Action: "add"},
+				{Path: []interface{}{"set"}, Action: "update"},
+				{Path: []interface{}{"tags", "Name"}, Action: "update"},
+				{Path: []interface{}{"tags", "New"}, Action: "update", Unknown: true},
+				{Path: []interface{}{"tags", "Old"}, Action: "remove"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := marshalAttributeChanges(test.Before, test.After)
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\n%s", cmp.Diff(test.Want, got))
+			}
+		})
+	}
+}
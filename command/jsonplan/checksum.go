@@ -0,0 +1,91 @@
+package jsonplan
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/plans"
+)
+
+// planChecksum returns the lowercase hex SHA-256 checksum of the given
+// changes, or an empty string if there are none.
+//
+// The checksum is computed over the changes as recorded in the plan file
+// rather than over any json representation, so it doesn't depend on the
+// options the json is marshaled with, and identifies the changes of a
+// particular plan file. The bytes hashed are a sequence of fields, each
+// written as its length in bytes as an unsigned 64-bit big-endian integer
+// followed by its content. For each change there are six fields, in order:
+//
+//   - "resource" for a resource instance change, or "output" for an output
+//     value change
+//   - the absolute address of the resource instance or output value, such as
+//     "module.child.test_thing.foo[0]" or "output.name"
+//   - the deposed key of a deposed object, or nothing otherwise
+//   - the change's actions as in its "actions" array, joined with commas,
+//     such as "delete,create"
+//   - the prior value exactly as recorded in the plan file, which is
+//     msgpack encoded, or nothing if there is no prior value
+//   - likewise the planned value
+//
+// The resource instance changes come first, followed by the output value
+// changes, each ordered by address and then by deposed key, comparing
+// strings byte by byte.
+func planChecksum(changes *plans.Changes) string {
+	if changes == nil || (len(changes.Resources) == 0 && len(changes.Outputs) == 0) {
+		return ""
+	}
+
+	type checksumChange struct {
+		kind, addr, deposed string
+		plans.ChangeSrc
+	}
+	var resources, outputs []checksumChange
+	for _, rc := range changes.Resources {
+		resources = append(resources, checksumChange{
+			kind:      "resource",
+			addr:      rc.Addr.String(),
+			deposed:   string(rc.DeposedKey),
+			ChangeSrc: rc.ChangeSrc,
+		})
+	}
+	for _, oc := range changes.Outputs {
+		outputs = append(outputs, checksumChange{
+			kind:      "output",
+			addr:      oc.Addr.String(),
+			ChangeSrc: oc.ChangeSrc,
+		})
+	}
+
+	h := sha256.New()
+	for _, ccs := range [][]checksumChange{resources, outputs} {
+		sort.Slice(ccs, func(i, j int) bool {
+			if ccs[i].addr != ccs[j].addr {
+				return ccs[i].addr < ccs[j].addr
+			}
+			return ccs[i].deposed < ccs[j].deposed
+		})
+		for _, cc := range ccs {
+			writeChecksumField(h, []byte(cc.kind))
+			writeChecksumField(h, []byte(cc.addr))
+			writeChecksumField(h, []byte(cc.deposed))
+			writeChecksumField(h, []byte(strings.Join(marshalActions(cc.Action), ",")))
+			writeChecksumField(h, cc.Before)
+			writeChecksumField(h, cc.After)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeChecksumField writes a single length-prefixed field of the input to
+// planChecksum.
+func writeChecksumField(h hash.Hash, field []byte) {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(field)))
+	h.Write(n[:])
+	h.Write(field)
+}
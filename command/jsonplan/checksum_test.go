@@ -0,0 +1,115 @@
+package jsonplan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestMarshal_planChecksum(t *testing.T) {
+	create := testChange(t, plans.Create, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed,
+		cty.NullVal(testThingType),
+		cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.UnknownVal(cty.String),
+			"woozles": cty.StringVal("confuzles"),
+		}),
+	)
+	replace := testChange(t, plans.DeleteThenCreate, addrs.RootModuleInstance, "bar", addrs.NoKey, states.NotDeposed,
+		cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.StringVal("bar"),
+			"woozles": cty.StringVal("before"),
+		}),
+		cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.UnknownVal(cty.String),
+			"woozles": cty.StringVal("after"),
+		}),
+	)
+	output := testOutputChange(t, addrs.RootModuleInstance, "name", plans.Create, false,
+		cty.NullVal(cty.DynamicPseudoType),
+		cty.StringVal("hello"),
+	)
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{create, replace},
+			Outputs:   []*plans.OutputChangeSrc{output},
+		},
+	}
+
+	checksum := func(p *plans.Plan, noSensitive, changesOnly bool) string {
+		t.Helper()
+		src, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{NoSensitive: noSensitive, ChangesOnly: changesOnly})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var got struct {
+			PlanChecksum string `json:"plan_checksum"`
+		}
+		if err := json.Unmarshal(src, &got); err != nil {
+			t.Fatal(err)
+		}
+		return got.PlanChecksum
+	}
+
+	// The checksum is computed independently here, following the
+	// documentation of planChecksum, so that a change to the hashed bytes
+	// is caught.
+	var buf bytes.Buffer
+	field := func(s []byte) {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(s)))
+		buf.Write(n[:])
+		buf.Write(s)
+	}
+	for _, f := range [][]byte{
+		[]byte("resource"), []byte("test_thing.bar"), nil, []byte("delete,create"), replace.Before, replace.After,
+		[]byte("resource"), []byte("test_thing.foo"), nil, []byte("create"), create.Before, create.After,
+		[]byte("output"), []byte("output.name"), nil, []byte("create"), output.Before, output.After,
+	} {
+		field(f)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	want := hex.EncodeToString(sum[:])
+
+	if got := checksum(p, false, false); got != want {
+		t.Errorf("wrong checksum\ngot:  %s\nwant: %s", got, want)
+	}
+	if got := checksum(p, false, false); got != want {
+		t.Errorf("checksum changed when marshaled again\ngot:  %s\nwant: %s", got, want)
+	}
+	if got := checksum(p, true, true); got != want {
+		t.Errorf("checksum depends on the marshal options\ngot:  %s\nwant: %s", got, want)
+	}
+
+	reordered := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{replace, create},
+			Outputs:   []*plans.OutputChangeSrc{output},
+		},
+	}
+	if got := checksum(reordered, false, false); got != want {
+		t.Errorf("checksum depends on the order of the changes\ngot:  %s\nwant: %s", got, want)
+	}
+
+	fewer := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{create},
+			Outputs:   []*plans.OutputChangeSrc{output},
+		},
+	}
+	if got := checksum(fewer, false, false); got == want {
+		t.Errorf("checksum is the same for different changes")
+	}
+
+	if got := checksum(&plans.Plan{Changes: plans.NewChanges()}, false, false); got != "" {
+		t.Errorf("unexpected checksum %q for a plan with no changes", got)
+	}
+}
@@ -0,0 +1,129 @@
+package jsonplan
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/lang"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// resourceDependencies returns the sorted absolute addresses of the objects
+// that the object affected by the given change depends on.
+//
+// The dependencies are the union of the explicit depends_on arguments and
+// expression references in the resource configuration, if config is non-nil,
+// the dependencies recorded for the object in the prior state, if s is
+// non-nil, and the provider configuration that the object belongs to.
+func resourceDependencies(rc *plans.ResourceInstanceChangeSrc, config *configs.Config, s *states.State, schemas *terraform.Schemas) []string {
+	addr := rc.Addr
+
+	// A resource can refer to its own instances, such as via self in a
+	// provisioner, but that is not a dependency.
+	seen := map[string]struct{}{
+		addr.ContainingResource().String(): {},
+	}
+	var ret []string
+	add := func(dep string) {
+		if _, exists := seen[dep]; exists || dep == "" {
+			return
+		}
+		seen[dep] = struct{}{}
+		ret = append(ret, dep)
+	}
+
+	for _, ref := range configReferences(config, rc, schemas) {
+		add(dependencyAddr(addr.Module, ref.Subject))
+	}
+
+	if s != nil {
+		if ri := s.ResourceInstance(addr); ri != nil {
+			obj := ri.Current
+			if rc.DeposedKey != states.NotDeposed {
+				obj = ri.Deposed[rc.DeposedKey]
+			}
+			if obj != nil {
+				for _, dep := range obj.Dependencies {
+					add(dependencyAddr(addr.Module, dep))
+				}
+			}
+		}
+	}
+
+	add(rc.ProviderAddr.String())
+
+	sort.Strings(ret)
+	return ret
+}
+
+// configReferences returns the references made by the configuration of the
+// resource affected by the given change, or nil if there is no such
+// configuration.
+func configReferences(config *configs.Config, rc *plans.ResourceInstanceChangeSrc, schemas *terraform.Schemas) []*addrs.Reference {
+	if config == nil {
+		return nil
+	}
+	modCfg := config.DescendentForInstance(rc.Addr.Module)
+	if modCfg == nil {
+		return nil
+	}
+	resCfg := modCfg.Module.ResourceByAddr(rc.Addr.Resource.Resource)
+	if resCfg == nil {
+		// A resource that has been removed from the configuration has only
+		// the dependencies recorded in state.
+		return nil
+	}
+
+	var ret []*addrs.Reference
+	for _, traversal := range resCfg.DependsOn {
+		ref, diags := addrs.ParseRef(traversal)
+		if diags.HasErrors() {
+			// Invalid references are rejected during validation, so we
+			// should never get here.
+			continue
+		}
+		ret = append(ret, ref)
+	}
+
+	// Errors in the expressions are caught during validation, so we can
+	// safely take whatever references we are able to find.
+	refs, _ := lang.ReferencesInExpr(resCfg.Count)
+	ret = append(ret, refs...)
+	refs, _ = lang.ReferencesInExpr(resCfg.ForEach)
+	ret = append(ret, refs...)
+	if schema, err := resourceSchema(schemas, rc.ProviderAddr.ProviderConfig.Type, rc.Addr.Resource.Resource); err == nil {
+		refs, _ = lang.ReferencesInBlock(resCfg.Config, schema)
+		ret = append(ret, refs...)
+	}
+	if resCfg.Managed != nil {
+		for _, p := range resCfg.Managed.Provisioners {
+			if schema := schemas.ProvisionerConfig(p.Type); schema != nil {
+				refs, _ = lang.ReferencesInBlock(p.Config, schema)
+				ret = append(ret, refs...)
+			}
+		}
+	}
+
+	return ret
+}
+
+// dependencyAddr returns the absolute address of the object that the given
+// referenceable address within the given module refers to, or an empty
+// string if the referenced object is not a resource or module.
+func dependencyAddr(module addrs.ModuleInstance, ref addrs.Referenceable) string {
+	switch ref := ref.(type) {
+	case addrs.Resource:
+		return ref.Absolute(module).String()
+	case addrs.ResourceInstance:
+		return ref.ContainingResource().Absolute(module).String()
+	case addrs.ModuleCallInstance:
+		return module.Child(ref.Call.Name, ref.Key).String()
+	case addrs.ModuleCallOutput:
+		return module.Child(ref.Call.Call.Name, ref.Call.Key).String()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,3 @@
+// Package jsonplan implements methods for outputting a plan in a
+// machine-readable json format
+package jsonplan
@@ -0,0 +1,100 @@
+package jsonplan
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// nullValues returns the given value with each of the concrete values within
+// it replaced with null, keeping only its structure: the attributes of
+// objects and the elements of tuples, lists and maps are kept, but each
+// primitive value becomes null. A set is replaced with null as a whole,
+// since its elements are identified only by their values. Unknown and null
+// values are returned unchanged, as is cty.NilVal.
+func nullValues(val cty.Value) cty.Value {
+	if val == cty.NilVal || !val.IsKnown() || val.IsNull() {
+		return val
+	}
+
+	ty := val.Type()
+	switch {
+	case ty.IsObjectType() || ty.IsMapType():
+		if val.LengthInt() == 0 {
+			return val
+		}
+		vals := make(map[string]cty.Value)
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			vals[k.AsString()] = nullValues(v)
+		}
+		if ty.IsMapType() {
+			return cty.MapVal(vals)
+		}
+		return cty.ObjectVal(vals)
+	case ty.IsTupleType() || ty.IsListType():
+		if val.LengthInt() == 0 {
+			return val
+		}
+		var vals []cty.Value
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			vals = append(vals, nullValues(v))
+		}
+		if ty.IsListType() {
+			return cty.ListVal(vals)
+		}
+		return cty.TupleVal(vals)
+	default:
+		return cty.NullVal(ty)
+	}
+}
+
+// nullStateValues returns a copy of the given state with the attribute values
+// of each resource instance object replaced as for nullValues. It returns nil
+// if the given state is nil.
+func nullStateValues(s *states.State, schemas *terraform.Schemas) (*states.State, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	ret := s.DeepCopy()
+	for _, ms := range ret.Modules {
+		for _, rs := range ms.Resources {
+			schema, err := resourceSchema(schemas, rs.ProviderConfig.ProviderConfig.Type, rs.Addr)
+			if err != nil {
+				return nil, err
+			}
+			ty := schema.ImpliedType()
+
+			for k, ri := range rs.Instances {
+				addr := rs.Addr.Instance(k).Absolute(ms.Addr)
+				if ri.Current != nil {
+					ri.Current, err = nullObjectValues(ri.Current, ty)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %s", addr, err)
+					}
+				}
+				for dk, obj := range ri.Deposed {
+					ri.Deposed[dk], err = nullObjectValues(obj, ty)
+					if err != nil {
+						return nil, fmt.Errorf("%s (deposed object %s): %s", addr, dk, err)
+					}
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+func nullObjectValues(obj *states.ResourceInstanceObjectSrc, ty cty.Type) (*states.ResourceInstanceObjectSrc, error) {
+	val, err := obj.Decode(ty)
+	if err != nil {
+		return nil, err
+	}
+	val.Value = nullValues(val.Value)
+	return val.Encode(ty, obj.SchemaVersion)
+}
@@ -0,0 +1,166 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestNullValues(t *testing.T) {
+	tests := []struct {
+		val, want cty.Value
+	}{
+		{cty.NilVal, cty.NilVal},
+		{cty.StringVal("a"), cty.NullVal(cty.String)},
+		{cty.NullVal(cty.Number), cty.NullVal(cty.Number)},
+		{cty.UnknownVal(cty.Bool), cty.UnknownVal(cty.Bool)},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.NumberIntVal(1),
+				"b": cty.UnknownVal(cty.String),
+				"c": cty.ListVal([]cty.Value{cty.True, cty.False}),
+				"d": cty.MapVal(map[string]cty.Value{"k": cty.StringVal("v")}),
+				"e": cty.TupleVal([]cty.Value{cty.StringVal("x"), cty.NumberIntVal(2)}),
+				"f": cty.SetVal([]cty.Value{cty.StringVal("x"), cty.StringVal("y")}),
+				"g": cty.ListValEmpty(cty.String),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.NullVal(cty.Number),
+				"b": cty.UnknownVal(cty.String),
+				"c": cty.ListVal([]cty.Value{cty.NullVal(cty.Bool), cty.NullVal(cty.Bool)}),
+				"d": cty.MapVal(map[string]cty.Value{"k": cty.NullVal(cty.String)}),
+				"e": cty.TupleVal([]cty.Value{cty.NullVal(cty.String), cty.NullVal(cty.Number)}),
+				"f": cty.NullVal(cty.Set(cty.String)),
+				"g": cty.ListValEmpty(cty.String),
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%#v", test.val), func(t *testing.T) {
+			got := nullValues(test.val)
+			if got == cty.NilVal || test.want == cty.NilVal {
+				if got != test.want {
+					t.Fatalf("wrong result %#v; want %#v", got, test.want)
+				}
+				return
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_noValues(t *testing.T) {
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_thing",
+		Name: "foo",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	p := &plans.Plan{
+		VariableValues: map[string]plans.DynamicValue{
+			"name": testVariableValue(t, cty.ObjectVal(map[string]cty.Value{
+				"first": cty.StringVal("Jo"),
+			})),
+		},
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Update, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed,
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("foo"),
+						"woozles": cty.StringVal("old"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("foo"),
+						"woozles": cty.StringVal("new"),
+					}),
+				),
+			},
+			Outputs: []*plans.OutputChangeSrc{
+				testOutputChange(t, addrs.RootModuleInstance, "plain", plans.Update, false,
+					cty.StringVal("old"),
+					cty.ListVal([]cty.Value{cty.StringVal("new")}),
+				),
+			},
+		},
+	}
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addr,
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"foo","woozles":"old"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	src, err := Marshal(nil, p, prior, prior, testSchemas(), nil, &MarshalOpts{NoValues: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every value is within a property with one of these names, in the
+	// changes, the planned values, the variables and both of the states,
+	// except for the "values" property of a state document itself.
+	valueProps := map[string]bool{"before": true, "after": true, "values": true, "value": true}
+	var found int
+	var check func(v interface{}, path string, inValue bool)
+	check = func(v interface{}, path string, inValue bool) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, elem := range v {
+				isState := path == "/prior_state" || path == "/planned_state"
+				if !inValue && valueProps[k] && !isState {
+					found++
+					check(elem, path+"/"+k, true)
+				} else {
+					check(elem, path+"/"+k, inValue)
+				}
+			}
+		case []interface{}:
+			for i, elem := range v {
+				check(elem, fmt.Sprintf("%s/%d", path, i), inValue)
+			}
+		case nil:
+		default:
+			if inValue {
+				t.Errorf("%s: got %#v; want null", path, v)
+			}
+		}
+	}
+	check(doc, "", false)
+
+	// The resource change and prior and planned states each have values,
+	// and the output change both its values.
+	if found < 6 {
+		t.Errorf("found only %d values in the plan\n%s", found, src)
+	}
+
+	var got struct {
+		ResourceChanges []struct {
+			Change struct {
+				AttributeChanges []attributeChange `json:"attribute_changes"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []attributeChange{{Path: []interface{}{"woozles"}, Action: "update"}}
+	if changes := got.ResourceChanges[0].Change.AttributeChanges; !reflect.DeepEqual(changes, want) {
+		t.Errorf("wrong attribute changes %#v; want %#v", changes, want)
+	}
+}
@@ -0,0 +1,134 @@
+package jsonplan
+
+import (
+	"encoding/json"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/plans"
+)
+
+// output is the representation of a root module output value in the planned
+// values.
+type output struct {
+	Sensitive bool `json:"sensitive"`
+
+	// Value is omitted if the output is sensitive, or if its value will not
+	// be known until after apply.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// outputChange is the representation of a proposed change for a root module
+// output value.
+type outputChange struct {
+	change
+
+	// Sensitive is true if either the old or the new value is sensitive, in
+	// which case Before and After are always omitted, and BeforeSensitive
+	// and AfterSensitive are instead set to true in place of each of them
+	// that has a value, so that it's still clear whether the output is
+	// being added, changed or removed.
+	Sensitive bool `json:"sensitive"`
+}
+
+// marshalOutputChanges populates the output changes and the planned outputs
+// from the root module output value changes in the given changes.
+func (p *plan) marshalOutputChanges(changes *plans.Changes) error {
+	if changes == nil {
+		// Nothing to do!
+		return nil
+	}
+
+	for _, oc := range changes.Outputs {
+		if !oc.Addr.Module.IsRoot() {
+			// Only root module outputs are visible outside of the
+			// configuration, so the others are not of interest.
+			continue
+		}
+		if oc.Sensitive && p.noSensitive {
+			continue
+		}
+		name := oc.Addr.OutputValue.Name
+
+		changeV, err := oc.Decode()
+		if err != nil {
+			return err
+		}
+		if p.noValues {
+			changeV.Before = nullValues(changeV.Before)
+			changeV.After = nullValues(changeV.After)
+		}
+
+		r := outputChange{
+			change: change{
+				Actions: marshalActions(oc.Action),
+			},
+			Sensitive: oc.Sensitive,
+		}
+		if oc.Sensitive {
+			r.BeforeSensitive = sensitiveOutputMarker(changeV.Before)
+			r.AfterSensitive = sensitiveOutputMarker(changeV.After)
+		} else {
+			r.Before, err = marshalOutputValue(changeV.Before)
+			if err != nil {
+				return err
+			}
+			r.After, err = marshalOutputValue(changeV.After)
+			if err != nil {
+				return err
+			}
+		}
+		if p.OutputChanges == nil {
+			p.OutputChanges = make(map[string]outputChange)
+		}
+		p.OutputChanges[name] = r
+
+		// A deleted output won't be present in the new state, so it is not
+		// included in the planned outputs.
+		if oc.Action == plans.Delete {
+			continue
+		}
+		o := output{
+			Sensitive: oc.Sensitive,
+		}
+		if !oc.Sensitive {
+			o.Value = r.After
+		}
+		if p.PlannedValues.PlannedOutputs == nil {
+			p.PlannedValues.PlannedOutputs = make(map[string]output)
+		}
+		p.PlannedValues.PlannedOutputs[name] = o
+	}
+
+	return nil
+}
+
+// sensitiveOutputMarker returns the json value true in place of the given
+// sensitive output value, or nil if there is no value to redact because it
+// is null. A value that is not yet known is still a value, so it is marked
+// too.
+func sensitiveOutputMarker(v cty.Value) json.RawMessage {
+	if v == cty.NilVal || (v.IsKnown() && v.IsNull()) {
+		return nil
+	}
+	return json.RawMessage("true")
+}
+
+// marshalOutputValue returns the json representation of the given output
+// value, or nil if the value is null or unknown. As with resource values,
+// any unknown values nested within are set to null.
+func marshalOutputValue(v cty.Value) (json.RawMessage, error) {
+	if v == cty.NilVal {
+		return nil, nil
+	}
+	v = cty.UnknownAsNull(v)
+	if v.IsNull() {
+		return nil, nil
+	}
+	src, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(src), nil
+}
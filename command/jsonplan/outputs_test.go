@@ -0,0 +1,141 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+)
+
+func TestMarshal_outputs(t *testing.T) {
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Outputs: []*plans.OutputChangeSrc{
+				testOutputChange(t, addrs.RootModuleInstance, "plain", plans.Create, false,
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.ListVal([]cty.Value{cty.StringVal("hello"), cty.UnknownVal(cty.String)}),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "secret", plans.Update, true,
+					cty.StringVal("old password"),
+					cty.StringVal("new password"),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "new_secret", plans.Create, true,
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.UnknownVal(cty.String),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "old_secret", plans.Delete, true,
+					cty.StringVal("old token"),
+					cty.NullVal(cty.DynamicPseudoType),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "unknown", plans.Update, false,
+					cty.StringVal("before"),
+					cty.UnknownVal(cty.String),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "gone", plans.Delete, false,
+					cty.StringVal("bye"),
+					cty.NullVal(cty.DynamicPseudoType),
+				),
+				testOutputChange(t, addrs.RootModuleInstance.Child("child", addrs.NoKey), "plain", plans.Create, false,
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.StringVal("not visible"),
+				),
+			},
+		},
+	}
+
+	got, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var gotV, wantV interface{}
+	if err := json.Unmarshal(got, &gotV); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "format_version": "0.2",
+  "plan_checksum": "0ca286143a5f48f4057ca6e9f18c73f579281e05046106940cd253c434821b72",
+  "applyable": true,
+  "errored": false,
+  "applyable_partial": false,
+  "planned_values": {
+    "root_module": {},
+    "planned_outputs": {
+      "plain": {"sensitive": false, "value": ["hello", null]},
+      "secret": {"sensitive": true},
+      "new_secret": {"sensitive": true},
+      "unknown": {"sensitive": false}
+    }
+  },
+  "output_changes": {
+    "plain": {
+      "actions": ["create"],
+      "after": ["hello", null],
+      "sensitive": false
+    },
+    "secret": {
+      "actions": ["update"],
+      "before_sensitive": true,
+      "after_sensitive": true,
+      "sensitive": true
+    },
+    "new_secret": {
+      "actions": ["create"],
+      "after_sensitive": true,
+      "sensitive": true
+    },
+    "old_secret": {
+      "actions": ["delete"],
+      "before_sensitive": true,
+      "sensitive": true
+    },
+    "unknown": {
+      "actions": ["update"],
+      "before": "before",
+      "sensitive": false
+    },
+    "gone": {
+      "actions": ["delete"],
+      "before": "bye",
+      "sensitive": false
+    }
+  }
+}`
+	if err := json.Unmarshal([]byte(want), &wantV); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(gotV, wantV) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(wantV, gotV))
+	}
+	for _, secret := range []string{"old password", "new password", "old token"} {
+		if strings.Contains(string(got), secret) {
+			t.Errorf("result includes the sensitive value %q", secret)
+		}
+	}
+}
+
+func testOutputChange(t *testing.T, module addrs.ModuleInstance, name string, action plans.Action, sensitive bool, before, after cty.Value) *plans.OutputChangeSrc {
+	t.Helper()
+
+	oc := &plans.OutputChange{
+		Addr: addrs.OutputValue{Name: name}.Absolute(module),
+		Change: plans.Change{
+			Action: action,
+			Before: before,
+			After:  after,
+		},
+		Sensitive: sensitive,
+	}
+	ocs, err := oc.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ocs
+}
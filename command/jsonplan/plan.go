@@ -0,0 +1,776 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/command/addrfilter"
+	"github.com/hashicorp/terraform/command/jsonconfig"
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion represents the version of the json format and will be
+// incremented for any change to this format that requires changes to a
+// consuming parser.
+//
+// Version 0.2 replaced the "action" string of each resource and output
+// change with an "actions" array, so that a replacement is described as the
+// delete and create it consists of, in the order they happen. A parser of
+// version 0.1 documents should map "no-op", "create", "read", "update" and
+// "delete" to an array of that one action, and "replace" to either
+// ["delete", "create"] or ["create", "delete"]; version 0.1 didn't record
+// which.
+const FormatVersion = "0.2"
+
+// The values of the "mode" property of a resource or resource change.
+const (
+	ManagedResourceMode = "managed"
+	DataResourceMode    = "data"
+)
+
+// plan is the top-level representation of the json format of a plan. It
+// includes the planned values and the individual resource changes.
+type plan struct {
+	FormatVersion   string           `json:"format_version,omitempty"`
+	PlannedValues   stateValues      `json:"planned_values,omitempty"`
+	ResourceChanges []resourceChange `json:"resource_changes,omitempty"`
+
+	// OutputChanges describes the changes to the root module output values,
+	// keyed by output name.
+	OutputChanges map[string]outputChange `json:"output_changes,omitempty"`
+
+	// Variables are the values of the root module input variables that the
+	// plan was created with, keyed by variable name.
+	Variables map[string]variable `json:"variables,omitempty"`
+
+	// PriorState is the full prior state, in the same format as produced by
+	// the jsonstate package. It is omitted if there is no prior state.
+	PriorState json.RawMessage `json:"prior_state,omitempty"`
+
+	// PlannedState is the full state that is expected to result from
+	// applying the plan, in the same format as PriorState, with any values
+	// that will not be known until after apply set to null. It is omitted
+	// unless requested.
+	PlannedState json.RawMessage `json:"planned_state,omitempty"`
+
+	// Configuration is the configuration that the plan was created from, in
+	// the format of the jsonconfig package. It is omitted unless requested.
+	Configuration json.RawMessage `json:"configuration,omitempty"`
+
+	// ProviderVersions are the versions of the providers the plan was
+	// created with, keyed by provider name. It is omitted if no version
+	// information is available.
+	ProviderVersions map[string]string `json:"provider_versions,omitempty"`
+
+	// ProviderHashes are the hex SHA-256 hashes of the provider executables
+	// that the plan was created with, keyed by provider name, as recorded
+	// from the plugin lock. It is omitted if the plan records none.
+	ProviderHashes map[string]string `json:"provider_hashes,omitempty"`
+
+	// Timestamp is the time at which the plan was created, in RFC3339
+	// format and in UTC. It is omitted if the creation time isn't known.
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// PlanChecksum is the SHA-256 checksum of all of the planned changes,
+	// as described for planChecksum. It is omitted if there are no changes.
+	PlanChecksum string `json:"plan_checksum,omitempty"`
+
+	// Applyable is true if applying the plan would do anything: that is, if
+	// any of its resource or output changes has an action other than
+	// "no-op". It describes the whole plan, whatever the options.
+	Applyable bool `json:"applyable"`
+
+	// Errored is true if planning failed. A plan that failed is never saved
+	// to a plan file in this version, so it is always false for now, but
+	// consumers should check it along with Applyable.
+	Errored bool `json:"errored"`
+
+	// ApplyablePartial is true if the plan was created with the -target
+	// option, so that it includes only the changes to the targeted objects
+	// and their dependencies. Applying it leaves everything else as it is,
+	// even where it differs from the configuration.
+	ApplyablePartial bool `json:"applyable_partial"`
+
+	// Targets are the addresses given with the -target option when the plan
+	// was created, sorted. It is omitted unless ApplyablePartial is set.
+	Targets []string `json:"targets,omitempty"`
+
+	// noSensitive, noValues and changesOnly are the options of the same
+	// names, as described for MarshalOpts.
+	noSensitive bool
+	noValues    bool
+	changesOnly bool
+
+	// variables are the decoded values of the root module input variables
+	// that the plan was created with, used to evaluate the arguments whose
+	// changes were ignored, as described for marshalSuppressedChanges.
+	variables map[string]cty.Value
+
+	// workers is the number of resource changes to marshal concurrently.
+	// If it is zero, GOMAXPROCS are used.
+	workers int
+}
+
+// change is the representation of a proposed change for an object.
+type change struct {
+	// Actions describes the change that will be made to the object, as the
+	// sequence of actions that make it up. Each action is one of "no-op",
+	// "create", "read", "update" or "delete", and a replacement is either
+	// ["delete", "create"] or ["create", "delete"], depending on which
+	// happens first. Every other change is a single action.
+	Actions []string `json:"actions,omitempty"`
+
+	// Before and After are representations of the object value both before
+	// and after the action. For "create" actions, Before is null, and for
+	// "delete" actions After is null. After omits any values that are not
+	// yet known.
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+
+	// BeforeSensitive and AfterSensitive mirror the structure of Before and
+	// After respectively, with the value true in place of each attribute
+	// whose value is sensitive. They are omitted if the corresponding value
+	// is null.
+	BeforeSensitive json.RawMessage `json:"before_sensitive,omitempty"`
+	AfterSensitive  json.RawMessage `json:"after_sensitive,omitempty"`
+
+	// AfterUnknown mirrors the structure of After, with the value true in
+	// place of each value that will not be known until after apply, as
+	// described for marshalUnknown. It is omitted if After is null.
+	AfterUnknown json.RawMessage `json:"after_unknown,omitempty"`
+
+	// AttributeChanges lists the path and action for each leaf value that
+	// differs between Before and After, including those whose new value is
+	// not yet known. It is omitted for output changes.
+	AttributeChanges []attributeChange `json:"attribute_changes,omitempty"`
+
+	// ReplacePaths lists the paths, as for AttributeChanges, of the
+	// attributes whose changes the provider can't make in-place, which
+	// caused the object to be replaced. It is omitted unless the change is
+	// a replacement.
+	ReplacePaths [][]interface{} `json:"replace_paths,omitempty"`
+}
+
+// MarshalOpts are the options for Marshal. The zero value produces the
+// complete json representation of a plan.
+type MarshalOpts struct {
+	// NoSensitive, if set, causes sensitive attributes to be omitted from
+	// all object values, along with the before_sensitive and after_sensitive
+	// properties that would describe them, changes to sensitive output
+	// values to be omitted entirely, and the values of the input variables
+	// to be redacted. The result is then safe to share, but no longer a
+	// complete description of the plan.
+	NoSensitive bool
+
+	// NoValues, if set, causes every concrete value within the object
+	// values, the output values and the variable values to be replaced with
+	// null, as are the attribute values of both of the states, keeping only
+	// their structure, as described for nullValues. Everything else,
+	// including the paths of the attribute changes and which values are
+	// sensitive or unknown, is unchanged. The result describes what the plan
+	// changes but not what to, which is stricter than NoSensitive's
+	// redaction of just the sensitive values.
+	NoValues bool
+
+	// ChangesOnly, if set, causes the resource changes and the planned
+	// values to include only the resource instances whose actions are not
+	// ["no-op"], which makes the result much smaller for a plan that leaves
+	// most resources unchanged.
+	ChangesOnly bool
+
+	// WithConfig, if set, causes the configuration the plan was created
+	// from to be included in full, as described for jsonconfig.Marshal, with
+	// its constant values left out if NoValues is set. Nothing is included
+	// if the configuration is nil.
+	WithConfig bool
+
+	// Filter, if non-nil, selects the resource instances to include in the
+	// resource changes, the planned values and both of the states. The
+	// output changes and the configuration are not filtered.
+	Filter *addrfilter.Filter
+}
+
+// Marshal returns the json encoding of a terraform plan. The result depends
+// only on the content of the plan and the other arguments, not on the order
+// of the changes within the plan, so the same plan always produces the same
+// bytes: the resource changes are sorted by address, and every other list
+// has an order of its own.
+//
+// The given configuration is the one the plan was created from, used to
+// find the dependencies of each resource. It may be nil, in which case only
+// the dependencies recorded in the prior state are included.
+//
+// The given state is the prior state the plan was created against, such as
+// the state snapshot embedded in a saved plan file. It may be nil if there is
+// no prior state.
+//
+// The given planned state, if non-nil, is the state expected to result from
+// applying the plan, which is then included in full.
+//
+// The given plugins are the available provider plugins, used to find the
+// versions of the providers the plan was created with. It may be nil, in
+// which case no provider versions are included.
+//
+// The rest of the result is as described for the fields of the given
+// options.
+func Marshal(config *configs.Config, p *plans.Plan, s, plannedState *states.State, schemas *terraform.Schemas, plugins discovery.PluginMetaSet, opts *MarshalOpts) ([]byte, error) {
+	output, err := newPlan(config, p, s, plannedState, schemas, plugins, opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(output)
+}
+
+// MarshalIndent is like Marshal, but indents the result in the same manner
+// as json.MarshalIndent. Its content is always identical to that of Marshal.
+func MarshalIndent(config *configs.Config, p *plans.Plan, s, plannedState *states.State, schemas *terraform.Schemas, plugins discovery.PluginMetaSet, opts *MarshalOpts, prefix, indent string) ([]byte, error) {
+	output, err := newPlan(config, p, s, plannedState, schemas, plugins, opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(output, prefix, indent)
+}
+
+// MarshalChanges returns the json representation of just the given resource
+// instance changes, as a document with only the "format_version" and
+// "resource_changes" properties of a plan, for changes that don't come from
+// a plan, such as the differences between two states.
+//
+// The given state is the state the changes apply to, as for Marshal, and
+// may be nil. The noSensitive option is as for MarshalOpts.NoSensitive.
+func MarshalChanges(changes *plans.Changes, s *states.State, schemas *terraform.Schemas, noSensitive bool) ([]byte, error) {
+	output := &plan{
+		FormatVersion: FormatVersion,
+		noSensitive:   noSensitive,
+	}
+	if err := output.marshalResourceChanges(changes, nil, s, schemas); err != nil {
+		return nil, fmt.Errorf("error in marshalResourceChanges: %s", err)
+	}
+	if output.ResourceChanges == nil {
+		// The changes are always an array, even if there are none.
+		output.ResourceChanges = []resourceChange{}
+	}
+	return json.Marshal(struct {
+		FormatVersion   string           `json:"format_version"`
+		ResourceChanges []resourceChange `json:"resource_changes"`
+	}{output.FormatVersion, output.ResourceChanges})
+}
+
+// newPlan assembles the json representation of the given plan, as described
+// for Marshal.
+func newPlan(config *configs.Config, p *plans.Plan, s, plannedState *states.State, schemas *terraform.Schemas, plugins discovery.PluginMetaSet, opts *MarshalOpts) (*plan, error) {
+	output := &plan{
+		FormatVersion: FormatVersion,
+		noSensitive:   opts.NoSensitive,
+		noValues:      opts.NoValues,
+		changesOnly:   opts.ChangesOnly,
+		variables:     decodeVariables(p.VariableValues),
+	}
+
+	// The checksum identifies the plan itself, so it covers all of the
+	// changes whatever the options.
+	output.PlanChecksum = planChecksum(p.Changes)
+	output.Applyable = planApplyable(p.Changes)
+	output.Targets = marshalTargets(p.TargetAddrs)
+	output.ApplyablePartial = len(output.Targets) > 0
+
+	// The prior state is filtered along with the changes, rather than only
+	// when it's marshaled, so that everything derived from it describes
+	// just the selected resources too.
+	filter := opts.Filter
+	changes := filter.Changes(p.Changes)
+	s = filter.State(s)
+	if opts.NoValues {
+		var err error
+		s, err = nullStateValues(s, schemas)
+		if err != nil {
+			return nil, fmt.Errorf("error removing values from prior state: %s", err)
+		}
+		plannedState, err = nullStateValues(filter.State(plannedState), schemas)
+		if err != nil {
+			return nil, fmt.Errorf("error removing values from planned state: %s", err)
+		}
+	}
+
+	err := output.marshalVariables(p.VariableValues)
+	if err != nil {
+		return nil, fmt.Errorf("error in marshalVariables: %s", err)
+	}
+
+	err = output.marshalPlannedValues(changes, config, s, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("error in marshalPlannedValues: %s", err)
+	}
+
+	err = output.marshalResourceChanges(changes, config, s, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("error in marshalResourceChanges: %s", err)
+	}
+
+	err = output.marshalOutputChanges(changes)
+	if err != nil {
+		return nil, fmt.Errorf("error in marshalOutputChanges: %s", err)
+	}
+
+	if !s.Empty() {
+		output.PriorState, err = jsonstate.Marshal(s, schemas, &jsonstate.MarshalOpts{NoSensitive: opts.NoSensitive})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling prior state: %s", err)
+		}
+	}
+
+	if plannedState != nil {
+		output.PlannedState, err = jsonstate.Marshal(plannedState, schemas, &jsonstate.MarshalOpts{NoSensitive: opts.NoSensitive, Filter: filter})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling planned state: %s", err)
+		}
+	}
+
+	if opts.WithConfig && config != nil {
+		output.Configuration, err = jsonconfig.Marshal(config, schemas, opts.NoValues)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling configuration: %s", err)
+		}
+	}
+
+	output.ProviderVersions = marshalProviderVersions(p, plugins)
+	output.ProviderHashes = marshalProviderHashes(p)
+
+	if !p.Timestamp.IsZero() {
+		output.Timestamp = p.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	return output, nil
+}
+
+// marshalTargets returns the given target addresses as strings, sorted, or
+// nil if there are none.
+func marshalTargets(targets []addrs.Targetable) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+	ret := make([]string, len(targets))
+	for i, target := range targets {
+		ret[i] = target.String()
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// planApplyable returns true if any of the given changes has an action other
+// than plans.NoOp, as described for plan.Applyable.
+func planApplyable(changes *plans.Changes) bool {
+	if changes == nil {
+		return false
+	}
+	for _, rc := range changes.Resources {
+		if rc.Action != plans.NoOp {
+			return true
+		}
+	}
+	for _, oc := range changes.Outputs {
+		if oc.Action != plans.NoOp {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsupportedFormatVersionError is returned by Unmarshal when the given
+// document was produced in a format version newer than the one implemented
+// by this package.
+type UnsupportedFormatVersionError struct {
+	// Version is the format version recorded in the document.
+	Version string
+}
+
+func (e *UnsupportedFormatVersionError) Error() string {
+	return fmt.Sprintf("unsupported plan format version %q; this version of Terraform supports only version %s", e.Version, FormatVersion)
+}
+
+// Unmarshal decodes the json representation of a plan into the value pointed
+// to by v, in the same manner as json.Unmarshal.
+//
+// Before decoding, Unmarshal verifies that the document's format version is
+// not newer than FormatVersion, returning an *UnsupportedFormatVersionError
+// if it is, and that the mode of each planned resource and resource change
+// is either ManagedResourceMode or DataResourceMode.
+func Unmarshal(src []byte, v interface{}) error {
+	var header struct {
+		FormatVersion string `json:"format_version"`
+	}
+	if err := json.Unmarshal(src, &header); err != nil {
+		return err
+	}
+	if header.FormatVersion == "" {
+		return fmt.Errorf("plan has no format_version")
+	}
+
+	got, err := version.NewVersion(header.FormatVersion)
+	if err != nil {
+		return fmt.Errorf("invalid plan format version %q: %s", header.FormatVersion, err)
+	}
+	if got.GreaterThan(version.Must(version.NewVersion(FormatVersion))) {
+		return &UnsupportedFormatVersionError{Version: header.FormatVersion}
+	}
+
+	var modes struct {
+		PlannedValues struct {
+			RootModule modesModule `json:"root_module"`
+		} `json:"planned_values"`
+		ResourceChanges []modesResource `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(src, &modes); err != nil {
+		return err
+	}
+	if err := modes.PlannedValues.RootModule.validate(); err != nil {
+		return err
+	}
+	for _, r := range modes.ResourceChanges {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(src, v)
+}
+
+// modesModule and modesResource decode just enough of a planned module and
+// its resources, or of a resource change, for Unmarshal to validate their
+// modes.
+type modesModule struct {
+	Resources    []modesResource `json:"resources"`
+	ChildModules []modesModule   `json:"child_modules"`
+}
+
+type modesResource struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+}
+
+func (m modesModule) validate() error {
+	for _, r := range m.Resources {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	for _, c := range m.ChildModules {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate returns an error unless the resource's mode is one of the known
+// modes. Every marshaled resource has a mode, so a missing one is invalid
+// too.
+func (r modesResource) validate() error {
+	switch r.Mode {
+	case ManagedResourceMode, DataResourceMode:
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q for %s; must be %q or %q", r.Mode, r.Address, ManagedResourceMode, DataResourceMode)
+	}
+}
+
+func (p *plan) marshalResourceChanges(changes *plans.Changes, config *configs.Config, s *states.State, schemas *terraform.Schemas) error {
+	if changes == nil {
+		// Nothing to do!
+		return nil
+	}
+	// Decoding the changes and marshaling their values is most of the work
+	// for a large plan, so the changes are marshaled concurrently by a
+	// bounded number of workers, each writing only its own elements of the
+	// results, which are then collected in their original order.
+	workers := p.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	results := make([]resourceChange, len(changes.Resources))
+	errs := make([]error, len(changes.Resources))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = p.marshalResourceChange(changes.Resources[i], config, s, schemas)
+			}
+		}()
+	}
+	for i, rc := range changes.Resources {
+		if rc.Action == plans.NoOp && p.changesOnly {
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, rc := range changes.Resources {
+		if rc.Action == plans.NoOp && p.changesOnly {
+			continue
+		}
+		if errs[i] != nil {
+			// The first error in the original order is returned, as it
+			// would be if the changes were marshaled one at a time.
+			return errs[i]
+		}
+		p.ResourceChanges = append(p.ResourceChanges, results[i])
+	}
+
+	// The changes are in no particular order in the plan, so they are
+	// sorted to make the result deterministic, with each current object
+	// coming before any deposed objects of the same instance.
+	sort.Slice(p.ResourceChanges, func(i, j int) bool {
+		a, b := p.ResourceChanges[i], p.ResourceChanges[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.Deposed < b.Deposed
+	})
+
+	return nil
+}
+
+// marshalResourceChange returns the representation of a single resource
+// instance change. It only reads from the plan and its arguments, so it may
+// be called concurrently.
+func (p *plan) marshalResourceChange(rc *plans.ResourceInstanceChangeSrc, config *configs.Config, s *states.State, schemas *terraform.Schemas) (resourceChange, error) {
+	var r resourceChange
+	addr := rc.Addr
+
+	schema, err := resourceSchema(schemas, rc.ProviderAddr.ProviderConfig.Type, addr.Resource.Resource)
+	if err != nil {
+		return r, err
+	}
+
+	changeV, err := rc.Decode(schema.ImpliedType())
+	if err != nil {
+		return r, err
+	}
+	r.SuppressedChanges = marshalSuppressedChanges(rc, changeV.After, config, schema, p.variables)
+	if p.noSensitive {
+		changeV.Before = stripSensitive(changeV.Before, schema)
+		changeV.After = stripSensitive(changeV.After, schema)
+	}
+
+	// The attribute changes are found before the values are removed, so
+	// that they are still described.
+	attrChanges := marshalAttributeChanges(changeV.Before, changeV.After)
+	if p.noValues {
+		changeV.Before = nullValues(changeV.Before)
+		changeV.After = nullValues(changeV.After)
+	}
+
+	var before, after []byte
+	if changeV.Before != cty.NilVal && !changeV.Before.IsNull() {
+		before, err = ctyjson.Marshal(changeV.Before, changeV.Before.Type())
+		if err != nil {
+			return r, err
+		}
+	}
+	if changeV.After != cty.NilVal && !changeV.After.IsNull() {
+		afterV := cty.UnknownAsNull(changeV.After)
+		after, err = ctyjson.Marshal(afterV, afterV.Type())
+		if err != nil {
+			return r, err
+		}
+	}
+
+	r.Change = change{
+		Actions: marshalActions(rc.Action),
+		Before:  json.RawMessage(before),
+		After:   json.RawMessage(after),
+
+		AttributeChanges: attrChanges,
+	}
+	if rc.Action.IsReplace() {
+		r.Change.ReplacePaths = marshalReplacePaths(rc.RequiredReplace)
+	}
+	r.Change.AfterUnknown, err = marshalUnknown(changeV.After)
+	if err != nil {
+		return r, err
+	}
+	if !p.noSensitive {
+		r.Change.BeforeSensitive, err = marshalSensitive(changeV.Before, schema)
+		if err != nil {
+			return r, err
+		}
+		r.Change.AfterSensitive, err = marshalSensitive(changeV.After, schema)
+		if err != nil {
+			return r, err
+		}
+	}
+
+	r.Address = addr.String()
+	if !addr.Module.IsRoot() {
+		r.ModuleAddress = addr.Module.String()
+	}
+
+	r.Mode = marshalMode(addr.Resource.Resource.Mode)
+	r.Type = addr.Resource.Resource.Type
+	r.Name = addr.Resource.Resource.Name
+	r.Index = addr.Resource.Key
+	r.ProviderName = rc.ProviderAddr.ProviderConfig.Type
+	r.ProviderConfigKey = rc.ProviderAddr.String()
+	r.SchemaVersion = changeSchemaVersion(rc, s, schemas)
+	r.Dependencies = resourceDependencies(rc, config, s, schemas)
+	r.ActionReason = changeActionReason(rc, config, s)
+	r.ConfigSource = resourceConfigSource(rc, config)
+
+	if rc.DeposedKey != states.NotDeposed {
+		r.Deposed = rc.DeposedKey.String()
+	}
+
+	return r, nil
+}
+
+// changeSchemaVersion returns the version of the resource type schema that
+// the values in the given change conform to.
+//
+// A change to a current object conforms to the schema the provider reported
+// during planning. A deposed object is only ever destroyed, so its change
+// describes the object as it was recorded in the prior state, which may
+// predate an upgrade of the provider's schema.
+func changeSchemaVersion(rc *plans.ResourceInstanceChangeSrc, s *states.State, schemas *terraform.Schemas) uint64 {
+	if rc.DeposedKey != states.NotDeposed && s != nil {
+		if ri := s.ResourceInstance(rc.Addr); ri != nil {
+			if obj := ri.Deposed[rc.DeposedKey]; obj != nil {
+				return obj.SchemaVersion
+			}
+		}
+	}
+
+	ps := schemas.ProviderSchema(rc.ProviderAddr.ProviderConfig.Type)
+	if ps == nil {
+		return 0
+	}
+	return ps.SchemaVersionForResourceAddr(rc.Addr.Resource.Resource)
+}
+
+// changeActionReason returns the keyword describing why the given change
+// was planned, as documented for resourceChange.ActionReason, or an empty
+// string if there is no particular reason to report.
+//
+// The planned change itself doesn't record a reason, so it is derived from
+// the prior state and the configuration the plan was created from. If config
+// is nil then reasons that depend on the configuration are not reported.
+func changeActionReason(rc *plans.ResourceInstanceChangeSrc, config *configs.Config, s *states.State) string {
+	if rc.DeposedKey != states.NotDeposed {
+		// Deposed objects are only ever destroyed, for which there's nothing
+		// more to say.
+		return ""
+	}
+
+	switch rc.Action {
+	case plans.DeleteThenCreate, plans.CreateThenDelete:
+		if s != nil {
+			if ri := s.ResourceInstance(rc.Addr); ri.HasCurrent() && ri.Current.Status == states.ObjectTainted {
+				return "tainted"
+			}
+		}
+		// An object that isn't tainted is only replaced if the change
+		// includes an attribute that the provider cannot update in-place.
+		return "cannot_update"
+	case plans.Delete:
+		if config == nil {
+			return ""
+		}
+		modCfg := config.DescendentForInstance(rc.Addr.Module)
+		if modCfg == nil || modCfg.Module.ResourceByAddr(rc.Addr.Resource.Resource) == nil {
+			return "delete_because_no_resource_config"
+		}
+	}
+	return ""
+}
+
+// resourceConfigSource returns the position of the block declaring the
+// resource of the given change, or nil if it isn't in config.
+func resourceConfigSource(rc *plans.ResourceInstanceChangeSrc, config *configs.Config) *configSource {
+	if config == nil {
+		return nil
+	}
+	modCfg := config.DescendentForInstance(rc.Addr.Module)
+	if modCfg == nil {
+		return nil
+	}
+	resCfg := modCfg.Module.ResourceByAddr(rc.Addr.Resource.Resource)
+	if resCfg == nil {
+		return nil
+	}
+	return &configSource{
+		Filename: resCfg.DeclRange.Filename,
+		Line:     resCfg.DeclRange.Start.Line,
+		Column:   resCfg.DeclRange.Start.Column,
+	}
+}
+
+// marshalActions returns the json representation of the given change
+// action, as the sequence of actions that make it up.
+func marshalActions(action plans.Action) []string {
+	switch action {
+	case plans.NoOp:
+		return []string{"no-op"}
+	case plans.Create:
+		return []string{"create"}
+	case plans.Read:
+		return []string{"read"}
+	case plans.Update:
+		return []string{"update"}
+	case plans.DeleteThenCreate:
+		return []string{"delete", "create"}
+	case plans.CreateThenDelete:
+		return []string{"create", "delete"}
+	case plans.Delete:
+		return []string{"delete"}
+	default:
+		// Should never happen, since the above is exhaustive.
+		return []string{action.String()}
+	}
+}
+
+func marshalMode(mode addrs.ResourceMode) string {
+	switch mode {
+	case addrs.ManagedResourceMode:
+		return ManagedResourceMode
+	case addrs.DataResourceMode:
+		return DataResourceMode
+	default:
+		// Should never happen, since the above is exhaustive.
+		return mode.String()
+	}
+}
+
+// resourceSchema returns the schema for the given resource, selecting
+// between the managed resource and data source schemas by the resource mode.
+func resourceSchema(schemas *terraform.Schemas, providerType string, addr addrs.Resource) (*configschema.Block, error) {
+	var schema *configschema.Block
+	switch addr.Mode {
+	case addrs.ManagedResourceMode:
+		schema = schemas.ResourceTypeConfig(providerType, addr.Type)
+	case addrs.DataResourceMode:
+		schema = schemas.DataSourceConfig(providerType, addr.Type)
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("no schema found for %s (resource type %s)", addr, QualifiedType(providerType, addr.Type))
+	}
+	return schema, nil
+}
@@ -0,0 +1,1331 @@
+package jsonplan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/command/addrfilter"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestMarshal(t *testing.T) {
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.NoKey).Child("grandchild", addrs.NoKey)
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Create, addrs.RootModuleInstance, "foo", addrs.IntKey(0), states.NotDeposed,
+					cty.NullVal(testThingType),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.UnknownVal(cty.String),
+						"woozles": cty.StringVal("confuzles"),
+					}),
+				),
+				testChange(t, plans.Delete, addrs.RootModuleInstance, "bar", addrs.NoKey, states.NotDeposed,
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("bar"),
+						"woozles": cty.NullVal(cty.String),
+					}),
+					cty.NullVal(testThingType),
+				),
+				testChange(t, plans.Delete, childAddr, "baz", addrs.NoKey, states.DeposedKey("deadbeef"),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("old"),
+						"woozles": cty.NullVal(cty.String),
+					}),
+					cty.NullVal(testThingType),
+				),
+				testChange(t, plans.DeleteThenCreate, childAddr, "baz", addrs.NoKey, states.NotDeposed,
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("baz"),
+						"woozles": cty.StringVal("before"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.UnknownVal(cty.String),
+						"woozles": cty.StringVal("after"),
+					}),
+				),
+			},
+		},
+	}
+
+	// The deposed object predates an upgrade of the resource type schema,
+	// so its change must report the version recorded in the prior state.
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceDeposed(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "baz",
+			}.Instance(addrs.NoKey).Absolute(childAddr),
+			states.DeposedKey("deadbeef"),
+			&states.ResourceInstanceObjectSrc{
+				Status:        states.ObjectReady,
+				SchemaVersion: 1,
+				AttrsJSON:     []byte(`{"id":"old"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(childAddr),
+		)
+	})
+
+	got, err := Marshal(nil, p, prior, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var gotV, wantV interface{}
+	if err := json.Unmarshal(got, &gotV); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "format_version": "0.2",
+  "plan_checksum": "3f3edd62a83d4fd500e812ac1d61734e910638fe025423751684079a47d162ad",
+  "applyable": true,
+  "errored": false,
+  "applyable_partial": false,
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "test_thing.foo[0]",
+          "mode": "managed",
+          "type": "test_thing",
+          "name": "foo",
+          "index": 0,
+          "provider_name": "test",
+          "provider_config_key": "provider.test",
+          "schema_version": 2,
+          "values": {"id": null, "woozles": "confuzles"},
+          "unknown": {"id": true},
+          "depends_on": ["provider.test"]
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.child",
+          "child_modules": [
+            {
+              "address": "module.child.module.grandchild",
+              "resources": [
+                {
+                  "address": "module.child.module.grandchild.test_thing.baz",
+                  "module_address": "module.child.module.grandchild",
+                  "mode": "managed",
+                  "type": "test_thing",
+                  "name": "baz",
+                  "provider_name": "test",
+                  "provider_config_key": "module.child.module.grandchild.provider.test",
+                  "schema_version": 2,
+                  "values": {"id": null, "woozles": "after"},
+                  "unknown": {"id": true},
+                  "depends_on": ["module.child.module.grandchild.provider.test"]
+                }
+              ]
+            }
+          ]
+        }
+      ]
+    }
+  },
+  "resource_changes": [
+    {
+      "address": "module.child.module.grandchild.test_thing.baz",
+      "module_address": "module.child.module.grandchild",
+      "mode": "managed",
+      "type": "test_thing",
+      "name": "baz",
+      "provider_name": "test",
+      "provider_config_key": "module.child.module.grandchild.provider.test",
+      "schema_version": 2,
+      "dependencies": ["module.child.module.grandchild.provider.test"],
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"id": "baz", "woozles": "before"},
+        "after": {"id": null, "woozles": "after"},
+        "before_sensitive": {},
+        "after_sensitive": {},
+        "after_unknown": {"id": true},
+        "attribute_changes": [
+          {"path": ["id"], "action": "update", "unknown": true},
+          {"path": ["woozles"], "action": "update"}
+        ]
+      },
+      "action_reason": "cannot_update"
+    },
+    {
+      "address": "module.child.module.grandchild.test_thing.baz",
+      "module_address": "module.child.module.grandchild",
+      "mode": "managed",
+      "type": "test_thing",
+      "name": "baz",
+      "provider_name": "test",
+      "provider_config_key": "module.child.module.grandchild.provider.test",
+      "deposed": "deadbeef",
+      "schema_version": 1,
+      "dependencies": ["module.child.module.grandchild.provider.test"],
+      "change": {
+        "actions": ["delete"],
+        "before": {"id": "old", "woozles": null},
+        "before_sensitive": {},
+        "attribute_changes": [
+          {"path": ["id"], "action": "remove"}
+        ]
+      }
+    },
+    {
+      "address": "test_thing.bar",
+      "mode": "managed",
+      "type": "test_thing",
+      "name": "bar",
+      "provider_name": "test",
+      "provider_config_key": "provider.test",
+      "schema_version": 2,
+      "dependencies": ["provider.test"],
+      "change": {
+        "actions": ["delete"],
+        "before": {"id": "bar", "woozles": null},
+        "before_sensitive": {},
+        "attribute_changes": [
+          {"path": ["id"], "action": "remove"}
+        ]
+      }
+    },
+    {
+      "address": "test_thing.foo[0]",
+      "mode": "managed",
+      "type": "test_thing",
+      "name": "foo",
+      "index": 0,
+      "provider_name": "test",
+      "provider_config_key": "provider.test",
+      "schema_version": 2,
+      "dependencies": ["provider.test"],
+      "change": {
+        "actions": ["create"],
+        "after": {"id": null, "woozles": "confuzles"},
+        "after_sensitive": {},
+        "after_unknown": {"id": true},
+        "attribute_changes": [
+          {"path": ["id"], "action": "update", "unknown": true},
+          {"path": ["woozles"], "action": "add"}
+        ]
+      }
+    }
+  ],
+  "prior_state": {
+    "format_version": "0.1",
+    "values": {
+      "root_module": {
+        "child_modules": [
+          {
+            "address": "module.child",
+            "child_modules": [
+              {
+                "address": "module.child.module.grandchild",
+                "resources": [
+                  {
+                    "address": "module.child.module.grandchild.test_thing.baz",
+                    "mode": "managed",
+                    "type": "test_thing",
+                    "name": "baz",
+                    "provider_name": "test",
+                    "deposed_key": "deadbeef",
+                    "status": "ready",
+                    "schema_version": 1,
+                    "values": {"id": "old", "woozles": null}
+                  }
+                ]
+              }
+            ]
+          }
+        ]
+      }
+    }
+  }
+}`
+	if err := json.Unmarshal([]byte(want), &wantV); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(gotV, wantV) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(wantV, gotV))
+	}
+}
+
+func TestMarshal_dependencies(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir("testdata/dependencies")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	config, diags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Update, addrs.RootModuleInstance, "b", addrs.NoKey, states.NotDeposed,
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("b"),
+						"woozles": cty.StringVal("old"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("b"),
+						"woozles": cty.UnknownVal(cty.String),
+					}),
+				),
+			},
+		},
+	}
+
+	// The prior state records a dependency on an object that has since
+	// been removed from the configuration, along with a duplicate of one
+	// that is still present.
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "b",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"b","woozles":"old"}`),
+				Dependencies: []addrs.Referenceable{
+					addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "test_thing", Name: "a"},
+					addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "test_thing", Name: "d"},
+				},
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	got, err := Marshal(config, p, prior, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var output struct {
+		PlannedValues struct {
+			RootModule struct {
+				Resources []struct {
+					DependsOn []string `json:"depends_on"`
+				} `json:"resources"`
+			} `json:"root_module"`
+		} `json:"planned_values"`
+		ResourceChanges []struct {
+			Dependencies []string `json:"dependencies"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(got, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"provider.test",
+		"test_thing.a",
+		"test_thing.c",
+		"test_thing.d",
+	}
+	if got := output.ResourceChanges[0].Dependencies; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong change dependencies\n%s", cmp.Diff(want, got))
+	}
+	if got := output.PlannedValues.RootModule.Resources[0].DependsOn; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong resource depends_on\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMarshal_configuration(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir("testdata/dependencies")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	config, diags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	p := &plans.Plan{Changes: plans.NewChanges()}
+
+	for name, test := range map[string]struct {
+		config     *configs.Config
+		withConfig bool
+		want       bool
+	}{
+		"requested":     {config, true, true},
+		"not requested": {config, false, false},
+		"no config":     {nil, true, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, err := Marshal(test.config, p, nil, nil, testSchemas(), nil, &MarshalOpts{WithConfig: test.withConfig})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var got struct {
+				Configuration *struct {
+					RootModule struct {
+						Resources []struct {
+							Address string `json:"address"`
+						} `json:"resources"`
+					} `json:"root_module"`
+				} `json:"configuration"`
+			}
+			if err := json.Unmarshal(src, &got); err != nil {
+				t.Fatal(err)
+			}
+			if (got.Configuration != nil) != test.want {
+				t.Fatalf("wrong configuration; want configuration: %t\n%s", test.want, src)
+			}
+			if got.Configuration != nil && len(got.Configuration.RootModule.Resources) != len(config.Module.ManagedResources) {
+				t.Errorf("wrong resources in the configuration\n%s", src)
+			}
+		})
+	}
+}
+
+func TestMarshal_actionReason(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir("testdata/dependencies")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	config, diags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	obj := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("x"),
+		"woozles": cty.StringVal("x"),
+	})
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.CreateThenDelete, addrs.RootModuleInstance, "a", addrs.NoKey, states.NotDeposed, obj, obj),
+				testChange(t, plans.DeleteThenCreate, addrs.RootModuleInstance, "c", addrs.NoKey, states.NotDeposed, obj, obj),
+				testChange(t, plans.Delete, addrs.RootModuleInstance, "b", addrs.IntKey(1), states.NotDeposed, obj, cty.NullVal(testThingType)),
+				testChange(t, plans.Delete, addrs.RootModuleInstance, "gone", addrs.NoKey, states.NotDeposed, obj, cty.NullVal(testThingType)),
+				testChange(t, plans.Delete, addrs.RootModuleInstance, "gone", addrs.NoKey, states.DeposedKey("deadbeef"), obj, cty.NullVal(testThingType)),
+				testChange(t, plans.Update, addrs.RootModuleInstance, "b", addrs.IntKey(0), states.NotDeposed, obj, obj),
+			},
+		},
+	}
+
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "a",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectTainted,
+				AttrsJSON: []byte(`{"id":"x","woozles":"x"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	got, err := Marshal(config, p, prior, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var output struct {
+		ResourceChanges []struct {
+			Address      string `json:"address"`
+			Deposed      string `json:"deposed"`
+			ActionReason string `json:"action_reason"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(got, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"test_thing.a: tainted",
+		"test_thing.b[0]: ",
+		"test_thing.b[1]: ",
+		"test_thing.c: cannot_update",
+		"test_thing.gone: delete_because_no_resource_config",
+		"test_thing.gone (deadbeef): ",
+	}
+	var gotReasons []string
+	for _, rc := range output.ResourceChanges {
+		addr := rc.Address
+		if rc.Deposed != "" {
+			addr += " (" + rc.Deposed + ")"
+		}
+		gotReasons = append(gotReasons, addr+": "+rc.ActionReason)
+	}
+	if !reflect.DeepEqual(gotReasons, want) {
+		t.Errorf("wrong action reasons\n%s", cmp.Diff(want, gotReasons))
+	}
+}
+
+func TestMarshal_configSource(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir("testdata/dependencies")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	config, diags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	obj := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("x"),
+		"woozles": cty.StringVal("x"),
+	})
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Update, addrs.RootModuleInstance, "b", addrs.NoKey, states.NotDeposed, obj, obj),
+				testChange(t, plans.Delete, addrs.RootModuleInstance, "gone", addrs.NoKey, states.NotDeposed, obj, cty.NullVal(testThingType)),
+			},
+		},
+	}
+
+	for name, test := range map[string]struct {
+		config *configs.Config
+		want   []*configSource
+	}{
+		"with config": {
+			config,
+			[]*configSource{
+				{Filename: "testdata/dependencies/main.tf", Line: 4, Column: 1},
+				nil,
+			},
+		},
+		"without config": {
+			nil,
+			[]*configSource{nil, nil},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Marshal(test.config, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var output struct {
+				ResourceChanges []struct {
+					ConfigSource *configSource `json:"config_source"`
+				} `json:"resource_changes"`
+			}
+			if err := json.Unmarshal(got, &output); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotSources []*configSource
+			for _, rc := range output.ResourceChanges {
+				gotSources = append(gotSources, rc.ConfigSource)
+			}
+			if !reflect.DeepEqual(gotSources, test.want) {
+				t.Errorf("wrong config sources\n%s", cmp.Diff(test.want, gotSources))
+			}
+		})
+	}
+}
+
+func TestMarshal_deterministic(t *testing.T) {
+	var changes []*plans.ResourceInstanceChangeSrc
+	var outputs []*plans.OutputChangeSrc
+	for _, module := range []addrs.ModuleInstance{
+		addrs.RootModuleInstance,
+		addrs.RootModuleInstance.Child("a", addrs.NoKey),
+		addrs.RootModuleInstance.Child("b", addrs.IntKey(0)),
+		addrs.RootModuleInstance.Child("b", addrs.IntKey(1)).Child("c", addrs.StringKey("x")),
+	} {
+		for _, name := range []string{"foo", "bar", "baz"} {
+			changes = append(changes, testChange(t, plans.Create, module, name, addrs.NoKey, states.NotDeposed,
+				cty.NullVal(testThingType),
+				cty.ObjectVal(map[string]cty.Value{
+					"id":      cty.UnknownVal(cty.String),
+					"woozles": cty.StringVal(name),
+				}),
+			))
+		}
+		changes = append(changes, testChange(t, plans.Delete, module, "qux", addrs.NoKey, states.DeposedKey("deadbeef"),
+			cty.ObjectVal(map[string]cty.Value{
+				"id":      cty.StringVal("old"),
+				"woozles": cty.NullVal(cty.String),
+			}),
+			cty.NullVal(testThingType),
+		))
+	}
+	for _, name := range []string{"one", "two", "three"} {
+		outputs = append(outputs, testOutputChange(t, addrs.RootModuleInstance, name, plans.Create, false,
+			cty.NullVal(cty.DynamicPseudoType),
+			cty.MapVal(map[string]cty.Value{"a": cty.StringVal("b"), "c": cty.StringVal("d")}),
+		))
+	}
+
+	marshal := func(changes []*plans.ResourceInstanceChangeSrc) []byte {
+		t.Helper()
+		p := &plans.Plan{
+			VariableValues: map[string]plans.DynamicValue{
+				"x": testVariableValue(t, cty.StringVal("1")),
+				"y": testVariableValue(t, cty.StringVal("2")),
+			},
+			Changes: &plans.Changes{
+				Resources: changes,
+				Outputs:   outputs,
+			},
+		}
+		src, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return src
+	}
+
+	want := marshal(changes)
+
+	// Go randomizes map iteration order, so several runs are needed to
+	// catch a result built by iterating over a map.
+	for i := 0; i < 10; i++ {
+		if got := marshal(changes); !bytes.Equal(got, want) {
+			t.Fatalf("result changed when marshaled again\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+
+	reversed := make([]*plans.ResourceInstanceChangeSrc, len(changes))
+	for i, rc := range changes {
+		reversed[len(changes)-1-i] = rc
+	}
+	if got := marshal(reversed); !bytes.Equal(got, want) {
+		t.Fatalf("result depends on the order of the changes in the plan\ngot:  %s\nwant: %s", got, want)
+	}
+
+	var got plan
+	if err := json.Unmarshal(want, &got); err != nil {
+		t.Fatal(err)
+	}
+	sorted := sort.SliceIsSorted(got.ResourceChanges, func(i, j int) bool {
+		a, b := got.ResourceChanges[i], got.ResourceChanges[j]
+		return a.Address < b.Address || (a.Address == b.Address && a.Deposed < b.Deposed)
+	})
+	if !sorted {
+		t.Errorf("resource changes are not sorted by address\n%s", want)
+	}
+}
+
+func TestMarshal_applyable(t *testing.T) {
+	obj := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("x"),
+		"woozles": cty.StringVal("x"),
+	})
+	noOp := testChange(t, plans.NoOp, addrs.RootModuleInstance, "a", addrs.NoKey, states.NotDeposed, obj, obj)
+	update := testChange(t, plans.Update, addrs.RootModuleInstance, "b", addrs.NoKey, states.NotDeposed, obj, obj)
+	output := testOutputChange(t, addrs.RootModuleInstance, "foo", plans.Create, false,
+		cty.NullVal(cty.DynamicPseudoType),
+		cty.StringVal("hello"),
+	)
+
+	for name, test := range map[string]struct {
+		changes *plans.Changes
+		want    bool
+	}{
+		"no changes": {
+			&plans.Changes{},
+			false,
+		},
+		"only no-ops": {
+			&plans.Changes{Resources: []*plans.ResourceInstanceChangeSrc{noOp}},
+			false,
+		},
+		"resource change": {
+			&plans.Changes{Resources: []*plans.ResourceInstanceChangeSrc{noOp, update}},
+			true,
+		},
+		"output change": {
+			&plans.Changes{
+				Resources: []*plans.ResourceInstanceChangeSrc{noOp},
+				Outputs:   []*plans.OutputChangeSrc{output},
+			},
+			true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The changes-only option leaves out the no-ops, but doesn't
+			// change whether the plan is applyable.
+			got, err := Marshal(nil, &plans.Plan{Changes: test.changes}, nil, nil, testSchemas(), nil, &MarshalOpts{ChangesOnly: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var doc struct {
+				Applyable *bool `json:"applyable"`
+				Errored   *bool `json:"errored"`
+			}
+			if err := json.Unmarshal(got, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Applyable == nil || *doc.Applyable != test.want {
+				t.Errorf("wrong applyable %s; want %t", got, test.want)
+			}
+			if doc.Errored == nil || *doc.Errored {
+				t.Errorf("wrong errored %s; want false", got)
+			}
+		})
+	}
+}
+
+func TestMarshal_targets(t *testing.T) {
+	for name, test := range map[string]struct {
+		targets     []addrs.Targetable
+		wantPartial bool
+		wantTargets []string
+	}{
+		"none": {nil, false, nil},
+		"some": {
+			[]addrs.Targetable{
+				addrs.RootModuleInstance.Child("network", addrs.NoKey),
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_thing",
+					Name: "foo",
+				}.Instance(addrs.IntKey(1)).Absolute(addrs.RootModuleInstance),
+			},
+			true,
+			[]string{"module.network", "test_thing.foo[1]"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := &plans.Plan{Changes: &plans.Changes{}, TargetAddrs: test.targets}
+			got, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var doc struct {
+				ApplyablePartial *bool    `json:"applyable_partial"`
+				Targets          []string `json:"targets"`
+			}
+			if err := json.Unmarshal(got, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.ApplyablePartial == nil || *doc.ApplyablePartial != test.wantPartial {
+				t.Errorf("wrong applyable_partial %s; want %t", got, test.wantPartial)
+			}
+			if !reflect.DeepEqual(doc.Targets, test.wantTargets) {
+				t.Errorf("wrong targets %#v; want %#v", doc.Targets, test.wantTargets)
+			}
+		})
+	}
+}
+
+func TestMarshal_plannedModuleAddress(t *testing.T) {
+	after := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("x"),
+		"woozles": cty.StringVal("x"),
+	})
+	moduleAddrs := []addrs.ModuleInstance{
+		addrs.RootModuleInstance,
+		addrs.RootModuleInstance.Child("a", addrs.IntKey(0)),
+		addrs.RootModuleInstance.Child("a", addrs.StringKey("x")).Child("b", addrs.NoKey),
+	}
+	p := &plans.Plan{Changes: &plans.Changes{}}
+	for _, module := range moduleAddrs {
+		p.Changes.Resources = append(p.Changes.Resources,
+			testChange(t, plans.Create, module, "foo", addrs.NoKey, states.NotDeposed, cty.NullVal(testThingType), after),
+		)
+	}
+
+	src, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type module struct {
+		Resources []struct {
+			Address       string `json:"address"`
+			ModuleAddress string `json:"module_address"`
+		} `json:"resources"`
+		ChildModules []module `json:"child_modules"`
+	}
+	var got struct {
+		PlannedValues struct {
+			RootModule module `json:"root_module"`
+		} `json:"planned_values"`
+	}
+	if err := Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each module address parses back to the module of the change, and is
+	// a prefix of the resource's address.
+	var found int
+	var check func(m module)
+	check = func(m module) {
+		for _, r := range m.Resources {
+			found++
+			modAddr := addrs.RootModuleInstance
+			if r.ModuleAddress != "" {
+				parsed, diags := addrs.ParseModuleInstanceStr(r.ModuleAddress)
+				if diags.HasErrors() {
+					t.Errorf("%s: invalid module address %q: %s", r.Address, r.ModuleAddress, diags.Err())
+					continue
+				}
+				modAddr = parsed
+			}
+			want := addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(modAddr).String()
+			if r.Address != want {
+				t.Errorf("module address %q doesn't match resource address %q", r.ModuleAddress, r.Address)
+			}
+		}
+		for _, child := range m.ChildModules {
+			check(child)
+		}
+	}
+	check(got.PlannedValues.RootModule)
+	if found != len(moduleAddrs) {
+		t.Errorf("found %d planned resources; want %d", found, len(moduleAddrs))
+	}
+}
+
+func TestMarshal_noChanges(t *testing.T) {
+	// An empty prior state, as for a first apply, is omitted in the same way
+	// as no prior state at all.
+	for _, s := range []*states.State{nil, states.NewState()} {
+		got, err := Marshal(nil, &plans.Plan{}, s, nil, testSchemas(), nil, &MarshalOpts{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := `{"format_version":"0.2","planned_values":{"root_module":{}},"applyable":false,"errored":false,"applyable_partial":false}`
+		if string(got) != want {
+			t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestMarshal_changesOnly(t *testing.T) {
+	val := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("foo"),
+		"woozles": cty.StringVal("confuzles"),
+	})
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.NoOp, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed, val, val),
+				testChange(t, plans.NoOp, childAddr, "foo", addrs.NoKey, states.NotDeposed, val, val),
+				testChange(t, plans.Create, addrs.RootModuleInstance, "bar", addrs.NoKey, states.NotDeposed, cty.NullVal(testThingType), val),
+			},
+		},
+	}
+
+	tests := map[bool][]string{
+		false: {"module.child.test_thing.foo", "test_thing.bar", "test_thing.foo"},
+		true:  {"test_thing.bar"},
+	}
+	for changesOnly, want := range tests {
+		src, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{ChangesOnly: changesOnly})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var got plan
+		if err := json.Unmarshal(src, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		var gotChanges []string
+		for _, rc := range got.ResourceChanges {
+			gotChanges = append(gotChanges, rc.Address)
+		}
+		if !reflect.DeepEqual(gotChanges, want) {
+			t.Errorf("wrong resource changes with changesOnly %t\n%s", changesOnly, cmp.Diff(want, gotChanges))
+		}
+
+		var gotPlanned []string
+		var walk func(m module)
+		walk = func(m module) {
+			for _, r := range m.Resources {
+				gotPlanned = append(gotPlanned, r.Address)
+			}
+			for _, c := range m.ChildModules {
+				walk(c)
+			}
+		}
+		walk(got.PlannedValues.RootModule)
+		sort.Strings(gotPlanned)
+		sort.Strings(want)
+		if !reflect.DeepEqual(gotPlanned, want) {
+			t.Errorf("wrong planned values with changesOnly %t\n%s", changesOnly, cmp.Diff(want, gotPlanned))
+		}
+	}
+}
+
+func TestMarshal_filter(t *testing.T) {
+	val := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("foo"),
+		"woozles": cty.StringVal("confuzles"),
+	})
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Update, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed, val, val),
+				testChange(t, plans.Update, childAddr, "foo", addrs.NoKey, states.NotDeposed, val, val),
+				testChange(t, plans.Create, addrs.RootModuleInstance, "bar", addrs.NoKey, states.NotDeposed, cty.NullVal(testThingType), val),
+			},
+		},
+	}
+	prior := states.BuildState(func(s *states.SyncState) {
+		for _, module := range []addrs.ModuleInstance{addrs.RootModuleInstance, childAddr} {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_thing",
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{"id":"foo","woozles":"confuzles"}`),
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+	filter := addrfilter.New([]addrs.Targetable{
+		childAddr,
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_thing",
+			Name: "bar",
+		}.Absolute(addrs.RootModuleInstance),
+	})
+
+	src, err := Marshal(nil, p, prior, nil, testSchemas(), nil, &MarshalOpts{Filter: filter})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got struct {
+		plan
+		PriorState struct {
+			Values struct {
+				RootModule module `json:"root_module"`
+			} `json:"values"`
+		} `json:"prior_state"`
+	}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	resourceAddrs := func(m module) []string {
+		var ret []string
+		var walk func(m module)
+		walk = func(m module) {
+			for _, r := range m.Resources {
+				ret = append(ret, r.Address)
+			}
+			for _, c := range m.ChildModules {
+				walk(c)
+			}
+		}
+		walk(m)
+		sort.Strings(ret)
+		return ret
+	}
+
+	var gotChanges []string
+	for _, rc := range got.ResourceChanges {
+		gotChanges = append(gotChanges, rc.Address)
+	}
+	if want := []string{"module.child.test_thing.foo", "test_thing.bar"}; !reflect.DeepEqual(gotChanges, want) {
+		t.Errorf("wrong resource changes\n%s", cmp.Diff(want, gotChanges))
+	}
+	gotPlanned := resourceAddrs(got.PlannedValues.RootModule)
+	if want := []string{"module.child.test_thing.foo", "test_thing.bar"}; !reflect.DeepEqual(gotPlanned, want) {
+		t.Errorf("wrong planned values\n%s", cmp.Diff(want, gotPlanned))
+	}
+	gotPrior := resourceAddrs(got.PriorState.Values.RootModule)
+	if want := []string{"module.child.test_thing.foo"}; !reflect.DeepEqual(gotPrior, want) {
+		t.Errorf("wrong prior state\n%s", cmp.Diff(want, gotPrior))
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Create, addrs.RootModuleInstance, "foo", addrs.IntKey(0), states.NotDeposed,
+					cty.NullVal(testThingType),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.UnknownVal(cty.String),
+						"woozles": cty.StringVal("confuzles"),
+					}),
+				),
+			},
+		},
+	}
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "bar",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"bar"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	compact, err := Marshal(nil, p, prior, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	indented, err := MarshalIndent(nil, p, prior, nil, testSchemas(), nil, &MarshalOpts{}, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if bytes.Equal(compact, indented) {
+		t.Fatalf("MarshalIndent result is not indented\n%s", indented)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, indented); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), string(compact); got != want {
+		t.Errorf("wrong result after compacting\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestMarshal_timestamp(t *testing.T) {
+	created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+	tests := map[string]struct {
+		Timestamp time.Time
+		Want      string
+	}{
+		"known":   {created, "2019-01-02T08:04:05Z"},
+		"unknown": {time.Time{}, ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			src, err := Marshal(nil, &plans.Plan{Timestamp: tc.Timestamp}, nil, nil, testSchemas(), nil, &MarshalOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(src, &got); err != nil {
+				t.Fatal(err)
+			}
+			ts, ok := got["timestamp"]
+			if tc.Want == "" {
+				if ok {
+					t.Fatalf("unexpected timestamp %#v", ts)
+				}
+				return
+			}
+			if ts != tc.Want {
+				t.Fatalf("wrong timestamp %#v; want %q", ts, tc.Want)
+			}
+			parsed, err := time.Parse(time.RFC3339, ts.(string))
+			if err != nil {
+				t.Fatalf("timestamp is not RFC3339: %s", err)
+			}
+			if !parsed.Equal(tc.Timestamp) {
+				t.Errorf("wrong time %s; want %s", parsed, tc.Timestamp)
+			}
+		})
+	}
+}
+
+func TestMarshalChanges(t *testing.T) {
+	changes := &plans.Changes{
+		Resources: []*plans.ResourceInstanceChangeSrc{
+			testChange(t, plans.Update, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed,
+				cty.ObjectVal(map[string]cty.Value{
+					"id":      cty.StringVal("foo"),
+					"woozles": cty.StringVal("before"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"id":      cty.StringVal("foo"),
+					"woozles": cty.StringVal("after"),
+				}),
+			),
+		},
+	}
+
+	src, err := MarshalChanges(changes, nil, testSchemas(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["format_version"] != FormatVersion {
+		t.Errorf("wrong properties in %s", src)
+	}
+	if rcs, _ := got["resource_changes"].([]interface{}); len(rcs) != 1 {
+		t.Errorf("wrong resource_changes in %s", src)
+	}
+
+	src, err = MarshalChanges(&plans.Changes{}, nil, testSchemas(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := string(src), `{"format_version":"`+FormatVersion+`","resource_changes":[]}`; got != want {
+		t.Errorf("wrong result for no changes\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	src, err := Marshal(nil, &plans.Plan{}, nil, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got map[string]interface{}
+	if err := Unmarshal(src, &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["format_version"] != FormatVersion {
+		t.Errorf("wrong format_version %#v; want %q", got["format_version"], FormatVersion)
+	}
+	if _, ok := got["planned_values"]; !ok {
+		t.Errorf("missing planned_values in %#v", got)
+	}
+}
+
+func TestUnmarshal_unsupportedVersion(t *testing.T) {
+	var got map[string]interface{}
+	err := Unmarshal([]byte(`{"format_version":"1.0","planned_values":{}}`), &got)
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if verErr, ok := err.(*UnsupportedFormatVersionError); !ok {
+		t.Fatalf("wrong error type %T: %s", err, err)
+	} else if verErr.Version != "1.0" {
+		t.Errorf("wrong version %q; want %q", verErr.Version, "1.0")
+	}
+	if got != nil {
+		t.Errorf("value was decoded despite the error: %#v", got)
+	}
+}
+
+func TestUnmarshal_invalid(t *testing.T) {
+	tests := map[string]string{
+		"no version":      `{"planned_values":{}}`,
+		"invalid version": `{"format_version":"banana"}`,
+		"not json":        `planned_values`,
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]interface{}
+			err := Unmarshal([]byte(src), &got)
+			if err == nil {
+				t.Fatal("succeeded; want error")
+			}
+			if _, ok := err.(*UnsupportedFormatVersionError); ok {
+				t.Fatalf("wrong error type %T: %s", err, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_invalidMode(t *testing.T) {
+	tests := map[string]string{
+		"resource change":                 `{"format_version":"0.2","resource_changes":[{"address":"test_thing.foo","mode":"manged"}]}`,
+		"planned resource":                `{"format_version":"0.2","planned_values":{"root_module":{"resources":[{"address":"test_thing.foo","mode":""}]}}}`,
+		"resource change without a mode":  `{"format_version":"0.2","resource_changes":[{"address":"test_thing.foo"}]}`,
+		"planned resource without a mode": `{"format_version":"0.2","planned_values":{"root_module":{"resources":[{"address":"test_thing.foo"}]}}}`,
+		"child module":                    `{"format_version":"0.2","planned_values":{"root_module":{"child_modules":[{"resources":[{"address":"module.child.test_thing.foo","mode":"Data"}]}]}}}`,
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]interface{}
+			err := Unmarshal([]byte(src), &got)
+			if err == nil {
+				t.Fatal("succeeded; want error")
+			}
+			if !strings.Contains(err.Error(), "invalid mode") {
+				t.Errorf("wrong error: %s", err)
+			}
+			if got != nil {
+				t.Errorf("value was decoded despite the error: %#v", got)
+			}
+		})
+	}
+
+	var got map[string]interface{}
+	src := `{"format_version":"0.2","resource_changes":[{"address":"data.test_thing.foo","mode":"data"},{"address":"test_thing.foo","mode":"managed"}]}`
+	if err := Unmarshal([]byte(src), &got); err != nil {
+		t.Errorf("unexpected error for valid modes: %s", err)
+	}
+}
+
+func TestMarshalResourceChanges_workers(t *testing.T) {
+	changes := testManyChanges(t, 1000)
+
+	marshal := func(workers int) []byte {
+		t.Helper()
+		p := &plan{workers: workers}
+		if err := p.marshalResourceChanges(changes, nil, nil, testSchemas()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		src, err := json.Marshal(p.ResourceChanges)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	want := marshal(1)
+	for _, workers := range []int{2, 7, 64} {
+		if got := marshal(workers); !bytes.Equal(got, want) {
+			t.Errorf("result with %d workers differs from the sequential result", workers)
+		}
+	}
+
+	// The error for the first failing change is returned, whichever worker
+	// fails first.
+	bad := &plans.Changes{Resources: append([]*plans.ResourceInstanceChangeSrc(nil), changes.Resources...)}
+	for _, i := range []int{10, 500} {
+		rc := *bad.Resources[i]
+		rc.ProviderAddr = addrs.ProviderConfig{Type: fmt.Sprintf("missing%d", i)}.Absolute(addrs.RootModuleInstance)
+		bad.Resources[i] = &rc
+	}
+	p := &plan{workers: 16}
+	err := p.marshalResourceChanges(bad, nil, nil, testSchemas())
+	if err == nil || !strings.Contains(err.Error(), "missing10") {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func BenchmarkMarshalResourceChanges(b *testing.B) {
+	changes := testManyChanges(b, 20000)
+	schemas := testSchemas()
+
+	for name, workers := range map[string]int{"sequential": 1, "parallel": 0} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := &plan{workers: workers}
+				if err := p.marshalResourceChanges(changes, nil, nil, schemas); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// testManyChanges returns n synthetic changes to the instances of a counted
+// resource, with a mixture of actions.
+func testManyChanges(t testing.TB, n int) *plans.Changes {
+	t.Helper()
+
+	ret := &plans.Changes{}
+	for i := 0; i < n; i++ {
+		before := cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.StringVal(fmt.Sprintf("i-%08d", i)),
+			"woozles": cty.StringVal(strings.Repeat("confuzles ", 20)),
+		})
+		after := cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.UnknownVal(cty.String),
+			"woozles": cty.StringVal(strings.Repeat("bamboozles ", 20)),
+		})
+		action := plans.Update
+		switch i % 3 {
+		case 1:
+			action, before = plans.Create, cty.NullVal(testThingType)
+		case 2:
+			action = plans.DeleteThenCreate
+		}
+		ret.Resources = append(ret.Resources, testChange(t, action, addrs.RootModuleInstance, "many", addrs.IntKey(i), states.NotDeposed, before, after))
+	}
+	return ret
+}
+
+var testThingType = cty.Object(map[string]cty.Type{
+	"id":      cty.String,
+	"woozles": cty.String,
+})
+
+func TestMarshalActions(t *testing.T) {
+	tests := map[plans.Action][]string{
+		plans.NoOp:             {"no-op"},
+		plans.Create:           {"create"},
+		plans.Read:             {"read"},
+		plans.Update:           {"update"},
+		plans.DeleteThenCreate: {"delete", "create"},
+		plans.CreateThenDelete: {"create", "delete"},
+		plans.Delete:           {"delete"},
+	}
+	for action, want := range tests {
+		if got := marshalActions(action); !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong result for %s\ngot:  %#v\nwant: %#v", action, got, want)
+		}
+	}
+}
+
+func testChange(t testing.TB, action plans.Action, module addrs.ModuleInstance, name string, key addrs.InstanceKey, deposed states.DeposedKey, before, after cty.Value) *plans.ResourceInstanceChangeSrc {
+	t.Helper()
+
+	rc := &plans.ResourceInstanceChange{
+		Addr: addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_thing",
+			Name: name,
+		}.Instance(key).Absolute(module),
+		DeposedKey:   deposed,
+		ProviderAddr: addrs.ProviderConfig{Type: "test"}.Absolute(module),
+		Change: plans.Change{
+			Action: action,
+			Before: before,
+			After:  after,
+		},
+	}
+	rcs, err := rc.Encode(testThingType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rcs
+}
+
+func testSchemas() *terraform.Schemas {
+	return &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				ResourceTypes: map[string]*configschema.Block{
+					"test_thing": {
+						Attributes: map[string]*configschema.Attribute{
+							"id":      {Type: cty.String, Computed: true},
+							"woozles": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+				ResourceTypeSchemaVersions: map[string]uint64{
+					"test_thing": 2,
+				},
+			},
+		},
+	}
+}
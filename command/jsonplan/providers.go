@@ -0,0 +1,53 @@
+package jsonplan
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+// marshalProviderVersions returns the versions of the providers that the
+// given plan was created with, keyed by provider name, or nil if none are
+// known.
+//
+// A plan records the SHA256 hash of each provider executable it was created
+// with rather than its version, so the version is taken from whichever of the
+// given plugins has a matching executable. Providers with no matching plugin
+// are omitted.
+func marshalProviderVersions(p *plans.Plan, plugins discovery.PluginMetaSet) map[string]string {
+	var ret map[string]string
+	for name, want := range p.ProviderSHA256s {
+		for meta := range plugins.WithName(name) {
+			got, err := meta.SHA256()
+			if err != nil || !bytes.Equal(got, want) {
+				continue
+			}
+			if ret == nil {
+				ret = make(map[string]string)
+			}
+			ret[name] = string(meta.Version)
+			break
+		}
+	}
+	return ret
+}
+
+// marshalProviderHashes returns the hex SHA256 hashes of the provider
+// executables that the given plan was created with, keyed by provider name,
+// or nil if the plan records none.
+//
+// These are the hashes that the plugin lock held when the plan was created,
+// and that apply requires the providers to match, so a consumer can use them
+// to check that no provider executable has been replaced since.
+func marshalProviderHashes(p *plans.Plan) map[string]string {
+	if len(p.ProviderSHA256s) == 0 {
+		return nil
+	}
+	ret := make(map[string]string, len(p.ProviderSHA256s))
+	for name, hash := range p.ProviderSHA256s {
+		ret[name] = hex.EncodeToString(hash)
+	}
+	return ret
+}
@@ -0,0 +1,83 @@
+package jsonplan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+func TestMarshalProviderVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-jsonplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plugins := make(discovery.PluginMetaSet)
+	addPlugin := func(name, version, content string) []byte {
+		path := filepath.Join(dir, "terraform-provider-"+name+"_v"+version)
+		if err := ioutil.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+		plugins.Add(discovery.PluginMeta{
+			Name:    name,
+			Version: discovery.VersionStr(version),
+			Path:    path,
+		})
+		sum := sha256.Sum256([]byte(content))
+		return sum[:]
+	}
+	awsOld := addPlugin("aws", "1.0.0", "aws 1.0.0")
+	addPlugin("aws", "2.0.0", "aws 2.0.0")
+	addPlugin("null", "1.0.0", "null 1.0.0")
+
+	p := &plans.Plan{
+		ProviderSHA256s: map[string][]byte{
+			"aws":      awsOld,
+			"null":     []byte("not the hash of any plugin"),
+			"template": []byte("not installed"),
+		},
+	}
+
+	got := marshalProviderVersions(p, plugins)
+	want := map[string]string{
+		"aws": "1.0.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if got := marshalProviderVersions(p, nil); got != nil {
+		t.Errorf("wrong result with no plugins\ngot:  %#v\nwant: nil", got)
+	}
+}
+
+func TestMarshalProviderHashes(t *testing.T) {
+	sum := sha256.Sum256([]byte("aws 1.0.0"))
+	p := &plans.Plan{
+		ProviderSHA256s: map[string][]byte{
+			"aws":  sum[:],
+			"null": {0xde, 0xad, 0xbe, 0xef},
+		},
+	}
+
+	got := marshalProviderHashes(p)
+	want := map[string]string{
+		"aws":  hex.EncodeToString(sum[:]),
+		"null": "deadbeef",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if got := marshalProviderHashes(&plans.Plan{}); got != nil {
+		t.Errorf("wrong result with no hashes\ngot:  %#v\nwant: nil", got)
+	}
+}
@@ -0,0 +1,65 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// marshalReplacePaths returns the given paths of the attributes that caused
+// a change to be planned as a replacement, each as a sequence of steps as
+// for attributeChange, or nil if there are none. The paths are sorted by
+// their json encodings so that the result is deterministic.
+//
+// A set element has no key that could identify it, so a path into a set
+// ends at the set itself.
+func marshalReplacePaths(paths cty.PathSet) [][]interface{} {
+	type replacePath struct {
+		steps []interface{}
+		key   string
+	}
+	var rps []replacePath
+	seen := map[string]bool{}
+	for _, path := range paths.List() {
+		steps := []interface{}{}
+	Steps:
+		for _, step := range path {
+			switch step := step.(type) {
+			case cty.GetAttrStep:
+				steps = append(steps, step.Name)
+			case cty.IndexStep:
+				switch step.Key.Type() {
+				case cty.String:
+					steps = append(steps, step.Key.AsString())
+				case cty.Number:
+					i, _ := step.Key.AsBigFloat().Int64()
+					steps = append(steps, i)
+				default:
+					break Steps
+				}
+			}
+		}
+
+		// Paths to several elements of the same set are the same once
+		// they end at the set.
+		src, _ := json.Marshal(steps)
+		if seen[string(src)] {
+			continue
+		}
+		seen[string(src)] = true
+		rps = append(rps, replacePath{steps, string(src)})
+	}
+	if len(rps) == 0 {
+		return nil
+	}
+
+	sort.Slice(rps, func(i, j int) bool {
+		return rps[i].key < rps[j].key
+	})
+	ret := make([][]interface{}, len(rps))
+	for i, rp := range rps {
+		ret[i] = rp.steps
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+package jsonplan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestMarshalReplacePaths(t *testing.T) {
+	tests := map[string]struct {
+		paths cty.PathSet
+		want  [][]interface{}
+	}{
+		"none": {
+			cty.NewPathSet(),
+			nil,
+		},
+		"attributes and indexes": {
+			cty.NewPathSet(
+				cty.Path{}.GetAttr("woozles"),
+				cty.Path{}.GetAttr("ebs_block_device").Index(cty.NumberIntVal(0)).GetAttr("volume_type"),
+				cty.Path{}.GetAttr("tags").Index(cty.StringVal("Name")),
+			),
+			[][]interface{}{
+				{"ebs_block_device", int64(0), "volume_type"},
+				{"tags", "Name"},
+				{"woozles"},
+			},
+		},
+		"set elements": {
+			cty.NewPathSet(
+				cty.Path{}.GetAttr("rule").Index(cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(80)})).GetAttr("port"),
+				cty.Path{}.GetAttr("rule").Index(cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(443)})).GetAttr("port"),
+			),
+			[][]interface{}{
+				{"rule"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := marshalReplacePaths(test.paths)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_replacePaths(t *testing.T) {
+	before := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("foo"),
+		"woozles": cty.StringVal("before"),
+	})
+	after := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.UnknownVal(cty.String),
+		"woozles": cty.StringVal("after"),
+	})
+
+	for action, want := range map[plans.Action][][]interface{}{
+		plans.DeleteThenCreate: {{"woozles"}},
+		plans.CreateThenDelete: {{"woozles"}},
+		plans.Update:           nil,
+	} {
+		t.Run(action.String(), func(t *testing.T) {
+			rc := testChange(t, action, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed, before, after)
+			rc.RequiredReplace = cty.NewPathSet(cty.Path{}.GetAttr("woozles"))
+			p := &plans.Plan{
+				Changes: &plans.Changes{
+					Resources: []*plans.ResourceInstanceChangeSrc{rc},
+				},
+			}
+
+			output, err := newPlan(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := output.ResourceChanges[0].Change.ReplacePaths; !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong replace_paths\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package jsonplan
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// resource is the representation of a resource in the json plan
+type resource struct {
+	// Address is the absolute resource address
+	Address string `json:"address,omitempty"`
+
+	// ModuleAddress is the module portion of the above address, as for
+	// resourceChange. Omitted if the instance is in the root module.
+	ModuleAddress string `json:"module_address,omitempty"`
+
+	// Mode is ManagedResourceMode or DataResourceMode.
+	Mode string `json:"mode,omitempty"`
+
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Index is the instance key: a number for a resource using `count`, or
+	// a string for one using `for_each`. It is omitted for a resource not
+	// using `count` or `for_each`.
+	Index addrs.InstanceKey `json:"index,omitempty"`
+
+	// ProviderName allows the property "type" to be interpreted unambiguously
+	// in the unusual situation where a provider offers a resource type whose
+	// name does not start with its own name, such as the "googlebeta"
+	// provider offering "google_compute_instance".
+	ProviderName string `json:"provider_name,omitempty"`
+
+	// ProviderConfigKey is the absolute address of the provider
+	// configuration that manages the resource, such as "provider.aws" for
+	// the default configuration of a provider in the root module, or
+	// "module.db.provider.aws.us_east_1" for one with an alias in a child
+	// module, so that resources managed by different configurations of the
+	// same provider can be told apart.
+	ProviderConfigKey string `json:"provider_config_key,omitempty"`
+
+	// SchemaVersion indicates which version of the resource type schema the
+	// "values" property conforms to.
+	SchemaVersion uint64 `json:"schema_version"`
+
+	// AttributeValues is the JSON representation of the attribute values of
+	// the resource, whose structure depends on the resource type schema. Any
+	// unknown values are omitted or set to null, so Unknown must be used to
+	// distinguish them from absent values.
+	AttributeValues attributeValues `json:"values,omitempty"`
+
+	// Unknown mirrors the structure of AttributeValues, with the value true
+	// in place of each value that will not be known until after apply.
+	Unknown json.RawMessage `json:"unknown,omitempty"`
+
+	// DependsOn contains the absolute addresses of the resources, modules and
+	// provider configurations that this resource depends on, sorted
+	// lexically.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// resourceChange is a description of an individual change action that
+// Terraform plans to use to move from the prior state to a new state
+// matching the configuration.
+type resourceChange struct {
+	// Address is the absolute resource address
+	Address string `json:"address,omitempty"`
+
+	// ModuleAddress is the module portion of the above address. Omitted if
+	// the instance is in the root module.
+	ModuleAddress string `json:"module_address,omitempty"`
+
+	// Mode is ManagedResourceMode or DataResourceMode.
+	Mode string `json:"mode,omitempty"`
+
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Index is a number for a resource using `count` and a string for one
+	// using `for_each`, as for resource. It is omitted for a resource using
+	// neither.
+	Index addrs.InstanceKey `json:"index,omitempty"`
+
+	ProviderName string `json:"provider_name,omitempty"`
+
+	// ProviderConfigKey is the absolute address of the provider
+	// configuration that manages the resource, as for resource.
+	ProviderConfigKey string `json:"provider_config_key,omitempty"`
+
+	// "deposed", if set, indicates that this action applies to a "deposed"
+	// object of the given instance rather than to its "current" object.
+	// Omitted for changes to the current object.
+	Deposed string `json:"deposed,omitempty"`
+
+	// SchemaVersion indicates which version of the resource type schema the
+	// values in Change conform to. Omitted for version zero.
+	SchemaVersion uint64 `json:"schema_version,omitempty"`
+
+	// Dependencies contains the absolute addresses of the resources, modules
+	// and provider configurations that this object depends on, sorted
+	// lexically.
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// ConfigSource is the position of the resource block in the
+	// configuration the plan was created from. Omitted if the resource is
+	// no longer in the configuration, or the configuration isn't available.
+	ConfigSource *configSource `json:"config_source,omitempty"`
+
+	// Change describes the change that will be made to this object
+	Change change `json:"change"`
+
+	// ActionReason is a keyword representing some optional extra context
+	// for why the action was planned, omitted if there is no such context:
+	//   - "tainted": the prior object is tainted, so it is being replaced.
+	//   - "cannot_update": the change includes attributes that the provider
+	//     cannot update in-place, so the object is being replaced.
+	//   - "delete_because_no_resource_config": the object is being deleted
+	//     because its resource is no longer in the configuration.
+	// The value "replace_by_request" is reserved for a replacement that was
+	// requested explicitly, which this version of Terraform never plans.
+	ActionReason string `json:"action_reason,omitempty"`
+
+	// SuppressedChanges lists the paths, as for change.ReplacePaths, of the
+	// arguments that the configuration sets to values other than those
+	// planned, whose changes were ignored because of the resource's
+	// lifecycle ignore_changes. It explains a "no-op" action for a resource
+	// whose configuration differs from its object. It is omitted if no such
+	// arguments are found, which may also be because their values can't be
+	// determined from the plan, as described for marshalSuppressedChanges.
+	SuppressedChanges [][]interface{} `json:"suppressed_changes,omitempty"`
+}
+
+// QualifiedType returns the given resource type qualified by the name of the
+// provider offering it, as "provider_name/type", such as "aws/aws_instance".
+// A resource type's name usually starts with its provider's name, but not
+// always, as with the "googlebeta" provider offering
+// "google_compute_instance", so this is the one way to refer to a resource
+// type unambiguously. A consumer of the json format should join the
+// "provider_name" and "type" properties in the same way.
+func QualifiedType(providerName, typeName string) string {
+	return providerName + "/" + typeName
+}
+
+// QualifiedType returns the qualified type of the resource, as for the
+// QualifiedType function.
+func (r resource) QualifiedType() string {
+	return QualifiedType(r.ProviderName, r.Type)
+}
+
+// QualifiedType returns the qualified type of the changed resource, as for
+// the QualifiedType function.
+func (r resourceChange) QualifiedType() string {
+	return QualifiedType(r.ProviderName, r.Type)
+}
+
+// configSource is the position of the start of a block in the configuration.
+type configSource struct {
+	// Filename is the path of the file containing the block, as recorded in
+	// the configuration snapshot, which is relative to the working directory
+	// the plan was created in.
+	Filename string `json:"filename"`
+
+	// Line and Column are both counted from one, with Column counting
+	// characters rather than bytes.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,188 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestResourceChange_jsonKeys(t *testing.T) {
+	rc := resourceChange{
+		Address:       "module.child.test_thing.foo[0]",
+		ModuleAddress: "module.child",
+		Mode:          "managed",
+		Type:          "test_thing",
+		Name:          "foo",
+		Index:         addrs.IntKey(0),
+		ProviderName:  "test",
+		Change: change{
+			Actions: []string{"create"},
+			After:   json.RawMessage(`{"woozles":"confuzles"}`),
+		},
+	}
+
+	src, err := json.Marshal(rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"address", "module_address", "mode", "type", "name", "index", "provider_name", "change"} {
+		if _, ok := got[k]; !ok {
+			t.Errorf("missing key %q in %s", k, src)
+		}
+	}
+	for k := range got {
+		switch k {
+		case "Mode", "Type", "Name", "Index", "Change":
+			t.Errorf("unexpected Go field name %q in %s", k, src)
+		}
+	}
+}
+
+func TestResourceIndex(t *testing.T) {
+	tests := map[string]struct {
+		key  addrs.InstanceKey
+		want string
+	}{
+		"no key":      {addrs.NoKey, ""},
+		"count":       {addrs.IntKey(1), `1`},
+		"for_each":    {addrs.StringKey("a"), `"a"`},
+		"numeric key": {addrs.StringKey("1"), `"1"`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for typeName, v := range map[string]interface{}{
+				"resource":       resource{Index: test.key},
+				"resourceChange": resourceChange{Index: test.key},
+			} {
+				src, err := json.Marshal(v)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				var got map[string]json.RawMessage
+				if err := json.Unmarshal(src, &got); err != nil {
+					t.Fatal(err)
+				}
+				if index, ok := got["index"]; string(index) != test.want || ok != (test.want != "") {
+					t.Errorf("wrong index for %s\ngot:  %s\nwant: %s", typeName, src, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshal_providerConfigKey(t *testing.T) {
+	child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	tests := map[string]struct {
+		provider addrs.AbsProviderConfig
+		want     string
+	}{
+		"default": {
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			"provider.test",
+		},
+		"alias": {
+			addrs.ProviderConfig{Type: "test", Alias: "east"}.Absolute(addrs.RootModuleInstance),
+			"provider.test.east",
+		},
+		"child module alias": {
+			addrs.ProviderConfig{Type: "test", Alias: "east"}.Absolute(child),
+			"module.child.provider.test.east",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc := testChange(t, plans.Create, addrs.RootModuleInstance, "foo", addrs.NoKey, states.NotDeposed,
+				cty.NullVal(testThingType),
+				cty.ObjectVal(map[string]cty.Value{
+					"id":      cty.UnknownVal(cty.String),
+					"woozles": cty.StringVal("confuzles"),
+				}),
+			)
+			rc.ProviderAddr = test.provider
+			p := &plans.Plan{
+				Changes: &plans.Changes{
+					Resources: []*plans.ResourceInstanceChangeSrc{rc},
+				},
+			}
+
+			output, err := newPlan(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := output.ResourceChanges[0].ProviderConfigKey; got != test.want {
+				t.Errorf("wrong resource change provider_config_key %q; want %q", got, test.want)
+			}
+			if got := output.PlannedValues.RootModule.Resources[0].ProviderConfigKey; got != test.want {
+				t.Errorf("wrong planned resource provider_config_key %q; want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQualifiedType(t *testing.T) {
+	tests := map[string]struct {
+		providerName, typeName string
+		want                   string
+	}{
+		"matching provider": {
+			"aws", "aws_instance",
+			"aws/aws_instance",
+		},
+		// The googlebeta provider offers the same resource types as the
+		// google provider, so the type alone doesn't say which it is.
+		"googlebeta": {
+			"googlebeta", "google_compute_instance",
+			"googlebeta/google_compute_instance",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := QualifiedType(test.providerName, test.typeName); got != test.want {
+				t.Errorf("wrong result %q; want %q", got, test.want)
+			}
+
+			r := resource{ProviderName: test.providerName, Type: test.typeName}
+			if got := r.QualifiedType(); got != test.want {
+				t.Errorf("wrong result %q for resource; want %q", got, test.want)
+			}
+			rc := resourceChange{ProviderName: test.providerName, Type: test.typeName}
+			if got := rc.QualifiedType(); got != test.want {
+				t.Errorf("wrong result %q for resource change; want %q", got, test.want)
+			}
+		})
+	}
+
+	// The google and googlebeta types of the same name are told apart.
+	google := resource{ProviderName: "google", Type: "google_compute_instance"}
+	beta := resource{ProviderName: "googlebeta", Type: "google_compute_instance"}
+	if google.QualifiedType() == beta.QualifiedType() {
+		t.Errorf("google and googlebeta resources both have type %q", google.QualifiedType())
+	}
+}
+
+func TestResourceSchema_missing(t *testing.T) {
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "google_compute_instance",
+		Name: "foo",
+	}
+	_, err := resourceSchema(testSchemas(), "googlebeta", addr)
+	want := "no schema found for google_compute_instance.foo (resource type googlebeta/google_compute_instance)"
+	if err == nil || err.Error() != want {
+		t.Errorf("wrong error %v; want %q", err, want)
+	}
+}
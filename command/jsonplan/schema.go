@@ -0,0 +1,269 @@
+package jsonplan
+
+// Schema is a JSON Schema document describing the json format of a plan as
+// produced by Marshal, for the current FormatVersion. It must be updated
+// along with the format, and with FormatVersion whenever that changes.
+//
+// Properties may be added to the format without a change of FormatVersion,
+// so the schema allows properties it doesn't describe. The prior_state,
+// planned_state and configuration properties are described only in outline,
+// since they are in the formats of the jsonstate and jsonconfig packages.
+const Schema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Terraform plan",
+  "description": "The JSON representation of a Terraform plan, as produced by \"terraform show -json\".",
+  "type": "object",
+  "required": ["format_version"],
+  "properties": {
+    "format_version": {
+      "description": "The version of the format, which changes whenever the format changes in a way that needs changes to a consumer.",
+      "type": "string",
+      "const": "0.2"
+    },
+    "planned_values": {
+      "$ref": "#/definitions/values"
+    },
+    "resource_changes": {
+      "description": "The changes to each resource instance object, sorted by address.",
+      "type": "array",
+      "items": {"$ref": "#/definitions/resource_change"}
+    },
+    "output_changes": {
+      "description": "The changes to each root module output value, keyed by output name.",
+      "type": "object",
+      "additionalProperties": {"$ref": "#/definitions/output_change"}
+    },
+    "variables": {
+      "description": "The values of the root module input variables that the plan was created with, keyed by variable name.",
+      "type": "object",
+      "additionalProperties": {"$ref": "#/definitions/variable"}
+    },
+    "prior_state": {
+      "description": "The prior state that the plan was created against.",
+      "$ref": "#/definitions/state"
+    },
+    "planned_state": {
+      "description": "The state expected to result from applying the plan.",
+      "$ref": "#/definitions/state"
+    },
+    "configuration": {
+      "description": "The configuration that the plan was created from, included only if requested.",
+      "type": "object",
+      "required": ["root_module"],
+      "properties": {
+        "provider_config": {"type": "object"},
+        "root_module": {"type": "object"}
+      }
+    },
+    "provider_versions": {
+      "description": "The versions of the providers that the plan was created with, keyed by provider name.",
+      "type": "object",
+      "additionalProperties": {"type": "string"}
+    },
+    "provider_hashes": {
+      "description": "The hex SHA-256 hashes of the provider executables that the plan was created with, as recorded from the plugin lock, keyed by provider name.",
+      "type": "object",
+      "additionalProperties": {"type": "string"}
+    },
+    "timestamp": {
+      "description": "The time at which the plan was created, in UTC.",
+      "type": "string",
+      "format": "date-time"
+    },
+    "plan_checksum": {
+      "description": "The hex SHA-256 checksum of the planned changes as recorded in the plan file.",
+      "type": "string",
+      "pattern": "^[0-9a-f]{64}$"
+    },
+    "applyable": {
+      "description": "Whether applying the plan would change anything, which is the case if any resource or output change has an action other than \"no-op\".",
+      "type": "boolean"
+    },
+    "errored": {
+      "description": "Whether planning failed, in which case the plan is incomplete and must not be applied.",
+      "type": "boolean"
+    },
+    "applyable_partial": {
+      "description": "Whether the plan was created with -target, so that applying it makes only the changes to the targeted objects and their dependencies.",
+      "type": "boolean"
+    },
+    "targets": {
+      "description": "The addresses given with -target when the plan was created, sorted, included only if there were any.",
+      "type": "array",
+      "items": {"type": "string"}
+    }
+  },
+  "definitions": {
+    "values": {
+      "type": "object",
+      "properties": {
+        "root_module": {"$ref": "#/definitions/module"},
+        "planned_outputs": {
+          "type": "object",
+          "additionalProperties": {"$ref": "#/definitions/output"}
+        }
+      }
+    },
+    "module": {
+      "type": "object",
+      "properties": {
+        "address": {
+          "description": "The absolute module address, omitted for the root module.",
+          "type": "string"
+        },
+        "resources": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/resource"}
+        },
+        "child_modules": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/module"}
+        }
+      }
+    },
+    "resource": {
+      "type": "object",
+      "required": ["address", "mode", "type", "name", "provider_name", "schema_version"],
+      "properties": {
+        "address": {"type": "string"},
+        "module_address": {
+          "description": "The module portion of the address, omitted for the root module.",
+          "type": "string"
+        },
+        "mode": {"enum": ["managed", "data"]},
+        "type": {"type": "string"},
+        "name": {"type": "string"},
+        "index": {
+          "description": "A number for a resource using count, or a string for one using for_each.",
+          "type": ["integer", "string"]
+        },
+        "provider_name": {"type": "string"},
+        "provider_config_key": {
+          "description": "The absolute address of the provider configuration that manages the resource, such as \"provider.aws.us_east_1\".",
+          "type": "string"
+        },
+        "schema_version": {"type": "integer", "minimum": 0},
+        "values": {"type": "object"},
+        "unknown": {"type": "object"},
+        "depends_on": {
+          "type": "array",
+          "items": {"type": "string"}
+        }
+      }
+    },
+    "output": {
+      "type": "object",
+      "required": ["sensitive"],
+      "properties": {
+        "sensitive": {"type": "boolean"},
+        "value": {}
+      }
+    },
+    "change": {
+      "type": "object",
+      "required": ["actions"],
+      "properties": {
+        "actions": {
+          "type": "array",
+          "items": {"enum": ["no-op", "create", "read", "update", "delete"]}
+        },
+        "before": {},
+        "after": {},
+        "before_sensitive": {},
+        "after_sensitive": {},
+        "after_unknown": {},
+        "attribute_changes": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/attribute_change"}
+        },
+        "replace_paths": {
+          "description": "The paths of the attributes that caused a replacement.",
+          "type": "array",
+          "items": {"$ref": "#/definitions/path"}
+        }
+      }
+    },
+    "attribute_change": {
+      "type": "object",
+      "required": ["path", "action"],
+      "properties": {
+        "path": {"$ref": "#/definitions/path"},
+        "action": {"enum": ["add", "remove", "update"]},
+        "unknown": {"type": "boolean"}
+      }
+    },
+    "path": {
+      "description": "A path to a value within an object: a string for each attribute name or map key, and a number for each list or tuple index.",
+      "type": "array",
+      "items": {"type": ["string", "integer"]}
+    },
+    "resource_change": {
+      "type": "object",
+      "required": ["address", "mode", "type", "name", "provider_name", "change"],
+      "properties": {
+        "address": {"type": "string"},
+        "module_address": {"type": "string"},
+        "mode": {"enum": ["managed", "data"]},
+        "type": {"type": "string"},
+        "name": {"type": "string"},
+        "index": {"type": ["integer", "string"]},
+        "provider_name": {"type": "string"},
+        "provider_config_key": {"type": "string"},
+        "deposed": {"type": "string"},
+        "schema_version": {"type": "integer", "minimum": 0},
+        "dependencies": {
+          "type": "array",
+          "items": {"type": "string"}
+        },
+        "config_source": {"$ref": "#/definitions/config_source"},
+        "change": {"$ref": "#/definitions/change"},
+        "action_reason": {
+          "enum": ["tainted", "cannot_update", "delete_because_no_resource_config", "replace_by_request"]
+        },
+        "suppressed_changes": {
+          "description": "The paths of the arguments whose configured values differ from the planned ones, but whose changes were ignored because of ignore_changes.",
+          "type": "array",
+          "items": {"$ref": "#/definitions/path"}
+        }
+      }
+    },
+    "config_source": {
+      "description": "The position of the start of a block in the configuration, with the line and column counted from one.",
+      "type": "object",
+      "required": ["filename", "line", "column"],
+      "properties": {
+        "filename": {"type": "string"},
+        "line": {"type": "integer", "minimum": 1},
+        "column": {"type": "integer", "minimum": 1}
+      }
+    },
+    "output_change": {
+      "allOf": [
+        {"$ref": "#/definitions/change"},
+        {
+          "type": "object",
+          "required": ["sensitive"],
+          "properties": {
+            "sensitive": {"type": "boolean"}
+          }
+        }
+      ]
+    },
+    "variable": {
+      "type": "object",
+      "properties": {
+        "value": {},
+        "sensitive": {"type": "boolean"}
+      }
+    },
+    "state": {
+      "type": "object",
+      "required": ["format_version"],
+      "properties": {
+        "format_version": {"type": "string"},
+        "values": {"type": "object"}
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,253 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(Schema), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %s", err)
+	}
+
+	got := schema["properties"].(map[string]interface{})["format_version"].(map[string]interface{})["const"]
+	if got != FormatVersion {
+		t.Errorf("schema is for format version %v; want %s", got, FormatVersion)
+	}
+
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.StringKey("a"))
+	p := &plans.Plan{
+		VariableValues: map[string]plans.DynamicValue{
+			"name": testVariableValue(t, cty.StringVal("web")),
+		},
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				testChange(t, plans.Create, addrs.RootModuleInstance, "foo", addrs.IntKey(0), states.NotDeposed,
+					cty.NullVal(testThingType),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.UnknownVal(cty.String),
+						"woozles": cty.StringVal("confuzles"),
+					}),
+				),
+				testChange(t, plans.DeleteThenCreate, childAddr, "bar", addrs.StringKey("x"), states.NotDeposed,
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("bar"),
+						"woozles": cty.StringVal("before"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.UnknownVal(cty.String),
+						"woozles": cty.StringVal("after"),
+					}),
+				),
+				testChange(t, plans.Delete, childAddr, "baz", addrs.NoKey, states.DeposedKey("deadbeef"),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":      cty.StringVal("old"),
+						"woozles": cty.NullVal(cty.String),
+					}),
+					cty.NullVal(testThingType),
+				),
+			},
+			Outputs: []*plans.OutputChangeSrc{
+				testOutputChange(t, addrs.RootModuleInstance, "plain", plans.Create, false,
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.StringVal("hello"),
+				),
+				testOutputChange(t, addrs.RootModuleInstance, "secret", plans.Update, true,
+					cty.StringVal("old"),
+					cty.StringVal("new"),
+				),
+			},
+		},
+		ProviderSHA256s: map[string][]byte{
+			"test": {0xde, 0xad, 0xbe, 0xef},
+		},
+		Timestamp: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	p.Changes.Resources[1].RequiredReplace = cty.NewPathSet(cty.Path{}.GetAttr("woozles"))
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "bar",
+			}.Instance(addrs.StringKey("x")).Absolute(childAddr),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"bar","woozles":"before"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(childAddr),
+		)
+	})
+
+	src, err := Marshal(nil, p, prior, prior, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range validateSchema(schema, schema, doc, "") {
+		t.Errorf("plan does not conform to the schema: %s", err)
+	}
+
+	// The validator is only any use if it finds errors too.
+	invalid := map[string]interface{}{
+		"format_version": FormatVersion,
+		"resource_changes": []interface{}{
+			map[string]interface{}{"address": "test_thing.foo", "change": map[string]interface{}{"actions": []interface{}{"replace"}}},
+		},
+		"plan_checksum": "not hex",
+	}
+	if errs := validateSchema(schema, schema, invalid, ""); len(errs) != 6 {
+		t.Errorf("wrong number of errors for an invalid plan; want 6\n%s", strings.Join(errs, "\n"))
+	}
+}
+
+// validateSchema returns a description of each way in which the given
+// decoded JSON value fails to conform to the given JSON Schema, whose $ref
+// properties are resolved within root. Only the parts of JSON Schema that
+// Schema uses are supported.
+func validateSchema(root, schema map[string]interface{}, v interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		def, ok := root["definitions"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: schema has no definition for %s", path, ref)}
+		}
+		return validateSchema(root, def, v, path)
+	}
+
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, sub := range schemaList(schema["allOf"]) {
+		errs = append(errs, validateSchema(root, sub.(map[string]interface{}), v, path)...)
+	}
+
+	if ty, ok := schema["type"]; ok {
+		var types []string
+		switch ty := ty.(type) {
+		case string:
+			types = []string{ty}
+		case []interface{}:
+			for _, t := range ty {
+				types = append(types, t.(string))
+			}
+		}
+		matched := false
+		for _, t := range types {
+			if schemaTypeOf(v, t) {
+				matched = true
+			}
+		}
+		if !matched {
+			fail("got %#v; want %s", v, strings.Join(types, " or "))
+			return errs
+		}
+	}
+	if want, ok := schema["const"]; ok && !reflect.DeepEqual(v, want) {
+		fail("got %#v; want %#v", v, want)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, want := range enum {
+			if reflect.DeepEqual(v, want) {
+				found = true
+			}
+		}
+		if !found {
+			fail("got %#v; want one of %v", v, enum)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, ok := v.(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			fail("%q does not match %s", s, pattern)
+		}
+	}
+	if min, ok := schema["minimum"].(float64); ok {
+		if n, ok := v.(float64); ok && n < min {
+			fail("%v is less than %v", n, min)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range schemaList(schema["required"]) {
+			if _, ok := v[name.(string)]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := props[name].(map[string]interface{}); ok {
+				errs = append(errs, validateSchema(root, prop, v[name], path+"/"+name)...)
+			} else if additional != nil {
+				errs = append(errs, validateSchema(root, additional, v[name], path+"/"+name)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, elem := range v {
+				errs = append(errs, validateSchema(root, items, elem, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func schemaList(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+// schemaTypeOf returns true if the given decoded JSON value is of the given
+// JSON Schema type.
+func schemaTypeOf(v interface{}, ty string) bool {
+	switch ty {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return v == nil
+	default:
+		return false
+	}
+}
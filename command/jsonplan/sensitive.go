@@ -0,0 +1,78 @@
+package jsonplan
+
+import (
+	"encoding/json"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+// marshalSensitive returns the json representation of which parts of the
+// given object value are sensitive according to the given schema, or nil if
+// the value is null.
+//
+// The result mirrors the structure of the value: an object with the key
+// true for each sensitive attribute, and the same structure recursively for
+// each nested block, as a single object, an array of objects or an object of
+// objects depending on the block's nesting mode. Attributes that are not
+// sensitive are omitted. A sensitive attribute is marked true as a whole,
+// even if its value is a collection.
+func marshalSensitive(val cty.Value, schema *configschema.Block) (json.RawMessage, error) {
+	if val == cty.NilVal || val.IsNull() {
+		return nil, nil
+	}
+	return json.Marshal(sensitiveObject(val, schema))
+}
+
+// stripSensitive returns the given object value with its sensitive
+// attributes removed according to the given schema. Unlike
+// configschema.Block.StripSensitive, it accepts cty.NilVal, which is
+// returned unchanged.
+func stripSensitive(val cty.Value, schema *configschema.Block) cty.Value {
+	if val == cty.NilVal {
+		return val
+	}
+	return schema.StripSensitive(val)
+}
+
+func sensitiveObject(val cty.Value, schema *configschema.Block) map[string]interface{} {
+	ret := map[string]interface{}{}
+	if !val.IsKnown() || val.IsNull() {
+		return ret
+	}
+
+	for name, attrS := range schema.Attributes {
+		if attrS.Sensitive {
+			ret[name] = true
+		}
+	}
+
+	for name, blockS := range schema.BlockTypes {
+		blockV := val.GetAttr(name)
+		if !blockV.IsKnown() || blockV.IsNull() {
+			continue
+		}
+
+		switch blockS.Nesting {
+		case configschema.NestingSingle:
+			ret[name] = sensitiveObject(blockV, &blockS.Block)
+		case configschema.NestingList, configschema.NestingSet:
+			elems := []interface{}{}
+			for it := blockV.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				elems = append(elems, sensitiveObject(v, &blockS.Block))
+			}
+			ret[name] = elems
+		case configschema.NestingMap:
+			elems := map[string]interface{}{}
+			for it := blockV.ElementIterator(); it.Next(); {
+				k, v := it.Element()
+				elems[k.AsString()] = sensitiveObject(v, &blockS.Block)
+			}
+			ret[name] = elems
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,292 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestMarshalSensitive(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":       {Type: cty.String, Computed: true},
+			"password": {Type: cty.String, Optional: true, Sensitive: true},
+			"tags":     {Type: cty.Map(cty.String), Optional: true, Sensitive: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"credential": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"user":  {Type: cty.String, Optional: true},
+						"token": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+			},
+			"settings": {
+				Nesting: configschema.NestingSingle,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"key": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+			},
+			"named": {
+				Nesting: configschema.NestingMap,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"secret": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+			},
+		},
+	}
+	credentialTy := cty.Object(map[string]cty.Type{
+		"user":  cty.String,
+		"token": cty.String,
+	})
+	settingsTy := cty.Object(map[string]cty.Type{
+		"key": cty.String,
+	})
+	namedTy := cty.Object(map[string]cty.Type{
+		"secret": cty.String,
+	})
+
+	tests := map[string]struct {
+		val  cty.Value
+		want string
+	}{
+		"null": {
+			cty.NullVal(schema.ImpliedType()),
+			``,
+		},
+		"nested blocks": {
+			cty.ObjectVal(map[string]cty.Value{
+				"id":       cty.StringVal("foo"),
+				"password": cty.StringVal("hunter2"),
+				"tags":     cty.MapVal(map[string]cty.Value{"a": cty.StringVal("b")}),
+				"credential": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"user":  cty.StringVal("a"),
+						"token": cty.StringVal("secret a"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"user":  cty.StringVal("b"),
+						"token": cty.StringVal("secret b"),
+					}),
+				}),
+				"settings": cty.ObjectVal(map[string]cty.Value{
+					"key": cty.StringVal("secret"),
+				}),
+				"named": cty.MapVal(map[string]cty.Value{
+					"x": cty.ObjectVal(map[string]cty.Value{
+						"secret": cty.StringVal("secret x"),
+					}),
+				}),
+			}),
+			`{
+  "password": true,
+  "tags": true,
+  "credential": [{"token": true}, {"token": true}],
+  "settings": {"key": true},
+  "named": {"x": {"secret": true}}
+}`,
+		},
+		"absent and unknown blocks": {
+			cty.ObjectVal(map[string]cty.Value{
+				"id":         cty.UnknownVal(cty.String),
+				"password":   cty.NullVal(cty.String),
+				"tags":       cty.NullVal(cty.Map(cty.String)),
+				"credential": cty.UnknownVal(cty.List(credentialTy)),
+				"settings":   cty.NullVal(settingsTy),
+				"named":      cty.MapValEmpty(namedTy),
+			}),
+			`{
+  "password": true,
+  "tags": true,
+  "named": {}
+}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := marshalSensitive(test.val, schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if test.want == "" {
+				if got != nil {
+					t.Fatalf("wrong result %s; want nil", got)
+				}
+				return
+			}
+
+			var gotV, wantV interface{}
+			if err := json.Unmarshal(got, &gotV); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &wantV); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Errorf("wrong result\n%s", cmp.Diff(wantV, gotV))
+			}
+		})
+	}
+}
+
+func TestMarshal_noSensitive(t *testing.T) {
+	schemas := &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				ResourceTypes: map[string]*configschema.Block{
+					"test_secret": {
+						Attributes: map[string]*configschema.Attribute{
+							"name":     {Type: cty.String, Optional: true},
+							"password": {Type: cty.String, Optional: true, Sensitive: true},
+						},
+					},
+				},
+			},
+		},
+	}
+	ty := schemas.ResourceTypeConfig("test", "test_secret").ImpliedType()
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_secret",
+		Name: "foo",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	providerAddr := addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance)
+
+	rc := &plans.ResourceInstanceChange{
+		Addr:         addr,
+		ProviderAddr: providerAddr,
+		Change: plans.Change{
+			Action: plans.Update,
+			Before: cty.ObjectVal(map[string]cty.Value{
+				"name":     cty.StringVal("foo"),
+				"password": cty.StringVal("old-secret"),
+			}),
+			After: cty.ObjectVal(map[string]cty.Value{
+				"name":     cty.StringVal("foo"),
+				"password": cty.StringVal("new-secret"),
+			}),
+		},
+	}
+	rcs, err := rc.Encode(ty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{rcs},
+			Outputs: []*plans.OutputChangeSrc{
+				testOutputChange(t, addrs.RootModuleInstance, "secret", plans.Create, true,
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.StringVal("output-secret"),
+				),
+			},
+		},
+	}
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addr,
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"name":"foo","password":"old-secret"}`),
+			},
+			providerAddr,
+		)
+	})
+
+	got, err := Marshal(nil, p, prior, nil, schemas, nil, &MarshalOpts{NoSensitive: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(string(got), "-secret") || strings.Contains(string(got), "sensitive") {
+		t.Fatalf("output contains sensitive values or markers\n%s", got)
+	}
+
+	var gotV, wantV interface{}
+	if err := json.Unmarshal(got, &gotV); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "format_version": "0.2",
+  "plan_checksum": "a122d6d275ea5449527d21f5b7dcf8793acad8111b0bc9d766a99dba664c55dc",
+  "applyable": true,
+  "errored": false,
+  "applyable_partial": false,
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "test_secret.foo",
+          "mode": "managed",
+          "type": "test_secret",
+          "name": "foo",
+          "provider_name": "test",
+          "provider_config_key": "provider.test",
+          "schema_version": 0,
+          "values": {"name": "foo"},
+          "unknown": {},
+          "depends_on": ["provider.test"]
+        }
+      ]
+    }
+  },
+  "resource_changes": [
+    {
+      "address": "test_secret.foo",
+      "mode": "managed",
+      "type": "test_secret",
+      "name": "foo",
+      "provider_name": "test",
+      "provider_config_key": "provider.test",
+      "change": {
+        "actions": ["update"],
+        "before": {"name": "foo"},
+        "after": {"name": "foo"},
+        "after_unknown": {}
+      },
+      "dependencies": ["provider.test"]
+    }
+  ],
+  "prior_state": {
+    "format_version": "0.1",
+    "values": {
+      "root_module": {
+        "resources": [
+          {
+            "address": "test_secret.foo",
+            "mode": "managed",
+            "type": "test_secret",
+            "name": "foo",
+            "provider_name": "test",
+            "status": "ready",
+            "schema_version": 0,
+            "values": {"name": "foo"}
+          }
+        ]
+      }
+    }
+  }
+}`
+	if err := json.Unmarshal([]byte(want), &wantV); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotV, wantV) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(wantV, gotV))
+	}
+}
@@ -0,0 +1,161 @@
+package jsonplan
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+// marshalSuppressedChanges returns the paths, as for marshalReplacePaths,
+// of the arguments whose changes were suppressed by the ignore_changes
+// setting of the given change's resource: those whose configured values
+// differ from the planned ones. It returns nil if there are none, or if the
+// configuration isn't available.
+//
+// The plan doesn't record what was ignored, so the configured values are
+// evaluated again here. That is only possible for an expression that refers
+// to nothing but the root module input variables recorded in the plan, and
+// calls no functions, so a change to an argument set any other way is never
+// reported. The result therefore explains a change that was suppressed, but
+// its absence doesn't mean that nothing was.
+func marshalSuppressedChanges(rc *plans.ResourceInstanceChangeSrc, after cty.Value, config *configs.Config, schema *configschema.Block, vars map[string]cty.Value) [][]interface{} {
+	if config == nil || rc.Addr.Resource.Resource.Mode != addrs.ManagedResourceMode {
+		return nil
+	}
+	// ignore_changes applies only to an object that already exists and
+	// continues to.
+	switch rc.Action {
+	case plans.Create, plans.Read, plans.Delete:
+		return nil
+	}
+	if rc.DeposedKey != states.NotDeposed || after == cty.NilVal || after.IsNull() || !after.IsKnown() {
+		return nil
+	}
+
+	modCfg := config.DescendentForInstance(rc.Addr.Module)
+	if modCfg == nil {
+		return nil
+	}
+	resCfg := modCfg.Module.ResourceByAddr(rc.Addr.Resource.Resource)
+	if resCfg == nil || resCfg.Managed == nil || resCfg.Config == nil {
+		return nil
+	}
+
+	var paths []cty.Path
+	if resCfg.Managed.IgnoreAllChanges {
+		names := make([]string, 0, len(schema.Attributes))
+		for name := range schema.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			paths = append(paths, cty.Path{}.GetAttr(name))
+		}
+	} else {
+		for _, traversal := range resCfg.Managed.IgnoreChanges {
+			if path, ok := traversalPath(traversal); ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// The variables are only those of the root module, so a child module's
+	// expressions are evaluated only if they are constant.
+	ctx := &hcl.EvalContext{}
+	if rc.Addr.Module.IsRoot() {
+		ctx.Variables = map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+		}
+	}
+
+	content, _, _ := resCfg.Config.PartialContent(hcldec.ImpliedSchema(schema.DecoderSpec()))
+	if content == nil {
+		return nil
+	}
+
+	suppressed := cty.NewPathSet()
+	for _, path := range paths {
+		name := path[0].(cty.GetAttrStep).Name
+		attr, ok := content.Attributes[name]
+		attrS := schema.Attributes[name]
+		if !ok || attrS == nil {
+			continue
+		}
+
+		configV, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() {
+			continue
+		}
+		configV, err := convert.Convert(configV, attrS.Type)
+		if err != nil {
+			continue
+		}
+		configV, err = path[1:].Apply(configV)
+		if err != nil || !configV.IsWhollyKnown() {
+			continue
+		}
+
+		// A configured element that isn't in the planned value at all, such
+		// as a new map key, is a change too.
+		afterV, err := path.Apply(after)
+		if err != nil {
+			afterV = cty.NullVal(configV.Type())
+		}
+		if !afterV.IsWhollyKnown() {
+			continue
+		}
+
+		if eq := configV.Equals(afterV); eq.IsKnown() && eq.False() {
+			suppressed.Add(path)
+		}
+	}
+	return marshalReplacePaths(suppressed)
+}
+
+// traversalPath returns the path that the given ignore_changes traversal
+// refers to, and false if it includes a step that a path can't represent.
+func traversalPath(traversal hcl.Traversal) (cty.Path, bool) {
+	path := make(cty.Path, 0, len(traversal))
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			path = path.GetAttr(step.Name)
+		case hcl.TraverseAttr:
+			path = path.GetAttr(step.Name)
+		case hcl.TraverseIndex:
+			path = path.Index(step.Key)
+		default:
+			return nil, false
+		}
+	}
+	if len(path) == 0 {
+		return nil, false
+	}
+	return path, true
+}
+
+// decodeVariables returns the root module input variable values recorded in
+// the given plan, leaving out any that can't be decoded.
+func decodeVariables(vars map[string]plans.DynamicValue) map[string]cty.Value {
+	ret := make(map[string]cty.Value, len(vars))
+	for name, raw := range vars {
+		val, err := raw.Decode(cty.DynamicPseudoType)
+		if err != nil {
+			continue
+		}
+		ret[name] = val
+	}
+	return ret
+}
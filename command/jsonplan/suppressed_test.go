@@ -0,0 +1,141 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestMarshal_suppressedChanges(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, diags := parser.LoadConfigDir("testdata/ignore_changes")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	config, diags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	// Every object has the old value, which ignore_changes kept for all but
+	// the last.
+	obj := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.StringVal("x"),
+		"woozles": cty.StringVal("old"),
+	})
+	var changes []*plans.ResourceInstanceChangeSrc
+	for _, name := range []string{"literal", "variable", "all", "same", "reference", "function"} {
+		changes = append(changes, testChange(t, plans.NoOp, addrs.RootModuleInstance, name, addrs.NoKey, states.NotDeposed, obj, obj))
+	}
+	changes = append(changes, testChange(t, plans.Update, addrs.RootModuleInstance, "not_ignored", addrs.NoKey, states.NotDeposed,
+		cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.StringVal("x"),
+			"woozles": cty.StringVal("older"),
+		}),
+		obj,
+	))
+	p := &plans.Plan{
+		VariableValues: map[string]plans.DynamicValue{
+			"woozles": testVariableValue(t, cty.StringVal("new")),
+		},
+		Changes: &plans.Changes{Resources: changes},
+	}
+
+	got, err := Marshal(config, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var output struct {
+		ResourceChanges []struct {
+			Address           string          `json:"address"`
+			SuppressedChanges [][]interface{} `json:"suppressed_changes"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(got, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	woozles := [][]interface{}{{"woozles"}}
+	want := map[string][][]interface{}{
+		"test_thing.all":         woozles,
+		"test_thing.function":    nil,
+		"test_thing.literal":     woozles,
+		"test_thing.not_ignored": nil,
+		"test_thing.reference":   nil,
+		"test_thing.same":        nil,
+		"test_thing.variable":    woozles,
+	}
+	gotPaths := make(map[string][][]interface{})
+	for _, rc := range output.ResourceChanges {
+		gotPaths[rc.Address] = rc.SuppressedChanges
+	}
+	if !reflect.DeepEqual(gotPaths, want) {
+		t.Errorf("wrong suppressed changes\n%s", cmp.Diff(want, gotPaths))
+	}
+
+	t.Run("without configuration", func(t *testing.T) {
+		got, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var output struct {
+			ResourceChanges []map[string]interface{} `json:"resource_changes"`
+		}
+		if err := json.Unmarshal(got, &output); err != nil {
+			t.Fatal(err)
+		}
+		for _, rc := range output.ResourceChanges {
+			if _, ok := rc["suppressed_changes"]; ok {
+				t.Errorf("%s has suppressed changes without a configuration", rc["address"])
+			}
+		}
+	})
+}
+
+func TestTraversalPath(t *testing.T) {
+	tests := map[string]struct {
+		traversal hcl.Traversal
+		want      cty.Path
+	}{
+		"attribute": {
+			hcl.Traversal{hcl.TraverseAttr{Name: "woozles"}},
+			cty.Path{}.GetAttr("woozles"),
+		},
+		"index": {
+			hcl.Traversal{
+				hcl.TraverseAttr{Name: "tags"},
+				hcl.TraverseIndex{Key: cty.StringVal("Name")},
+			},
+			cty.Path{}.GetAttr("tags").Index(cty.StringVal("Name")),
+		},
+		"splat": {
+			hcl.Traversal{
+				hcl.TraverseAttr{Name: "rule"},
+				hcl.TraverseSplat{},
+			},
+			nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := traversalPath(test.traversal)
+			if ok != (test.want != nil) {
+				t.Fatalf("wrong ok %t", ok)
+			}
+			if ok && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("wrong path %#v; want %#v", got, test.want)
+			}
+		})
+	}
+}
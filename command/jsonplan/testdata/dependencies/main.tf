@@ -0,0 +1,11 @@
+resource "test_thing" "a" {
+}
+
+resource "test_thing" "b" {
+  woozles = test_thing.a.id
+
+  depends_on = [test_thing.c]
+}
+
+resource "test_thing" "c" {
+}
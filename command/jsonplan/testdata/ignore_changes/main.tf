@@ -0,0 +1,54 @@
+variable "woozles" {
+}
+
+resource "test_thing" "literal" {
+  woozles = "new"
+
+  lifecycle {
+    ignore_changes = [woozles]
+  }
+}
+
+resource "test_thing" "variable" {
+  woozles = "${var.woozles}-suffix"
+
+  lifecycle {
+    ignore_changes = [woozles]
+  }
+}
+
+resource "test_thing" "all" {
+  woozles = "new"
+
+  lifecycle {
+    ignore_changes = all
+  }
+}
+
+resource "test_thing" "same" {
+  woozles = "old"
+
+  lifecycle {
+    ignore_changes = [woozles]
+  }
+}
+
+resource "test_thing" "reference" {
+  woozles = test_thing.literal.id
+
+  lifecycle {
+    ignore_changes = [woozles]
+  }
+}
+
+resource "test_thing" "function" {
+  woozles = upper("new")
+
+  lifecycle {
+    ignore_changes = [woozles]
+  }
+}
+
+resource "test_thing" "not_ignored" {
+  woozles = "old"
+}
@@ -0,0 +1,54 @@
+package jsonplan
+
+import (
+	"encoding/json"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// marshalUnknown returns the json representation of which parts of the given
+// value are not yet known, or nil if the value is null.
+//
+// The result mirrors the structure of the value: true in place of each
+// unknown value, an object with the same keys for each known object or map,
+// and an array with the same length for each known list, set or tuple.
+// Within an object, the keys whose values are wholly known are omitted, but
+// within an array each known element is marked false so that the positions
+// of the others are preserved. A wholly-known value is therefore an empty
+// object.
+func marshalUnknown(val cty.Value) (json.RawMessage, error) {
+	if val == cty.NilVal || val.IsNull() {
+		return nil, nil
+	}
+	return json.Marshal(unknownValue(val))
+}
+
+func unknownValue(val cty.Value) interface{} {
+	ty := val.Type()
+	switch {
+	case !val.IsKnown():
+		return true
+	case val.IsNull() || ty.IsPrimitiveType():
+		return false
+	case ty.IsObjectType() || ty.IsMapType():
+		ret := map[string]interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			if u := unknownValue(v); u != false {
+				ret[k.AsString()] = u
+			}
+		}
+		return ret
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		ret := []interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			ret = append(ret, unknownValue(v))
+		}
+		return ret
+	default:
+		// Capsule types can't be unknown in part, so there's nothing more
+		// to say about them.
+		return false
+	}
+}
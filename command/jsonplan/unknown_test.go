@@ -0,0 +1,62 @@
+package jsonplan
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMarshalUnknown(t *testing.T) {
+	tests := map[string]struct {
+		Val  cty.Value
+		Want string
+	}{
+		"null": {
+			cty.NullVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+			``,
+		},
+		"wholly unknown": {
+			cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+			`true`,
+		},
+		"known": {
+			cty.ObjectVal(map[string]cty.Value{
+				"id":   cty.StringVal("foo"),
+				"null": cty.NullVal(cty.String),
+			}),
+			`{}`,
+		},
+		"nested": {
+			cty.ObjectVal(map[string]cty.Value{
+				"id":   cty.UnknownVal(cty.String),
+				"name": cty.StringVal("foo"),
+				"tags": cty.MapVal(map[string]cty.Value{
+					"a": cty.StringVal("x"),
+					"b": cty.UnknownVal(cty.String),
+				}),
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("x"),
+					cty.UnknownVal(cty.String),
+				}),
+				"block": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"size": cty.NumberIntVal(1),
+					}),
+				}),
+			}),
+			`{"block":[{}],"id":true,"list":[false,true],"tags":{"b":true}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := marshalUnknown(test.Val)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != test.Want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got, test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// stateValues is the common representation of resolved values for both the
+// prior state (which is always complete) and the planned new state.
+type stateValues struct {
+	RootModule module `json:"root_module,omitempty"`
+
+	// PlannedOutputs are the planned values of the root module output
+	// values, keyed by output name.
+	PlannedOutputs map[string]output `json:"planned_outputs,omitempty"`
+}
+
+// module is the representation of a module in state. This can be the root
+// module or a child module.
+type module struct {
+	Resources []resource `json:"resources,omitempty"`
+
+	// Address is the absolute module address, omitted for the root module
+	Address string `json:"address,omitempty"`
+
+	// Each module object can optionally have its own nested "child_modules",
+	// recursively describing the full module tree.
+	ChildModules []module `json:"child_modules,omitempty"`
+}
+
+// attributeValues is the JSON representation of the attribute values of the
+// resource, whose structure depends on the resource type schema.
+type attributeValues map[string]interface{}
+
+func marshalAttributeValues(value cty.Value) (attributeValues, error) {
+	if value == cty.NilVal || value.IsNull() {
+		return nil, nil
+	}
+	ret := make(attributeValues)
+
+	it := value.ElementIterator()
+	for it.Next() {
+		k, v := it.Element()
+		vJSON, err := ctyjson.Marshal(v, v.Type())
+		if err != nil {
+			return nil, err
+		}
+		ret[k.AsString()] = json.RawMessage(vJSON)
+	}
+	return ret, nil
+}
+
+// marshalPlannedValues takes the resource changes from a plan and creates a
+// json representation of the state that would result from applying them.
+func (p *plan) marshalPlannedValues(changes *plans.Changes, config *configs.Config, s *states.State, schemas *terraform.Schemas) error {
+	if changes == nil {
+		// Nothing to do!
+		return nil
+	}
+
+	// Group the planned resources by the module that contains them, making
+	// sure that every ancestor of a module with resources is also present
+	// so that the resulting tree has no gaps.
+	moduleResources := map[string][]resource{}
+	moduleAddrs := map[string]addrs.ModuleInstance{
+		"": addrs.RootModuleInstance,
+	}
+
+	for _, rc := range changes.Resources {
+		// Deleted objects and deposed objects won't be present in the new
+		// state, so they are not included in the planned values.
+		if rc.Action == plans.Delete || rc.DeposedKey != states.NotDeposed {
+			continue
+		}
+		if rc.Action == plans.NoOp && p.changesOnly {
+			continue
+		}
+
+		r, err := marshalPlannedResource(rc, config, s, schemas, p.noSensitive, p.noValues)
+		if err != nil {
+			return err
+		}
+
+		modAddr := rc.Addr.Module
+		moduleResources[modAddr.String()] = append(moduleResources[modAddr.String()], r)
+		for i := 1; i <= len(modAddr); i++ {
+			moduleAddrs[modAddr[:i].String()] = modAddr[:i]
+		}
+	}
+
+	p.PlannedValues.RootModule = marshalPlannedModule(addrs.RootModuleInstance, moduleAddrs, moduleResources)
+
+	return nil
+}
+
+func marshalPlannedResource(rc *plans.ResourceInstanceChangeSrc, config *configs.Config, s *states.State, schemas *terraform.Schemas, noSensitive, noValues bool) (resource, error) {
+	addr := rc.Addr
+	providerType := rc.ProviderAddr.ProviderConfig.Type
+
+	r := resource{
+		Address:           addr.String(),
+		Mode:              marshalMode(addr.Resource.Resource.Mode),
+		Type:              addr.Resource.Resource.Type,
+		Name:              addr.Resource.Resource.Name,
+		Index:             addr.Resource.Key,
+		ProviderName:      providerType,
+		ProviderConfigKey: rc.ProviderAddr.String(),
+		DependsOn:         resourceDependencies(rc, config, s, schemas),
+	}
+	if !addr.Module.IsRoot() {
+		r.ModuleAddress = addr.Module.String()
+	}
+
+	schema, err := resourceSchema(schemas, providerType, addr.Resource.Resource)
+	if err != nil {
+		return r, err
+	}
+	if ps := schemas.ProviderSchema(providerType); ps != nil {
+		r.SchemaVersion = ps.SchemaVersionForResourceAddr(addr.Resource.Resource)
+	}
+
+	changeV, err := rc.Decode(schema.ImpliedType())
+	if err != nil {
+		return r, err
+	}
+
+	if noSensitive {
+		changeV.After = stripSensitive(changeV.After, schema)
+	}
+	if noValues {
+		changeV.After = nullValues(changeV.After)
+	}
+	if changeV.After != cty.NilVal {
+		r.AttributeValues, err = marshalAttributeValues(cty.UnknownAsNull(changeV.After))
+		if err != nil {
+			return r, err
+		}
+		r.Unknown, err = marshalUnknown(changeV.After)
+		if err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+func marshalPlannedModule(addr addrs.ModuleInstance, moduleAddrs map[string]addrs.ModuleInstance, moduleResources map[string][]resource) module {
+	var ret module
+	if !addr.IsRoot() {
+		ret.Address = addr.String()
+	}
+
+	ret.Resources = moduleResources[addr.String()]
+	sort.Slice(ret.Resources, func(i, j int) bool {
+		return ret.Resources[i].Address < ret.Resources[j].Address
+	})
+
+	var childKeys []string
+	for k, childAddr := range moduleAddrs {
+		if len(childAddr) == len(addr)+1 && childAddr[:len(addr)].Equal(addr) {
+			childKeys = append(childKeys, k)
+		}
+	}
+	sort.Strings(childKeys)
+
+	for _, k := range childKeys {
+		ret.ChildModules = append(ret.ChildModules, marshalPlannedModule(moduleAddrs[k], moduleAddrs, moduleResources))
+	}
+
+	return ret
+}
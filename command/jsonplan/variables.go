@@ -0,0 +1,53 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/plans"
+)
+
+// variable is the representation of a root module input variable value that
+// the plan was created with.
+type variable struct {
+	// Value is omitted, and Sensitive set, if the value is redacted.
+	Value     json.RawMessage `json:"value,omitempty"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// marshalVariables populates the variables from the variable values recorded
+// in the given plan.
+//
+// The configuration language has no way to declare a variable as sensitive,
+// so any variable may hold a secret. Every value is therefore redacted if
+// noSensitive is set, and none otherwise. If noValues is set, the values
+// that aren't redacted are replaced as for nullValues.
+func (p *plan) marshalVariables(vars map[string]plans.DynamicValue) error {
+	for name, raw := range vars {
+		v := variable{Sensitive: p.noSensitive}
+		if !p.noSensitive {
+			val, err := raw.Decode(cty.DynamicPseudoType)
+			if err != nil {
+				return fmt.Errorf("variable %q: %s", name, err)
+			}
+			if p.noValues {
+				val = nullValues(val)
+			}
+			if !val.IsNull() {
+				src, err := ctyjson.Marshal(val, val.Type())
+				if err != nil {
+					return fmt.Errorf("variable %q: %s", name, err)
+				}
+				v.Value = json.RawMessage(src)
+			}
+		}
+		if p.Variables == nil {
+			p.Variables = make(map[string]variable)
+		}
+		p.Variables[name] = v
+	}
+	return nil
+}
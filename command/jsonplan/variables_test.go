@@ -0,0 +1,80 @@
+package jsonplan
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/plans"
+)
+
+func TestMarshal_variables(t *testing.T) {
+	p := &plans.Plan{
+		VariableValues: map[string]plans.DynamicValue{
+			"name": testVariableValue(t, cty.StringVal("web")),
+			"tags": testVariableValue(t, cty.MapVal(map[string]cty.Value{
+				"env": cty.StringVal("prod"),
+			})),
+			"unset": testVariableValue(t, cty.NullVal(cty.String)),
+		},
+		Changes: plans.NewChanges(),
+	}
+
+	tests := map[string]struct {
+		noSensitive bool
+		want        string
+	}{
+		"all values": {
+			false,
+			`{
+  "name": {"value": "web"},
+  "tags": {"value": {"env": "prod"}},
+  "unset": {}
+}`,
+		},
+		"no sensitive": {
+			true,
+			`{
+  "name": {"sensitive": true},
+  "tags": {"sensitive": true},
+  "unset": {"sensitive": true}
+}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			src, err := Marshal(nil, p, nil, nil, testSchemas(), nil, &MarshalOpts{NoSensitive: test.noSensitive})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var got struct {
+				Variables interface{} `json:"variables"`
+			}
+			if err := json.Unmarshal(src, &got); err != nil {
+				t.Fatal(err)
+			}
+			var want interface{}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Variables, want) {
+				t.Fatalf("wrong result\n%s", cmp.Diff(want, got.Variables))
+			}
+		})
+	}
+}
+
+func testVariableValue(t *testing.T, val cty.Value) plans.DynamicValue {
+	t.Helper()
+
+	dv, err := plans.NewDynamicValue(val, cty.DynamicPseudoType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dv
+}
@@ -0,0 +1,3 @@
+// Package jsonstate implements methods for outputting a state in a
+// machine-readable json format
+package jsonstate
@@ -0,0 +1,374 @@
+package jsonstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/command/addrfilter"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion represents the version of the json format and will be
+// incremented for any change to this format that requires changes to a
+// consuming parser.
+const FormatVersion = "0.1"
+
+// state is the top-level representation of the json format of a terraform
+// state.
+type state struct {
+	FormatVersion string      `json:"format_version,omitempty"`
+	Values        stateValues `json:"values"`
+}
+
+// stateValues is the common representation of resolved values for the
+// resources recorded in a state.
+type stateValues struct {
+	RootModule *module `json:"root_module,omitempty"`
+}
+
+// module is the representation of a module in state. This can be the root
+// module or a child module.
+type module struct {
+	// Address is the absolute module address, omitted for the root module.
+	Address string `json:"address,omitempty"`
+
+	Resources []resource `json:"resources,omitempty"`
+
+	// Each module object can optionally have its own nested "child_modules",
+	// recursively describing the full module tree.
+	ChildModules []module `json:"child_modules,omitempty"`
+}
+
+// resource is the representation of a single resource instance object in
+// state.
+type resource struct {
+	// Address is the absolute resource instance address.
+	Address string `json:"address,omitempty"`
+
+	// Mode can be "managed" or "data".
+	Mode string `json:"mode,omitempty"`
+
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// Index is omitted for a resource not using `count` or `for_each`.
+	Index addrs.InstanceKey `json:"index,omitempty"`
+
+	// ProviderName allows the property "type" to be interpreted unambiguously
+	// in the unusual situation where a provider offers a resource type whose
+	// name does not start with its own name, such as the "googlebeta"
+	// provider offering "google_compute_instance".
+	ProviderName string `json:"provider_name"`
+
+	// DeposedKey is set only for deposed objects, and identifies which of
+	// the deposed objects of the instance this entry describes.
+	DeposedKey string `json:"deposed_key,omitempty"`
+
+	// Status is the status recorded for the object: "ready" for an object
+	// that is ready to use, or "tainted" for one that must be replaced, such
+	// as one that an apply that failed only partly created.
+	Status string `json:"status"`
+
+	// SchemaVersion indicates which version of the resource type schema the
+	// "values" property conforms to.
+	SchemaVersion uint64 `json:"schema_version"`
+
+	// AttributeValues is the JSON representation of the attribute values of
+	// the resource, whose structure depends on the resource type schema.
+	AttributeValues attributeValues `json:"values,omitempty"`
+
+	// Error is set, instead of AttributeValues, for an object whose
+	// attributes can't be decoded with the resource type schema, such as one
+	// that a failed apply left incomplete, and describes why.
+	Error string `json:"error,omitempty"`
+}
+
+// attributeValues is the JSON representation of the attribute values of the
+// resource, whose structure depends on the resource type schema.
+type attributeValues map[string]interface{}
+
+func marshalAttributeValues(value cty.Value) (attributeValues, error) {
+	if value == cty.NilVal || value.IsNull() {
+		return nil, nil
+	}
+	ret := make(attributeValues)
+
+	it := value.ElementIterator()
+	for it.Next() {
+		k, v := it.Element()
+		vJSON, err := ctyjson.Marshal(v, v.Type())
+		if err != nil {
+			return nil, err
+		}
+		ret[k.AsString()] = json.RawMessage(vJSON)
+	}
+	return ret, nil
+}
+
+// MarshalOpts are the options for Marshal and MarshalStream. The zero value
+// produces the complete json representation of a state.
+type MarshalOpts struct {
+	// NoSensitive, if set, causes sensitive attributes to be omitted from
+	// the values of every resource, so that the result is safe to share but
+	// no longer a complete description of the state.
+	NoSensitive bool
+
+	// Filter, if non-nil, selects the resource instances to include.
+	Filter *addrfilter.Filter
+}
+
+// Marshal returns the json encoding of a terraform state, with the given
+// options.
+//
+// A nil or empty state produces a valid document whose "values" object is
+// empty, rather than an error.
+func Marshal(s *states.State, schemas *terraform.Schemas, opts *MarshalOpts) ([]byte, error) {
+	output := &state{
+		FormatVersion: FormatVersion,
+	}
+
+	s = opts.Filter.State(s)
+
+	if s != nil && !s.Empty() {
+		root, err := marshalModule(s, schemas, addrs.RootModuleInstance, opts.NoSensitive)
+		if err != nil {
+			return nil, err
+		}
+		output.Values.RootModule = &root
+	}
+
+	return json.Marshal(output)
+}
+
+// marshalModule returns the json representation of the module with the given
+// address, including all of its descendent modules.
+func marshalModule(s *states.State, schemas *terraform.Schemas, addr addrs.ModuleInstance, noSensitive bool) (module, error) {
+	var ret module
+	if !addr.IsRoot() {
+		ret.Address = addr.String()
+	}
+
+	if ms := s.Module(addr); ms != nil {
+		rs, err := marshalResources(ms, schemas, noSensitive)
+		if err != nil {
+			return ret, err
+		}
+		ret.Resources = rs
+	}
+
+	for _, childAddr := range childModuleAddrs(s, addr) {
+		child, err := marshalModule(s, schemas, childAddr, noSensitive)
+		if err != nil {
+			return ret, err
+		}
+		ret.ChildModules = append(ret.ChildModules, child)
+	}
+
+	return ret, nil
+}
+
+// childModuleAddrs returns the addresses of the direct children of the given
+// module, in lexical order. A module may have no state of its own while still
+// having descendents that do, so children are derived from the address of
+// every module in the state rather than only those tracked directly.
+func childModuleAddrs(s *states.State, parent addrs.ModuleInstance) []addrs.ModuleInstance {
+	children := map[string]addrs.ModuleInstance{}
+	for _, ms := range s.Modules {
+		addr := ms.Addr
+		if len(addr) <= len(parent) {
+			continue
+		}
+		if !addr[:len(parent)].Equal(parent) {
+			continue
+		}
+		child := addr[:len(parent)+1]
+		children[child.String()] = child
+	}
+
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ret := make([]addrs.ModuleInstance, len(keys))
+	for i, k := range keys {
+		ret[i] = children[k]
+	}
+	return ret
+}
+
+func marshalResources(ms *states.Module, schemas *terraform.Schemas, noSensitive bool) ([]resource, error) {
+	return marshalResourcesWorkers(ms, schemas, noSensitive, 0)
+}
+
+// marshalJob is a single object for marshalResourcesWorkers to marshal.
+type marshalJob struct {
+	partial resource
+	obj     *states.ResourceInstanceObjectSrc
+	schema  *configschema.Block
+
+	// desc describes the object in an error, such as "test_thing.foo" or
+	// "test_thing.foo (deposed object deadbeef)".
+	desc string
+}
+
+// marshalResourcesWorkers is marshalResources with the given number of
+// workers decoding and marshaling the objects concurrently, or GOMAXPROCS
+// workers if it is zero.
+//
+// Decoding each object against its schema is most of the work for a large
+// state, and depends only on the object and the schema, so the objects are
+// marshaled by a bounded number of workers, each writing only its own
+// elements of the results. The jobs are sorted before any work starts, so
+// the result and the first error, if any, are the same whatever the number
+// of workers.
+func marshalResourcesWorkers(ms *states.Module, schemas *terraform.Schemas, noSensitive bool, workers int) ([]resource, error) {
+	var jobs []marshalJob
+
+	for _, rs := range ms.Resources {
+		providerType := rs.ProviderConfig.ProviderConfig.Type
+		schema, err := resourceSchema(schemas, providerType, rs.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, ri := range rs.Instances {
+			addr := rs.Addr.Instance(k).Absolute(ms.Addr)
+
+			current := resource{
+				Address:      addr.String(),
+				Mode:         marshalMode(rs.Addr.Mode),
+				Type:         rs.Addr.Type,
+				Name:         rs.Addr.Name,
+				Index:        k,
+				ProviderName: providerType,
+			}
+
+			if ri.Current != nil {
+				jobs = append(jobs, marshalJob{current, ri.Current, schema, addr.String()})
+			}
+
+			for dk, obj := range ri.Deposed {
+				deposed := current
+				deposed.DeposedKey = dk.String()
+				jobs = append(jobs, marshalJob{deposed, obj, schema, fmt.Sprintf("%s (deposed object %s)", addr, dk)})
+			}
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].partial.Address == jobs[j].partial.Address {
+			return jobs[i].partial.DeposedKey < jobs[j].partial.DeposedKey
+		}
+		return jobs[i].partial.Address < jobs[j].partial.Address
+	})
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ret := make([]resource, len(jobs))
+	errs := make([]error, len(jobs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				ret[i], errs[i] = marshalObject(jobs[i].partial, jobs[i].obj, jobs[i].schema, noSensitive)
+			}
+		}()
+	}
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", jobs[i].desc, err)
+		}
+	}
+	return ret, nil
+}
+
+// marshalObject completes the given partially-populated resource with the
+// schema version and attribute values of the given object, omitting any
+// sensitive attributes if noSensitive is set.
+//
+// An object that can't be decoded is flagged with the error rather than
+// failing the whole state, so that the rest of what a failed apply left
+// behind can still be inspected.
+func marshalObject(r resource, obj *states.ResourceInstanceObjectSrc, schema *configschema.Block, noSensitive bool) (resource, error) {
+	r.SchemaVersion = obj.SchemaVersion
+	r.Status = marshalStatus(obj.Status)
+
+	val, err := obj.Decode(schema.ImpliedType())
+	if err != nil {
+		r.Error = fmt.Sprintf("error decoding object: %s", err)
+		return r, nil
+	}
+
+	value := val.Value
+	if noSensitive {
+		value = schema.StripSensitive(value)
+	}
+	r.AttributeValues, err = marshalAttributeValues(value)
+	return r, err
+}
+
+// resourceSchema returns the schema for the given resource, selecting between
+// the managed resource and data source schemas by the resource mode.
+func resourceSchema(schemas *terraform.Schemas, providerType string, addr addrs.Resource) (*configschema.Block, error) {
+	var schema *configschema.Block
+	switch addr.Mode {
+	case addrs.ManagedResourceMode:
+		schema = schemas.ResourceTypeConfig(providerType, addr.Type)
+	case addrs.DataResourceMode:
+		schema = schemas.DataSourceConfig(providerType, addr.Type)
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("no schema found for %s (in provider %s)", addr, providerType)
+	}
+	return schema, nil
+}
+
+// marshalStatus returns the json representation of the given object status.
+// A planned object is only ever a placeholder during planning, and is never
+// saved in a state file.
+func marshalStatus(status states.ObjectStatus) string {
+	switch status {
+	case states.ObjectReady:
+		return "ready"
+	case states.ObjectTainted:
+		return "tainted"
+	case states.ObjectPlanned:
+		return "planned"
+	default:
+		// Should never happen, since the above is exhaustive.
+		return status.String()
+	}
+}
+
+func marshalMode(mode addrs.ResourceMode) string {
+	switch mode {
+	case addrs.ManagedResourceMode:
+		return "managed"
+	case addrs.DataResourceMode:
+		return "data"
+	default:
+		// Should never happen, since the above is exhaustive.
+		return mode.String()
+	}
+}
@@ -0,0 +1,409 @@
+package jsonstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestMarshal_empty(t *testing.T) {
+	for _, s := range []*states.State{nil, states.NewState()} {
+		got, err := Marshal(s, testSchemas(), &MarshalOpts{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := `{"format_version":"0.1","values":{}}`
+		if string(got) != want {
+			t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.NoKey).Child("grandchild", addrs.IntKey(0))
+	s := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "foo",
+			}.Instance(addrs.IntKey(0)).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:        states.ObjectReady,
+				SchemaVersion: 1,
+				AttrsJSON:     []byte(`{"woozles":"confuzles"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetResourceInstanceDeposed(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "foo",
+			}.Instance(addrs.IntKey(0)).Absolute(addrs.RootModuleInstance),
+			states.DeposedKey("deadbeef"),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"old"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.DataResourceMode,
+				Type: "test_data",
+				Name: "bar",
+			}.Instance(addrs.StringKey("a")).Absolute(childAddr),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"value":"baz"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(childAddr),
+		)
+	})
+
+	got, err := Marshal(s, testSchemas(), &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var gotV, wantV interface{}
+	if err := json.Unmarshal(got, &gotV); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "format_version": "0.1",
+  "values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "test_thing.foo[0]",
+          "mode": "managed",
+          "type": "test_thing",
+          "name": "foo",
+          "index": 0,
+          "provider_name": "test",
+          "status": "ready",
+          "schema_version": 1,
+          "values": {"woozles": "confuzles"}
+        },
+        {
+          "address": "test_thing.foo[0]",
+          "mode": "managed",
+          "type": "test_thing",
+          "name": "foo",
+          "index": 0,
+          "provider_name": "test",
+          "deposed_key": "deadbeef",
+          "status": "ready",
+          "schema_version": 0,
+          "values": {"woozles": "old"}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.child",
+          "child_modules": [
+            {
+              "address": "module.child.module.grandchild[0]",
+              "resources": [
+                {
+                  "address": "module.child.module.grandchild[0].data.test_data.bar[\"a\"]",
+                  "mode": "data",
+                  "type": "test_data",
+                  "name": "bar",
+                  "index": "a",
+                  "provider_name": "test",
+                  "status": "ready",
+                  "schema_version": 0,
+                  "values": {"value": "baz"}
+                }
+              ]
+            }
+          ]
+        }
+      ]
+    }
+  }
+}`
+	if err := json.Unmarshal([]byte(want), &wantV); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(gotV, wantV) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(wantV, gotV))
+	}
+}
+
+func TestMarshal_missingSchema(t *testing.T) {
+	s := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_unknown",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	_, err := Marshal(s, testSchemas(), &MarshalOpts{})
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+}
+
+func TestMarshalResources_workers(t *testing.T) {
+	ms := testManyResources(1000).RootModule()
+
+	marshal := func(workers int) []byte {
+		t.Helper()
+		rs, err := marshalResourcesWorkers(ms, testSchemas(), false, workers)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		src, err := json.Marshal(rs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	want := marshal(1)
+	for _, workers := range []int{2, 7, 64} {
+		if got := marshal(workers); !bytes.Equal(got, want) {
+			t.Errorf("result with %d workers differs from the sequential result", workers)
+		}
+	}
+
+	// Objects that can't be decoded are flagged in place, with the same
+	// result whichever worker decodes them.
+	bad := testManyResources(1000)
+	for _, i := range []int{10, 500} {
+		bad.ResourceInstance(testManyResourcesAddr(i)).Current.AttrsJSON = []byte(`not json`)
+	}
+	ms = bad.RootModule()
+	want = marshal(1)
+	if got := marshal(16); !bytes.Equal(got, want) {
+		t.Errorf("result with 16 workers differs from the sequential result")
+	}
+	rs, err := marshalResourcesWorkers(ms, testSchemas(), false, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var flagged []string
+	for _, r := range rs {
+		if r.Error != "" {
+			flagged = append(flagged, r.Address)
+		}
+	}
+	if want := []string{"test_thing.foo[10]", "test_thing.foo[500]"}; !reflect.DeepEqual(flagged, want) {
+		t.Errorf("wrong flagged objects %#v; want %#v", flagged, want)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	s := testManyResources(20000)
+	schemas := testSchemas()
+
+	for name, workers := range map[string]int{"sequential": 1, "parallel": 0} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := marshalResourcesWorkers(s.RootModule(), schemas, false, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// testManyResources returns a state with n instances of a counted resource.
+func testManyResources(n int) *states.State {
+	return states.BuildState(func(s *states.SyncState) {
+		for i := 0; i < n; i++ {
+			s.SetResourceInstanceCurrent(
+				testManyResourcesAddr(i),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(fmt.Sprintf(`{"woozles":%q}`, strings.Repeat("confuzles ", 20))),
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+}
+
+func testManyResourcesAddr(i int) addrs.AbsResourceInstance {
+	return addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_thing",
+		Name: "foo",
+	}.Instance(addrs.IntKey(i)).Absolute(addrs.RootModuleInstance)
+}
+
+func testSchemas() *terraform.Schemas {
+	return &terraform.Schemas{
+		Providers: map[string]*terraform.ProviderSchema{
+			"test": {
+				ResourceTypes: map[string]*configschema.Block{
+					"test_thing": {
+						Attributes: map[string]*configschema.Attribute{
+							"woozles": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+				DataSources: map[string]*configschema.Block{
+					"test_data": {
+						Attributes: map[string]*configschema.Attribute{
+							"value": {Type: cty.String, Computed: true},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMarshal_status(t *testing.T) {
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_thing",
+		Name: "foo",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	s := states.BuildState(func(s *states.SyncState) {
+		// A failed create leaves behind a tainted object with whatever
+		// attributes were recorded before the failure.
+		s.SetResourceInstanceCurrent(
+			addr,
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectTainted,
+				AttrsJSON: []byte(`{}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetResourceInstanceDeposed(
+			addr,
+			states.DeposedKey("deadbeef"),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"old"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	src, err := Marshal(s, testSchemas(), &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got struct {
+		Values struct {
+			RootModule struct {
+				Resources []struct {
+					DeposedKey string `json:"deposed_key"`
+					Status     string `json:"status"`
+				} `json:"resources"`
+			} `json:"root_module"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []string
+	for _, r := range got.Values.RootModule.Resources {
+		statuses = append(statuses, r.DeposedKey+": "+r.Status)
+	}
+	want := []string{": tainted", "deadbeef: ready"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("wrong statuses %#v; want %#v", statuses, want)
+	}
+}
+
+func TestMarshal_undecodable(t *testing.T) {
+	s := states.BuildState(func(s *states.SyncState) {
+		// An object whose attributes don't match the schema, such as one
+		// that a failed apply left incomplete, can't be decoded.
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectTainted,
+				AttrsJSON: []byte(`{"woozles":{"not":"a string"}}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_thing",
+				Name: "bar",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"woozles":"confuzles"}`),
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+
+	src, err := Marshal(s, testSchemas(), &MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got struct {
+		Values struct {
+			RootModule struct {
+				Resources []map[string]interface{} `json:"resources"`
+			} `json:"root_module"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := got.Values.RootModule.Resources
+	if len(rs) != 2 {
+		t.Fatalf("wrong number of resources %d; want 2\n%s", len(rs), src)
+	}
+	bar, foo := rs[0], rs[1]
+	if _, ok := bar["error"]; ok {
+		t.Errorf("decodable object has an error\n%s", src)
+	}
+	if _, ok := bar["values"]; !ok {
+		t.Errorf("decodable object has no values\n%s", src)
+	}
+	if got, want := foo["address"], "test_thing.foo"; got != want {
+		t.Errorf("wrong address %v; want %v", got, want)
+	}
+	if got, want := foo["mode"], "managed"; got != want {
+		t.Errorf("wrong mode %v; want %v", got, want)
+	}
+	if got, want := foo["status"], "tainted"; got != want {
+		t.Errorf("wrong status %v; want %v", got, want)
+	}
+	if _, ok := foo["values"]; ok {
+		t.Errorf("undecodable object has values\n%s", src)
+	}
+	if msg, _ := foo["error"].(string); !strings.HasPrefix(msg, "error decoding object: ") {
+		t.Errorf("wrong error %q", msg)
+	}
+}
@@ -0,0 +1,80 @@
+package jsonstate
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// streamHeader is the first line of the streamed json format of a state.
+type streamHeader struct {
+	// Type is always "header".
+	Type          string `json:"type"`
+	FormatVersion string `json:"format_version"`
+}
+
+// streamResource is a line of the streamed json format of a state that
+// describes a single resource instance object.
+type streamResource struct {
+	// Type is always "resource".
+	Type     string   `json:"type"`
+	Resource resource `json:"resource"`
+}
+
+// MarshalStream writes the newline-delimited json encoding of a terraform
+// state to the given writer.
+//
+// The first line is a header object carrying the format version, followed by
+// one line for each resource instance object in the state, each carrying the
+// same representation of the object as Marshal. Each line has a "type"
+// property of either "header" or "resource" to tell them apart. Unlike
+// Marshal, the objects are encoded one module at a time, so the whole
+// document is never held in memory at once.
+//
+// A nil or empty state produces only the header line. The options are as
+// for Marshal.
+func MarshalStream(w io.Writer, s *states.State, schemas *terraform.Schemas, opts *MarshalOpts) error {
+	enc := json.NewEncoder(w)
+	s = opts.Filter.State(s)
+
+	err := enc.Encode(streamHeader{
+		Type:          "header",
+		FormatVersion: FormatVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s == nil {
+		return nil
+	}
+
+	// Modules are written in lexical order of their addresses so that the
+	// output is deterministic.
+	keys := make([]string, 0, len(s.Modules))
+	for k := range s.Modules {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		rs, err := marshalResources(s.Modules[k], schemas, opts.NoSensitive)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			err := enc.Encode(streamResource{
+				Type:     "resource",
+				Resource: r,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package jsonstate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestMarshalStream(t *testing.T) {
+	childAddr := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+	s := states.BuildState(func(s *states.SyncState) {
+		for _, module := range []addrs.ModuleInstance{childAddr, addrs.RootModuleInstance} {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_thing",
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					Status:    states.ObjectReady,
+					AttrsJSON: []byte(`{"woozles":"confuzles"}`),
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(module),
+			)
+		}
+	})
+
+	var buf bytes.Buffer
+	if err := MarshalStream(&buf, s, testSchemas(), &MarshalOpts{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		`{"type":"header","format_version":"0.1"}`,
+		`{"type":"resource","resource":{"address":"test_thing.foo","mode":"managed","type":"test_thing","name":"foo","provider_name":"test","status":"ready","schema_version":0,"values":{"woozles":"confuzles"}}}`,
+		`{"type":"resource","resource":{"address":"module.child.test_thing.foo","mode":"managed","type":"test_thing","name":"foo","provider_name":"test","status":"ready","schema_version":0,"values":{"woozles":"confuzles"}}}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of lines %d; want %d\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("wrong line %d\ngot:  %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMarshalStream_empty(t *testing.T) {
+	for _, s := range []*states.State{nil, states.NewState()} {
+		var buf bytes.Buffer
+		if err := MarshalStream(&buf, s, testSchemas(), &MarshalOpts{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := "{\"type\":\"header\",\"format_version\":\"0.1\"}\n"
+		if got := buf.String(); got != want {
+			t.Errorf("wrong result\ngot:  %q\nwant: %q", got, want)
+		}
+	}
+}
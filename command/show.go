@@ -1,126 +1,673 @@
 package command
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/backend/local"
+	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/hashicorp/terraform/tfdiags"
+	tfversion "github.com/hashicorp/terraform/version"
 
+	"github.com/hashicorp/terraform/command/addrfilter"
 	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
 )
 
 // ShowCommand is a Command implementation that reads and outputs the
 // contents of a Terraform plan or state file.
 type ShowCommand struct {
 	Meta
+	input io.Reader // STDIN if nil
+
+	// terminal returns true if the output is to a terminal, and is
+	// isatty on stdout if nil.
+	terminal func() bool
+
+	// jsonPretty is set by the -json-pretty flag to indent, and colorize if
+	// color is enabled, any JSON output.
+	jsonPretty bool
+
+	// noSensitive is set by the -no-sensitive flag to omit sensitive values
+	// entirely from any JSON output.
+	noSensitive bool
+
+	// The remaining fields are set by the other flags, with filter
+	// selecting the addresses given by -target, moduleAddr holding the
+	// parsed -module address, types and where holding the parsed -type and
+	// -where flags, and priorStatePath set by -state.
+	jsonOutput, jsonStream, check bool
+	withState, changesOnly        bool
+	noValues                      bool
+	jsonConfig                    bool
+	jsonStats                     bool
+	module, outPath               string
+	priorStatePath                string
+	filter                        *addrfilter.Filter
+	moduleAddr                    addrs.ModuleInstance
+	types                         []showResourceType
+	where                         []showWhere
+	deposed                       states.DeposedKey
+	urlTimeout                    time.Duration
+	urlMaxSize                    int64
+	maxAge                        time.Duration
+	decryptCmd                    string
+	jsonCacheDir                  string
+	hclOutput                     bool
+	tableOutput                   bool
+	runID                         string
+	diff                          bool
+	addresses                     bool
+	brief                         bool
+	showAll                       bool
+	backup                        bool
+	fromEnv                       string
+	onlyErrors                    bool
+	watch                         bool
+	watchInterval                 time.Duration
+
+	// jsonUi is the Ui that collects the diagnostics to include in the
+	// output while showing with -json, and is nil otherwise.
+	jsonUi *showJSONUi
 }
 
 func (c *ShowCommand) Run(args []string) int {
+	if c.input == nil {
+		c.input = os.Stdin
+	}
+
 	args, err := c.Meta.process(args, false)
 	if err != nil {
 		return 1
 	}
 
-	cmdFlags := flag.NewFlagSet("show", flag.ContinueOnError)
-
+	var f showFlags
+	cmdFlags := c.flagSet(&f)
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
 	args = cmdFlags.Args()
-	if len(args) > 1 {
-		c.Ui.Error(
-			"The show command expects at most one argument with the path\n" +
-				"to a Terraform state or plan file.\n")
+
+	format, ok := showOutputFormat(f.format)
+	if !ok {
+		c.Ui.Error("The -format option must be one of tree, table or json.\n")
 		cmdFlags.Usage()
 		return 1
 	}
+	given := showFlagsGiven(cmdFlags)
 
-	var diags tfdiags.Diagnostics
+	if f.jsonSchema {
+		// The schema describes the output rather than any particular file,
+		// so there's nothing else to do.
+		delete(given, "json-schema")
+		if format == 0 {
+			delete(given, "format")
+		}
+		if len(args) > 0 || len(given) > 0 {
+			c.Ui.Error("The -json-schema option takes no path, and can't be used together with any other option.\n")
+			cmdFlags.Usage()
+			return 1
+		}
+		c.Ui.Output(strings.TrimSpace(jsonplan.Schema))
+		return 0
+	}
 
-	// Load the backend
+	// Which options can be used together is described by the tables in
+	// show_options.go, rather than checked here one by one.
+	output, _, msg := showModes(given, format, len(args))
+	if msg != "" {
+		c.Ui.Error(msg + "\n")
+		cmdFlags.Usage()
+		return 1
+	}
+	c.jsonOutput = output == showOutputJSON
+	c.tableOutput = output == showOutputTable
+
+	if c.maxAge < 0 {
+		c.Ui.Error("The -max-age option must be a positive duration, such as 24h.\n")
+		cmdFlags.Usage()
+		return 1
+	}
+	if c.watch && c.watchInterval <= 0 {
+		c.Ui.Error("The -interval option must be a positive duration, such as 5s.\n")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	var targets []addrs.Targetable
+	for _, raw := range f.targets {
+		target, addrDiags := addrs.ParseTargetStr(raw)
+		if addrDiags.HasErrors() {
+			c.Ui.Error(fmt.Sprintf("Error parsing target address: %s", raw))
+			return 1
+		}
+		targets = append(targets, target.Subject)
+	}
+	c.filter = addrfilter.New(targets)
+
+	if f.deposed != "" && c.filter == nil {
+		c.Ui.Error("The -deposed option requires -target, to select the resource instance whose deposed object to show.\n")
+		cmdFlags.Usage()
+		return 1
+	}
+	c.deposed = states.DeposedKey(f.deposed)
+
+	if c.module != "" {
+		var addrDiags tfdiags.Diagnostics
+		c.moduleAddr, addrDiags = addrs.ParseModuleInstanceStr(c.module)
+		if addrDiags.HasErrors() || c.moduleAddr.IsRoot() {
+			c.Ui.Error(fmt.Sprintf("Error parsing module address: %s", c.module))
+			return 1
+		}
+	}
+
+	c.types = nil
+	for _, raw := range f.types {
+		t, ok := parseShowResourceType(raw)
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Error parsing resource type: %s", raw))
+			return 1
+		}
+		c.types = append(c.types, t)
+	}
+
+	c.where = nil
+	for _, raw := range f.where {
+		w, ok := parseShowWhere(raw)
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Error parsing -where condition: %s", raw))
+			return 1
+		}
+		c.where = append(c.where, w)
+	}
+
+	if !c.jsonOutput {
+		return c.showOutput(args)
+	}
+
+	// With -json, errors and warnings are included in the JSON output as
+	// diagnostics rather than written as text, as described for showJSONUi.
+	ui := c.Ui
+	c.jsonUi = &showJSONUi{Ui: ui}
+	c.Ui = c.jsonUi
+	defer func() {
+		c.Ui, c.jsonUi = ui, nil
+	}()
+
+	code := c.showOutput(args)
+	if code != 0 && len(c.jsonUi.diags) > 0 {
+		// No document was written to include the diagnostics in, so they
+		// make up a document of their own.
+		c.jsonStats = false
+		c.outputJSON([]byte("{}"))
+	}
+	return code
+}
+
+// showFlags holds the values of the flags of the show command that are
+// parsed further before they're kept in the fields of ShowCommand, if at all.
+type showFlags struct {
+	format     string
+	jsonSchema bool
+	targets    FlagStringSlice
+	types      FlagStringSlice
+	where      FlagStringSlice
+	deposed    string
+}
+
+// flagSet returns the flag set of the show command, which sets the fields of
+// the command and of the given showFlags.
+func (c *ShowCommand) flagSet(f *showFlags) *flag.FlagSet {
+	cmdFlags := flag.NewFlagSet("show", flag.ContinueOnError)
+	cmdFlags.BoolVar(&c.jsonOutput, "json", false, "produce JSON output")
+	cmdFlags.BoolVar(&c.jsonStream, "json-stream", false, "produce newline-delimited JSON output")
+	cmdFlags.BoolVar(&c.check, "check", false, "check the file without output")
+	cmdFlags.BoolVar(&c.hclOutput, "hcl", false, "produce import blocks for a state")
+	cmdFlags.BoolVar(&c.addresses, "addresses", false, "list the resource instance addresses of a state")
+	cmdFlags.BoolVar(&c.brief, "brief", false, "show a state without its attributes")
+	cmdFlags.BoolVar(&c.showAll, "show-all", false, "show the unchanged attributes of changed resources")
+	cmdFlags.StringVar(&f.format, "format", "tree", "output format: tree, table or json")
+	cmdFlags.BoolVar(&c.jsonPretty, "json-pretty", false, "indent JSON output")
+	cmdFlags.BoolVar(&c.jsonPretty, "pretty", false, "indent JSON output")
+	cmdFlags.BoolVar(&c.noSensitive, "no-sensitive", false, "omit sensitive values from JSON output")
+	cmdFlags.BoolVar(&c.withState, "with-state", false, "show the planned state along with a plan")
+	cmdFlags.BoolVar(&c.changesOnly, "json-changes-only", false, "omit unchanged resources from JSON plan output")
+	cmdFlags.BoolVar(&c.noValues, "json-no-values", false, "replace all values with nulls in JSON plan output")
+	cmdFlags.BoolVar(&c.jsonConfig, "json-config", false, "include the configuration in JSON plan output")
+	cmdFlags.BoolVar(&c.jsonStats, "json-stats", false, "summarize the JSON output on stderr")
+	cmdFlags.StringVar(&c.jsonCacheDir, "json-cache", "", "directory to cache JSON plan output in")
+	cmdFlags.BoolVar(&f.jsonSchema, "json-schema", false, "output the JSON Schema of the JSON plan output")
+	cmdFlags.Var(&f.targets, "target", "resource address")
+	cmdFlags.StringVar(&c.module, "module", "", "module instance address")
+	cmdFlags.Var(&f.types, "type", "resource type")
+	cmdFlags.Var(&f.where, "where", "attribute condition")
+	cmdFlags.StringVar(&f.deposed, "deposed", "", "deposed object key")
+	cmdFlags.StringVar(&c.outPath, "out", "", "path to write the output to")
+	cmdFlags.StringVar(&c.priorStatePath, "state", "", "path to a state to show a plan against")
+	cmdFlags.DurationVar(&c.urlTimeout, "url-timeout", defaultShowURLTimeout, "timeout for fetching a URL")
+	cmdFlags.Int64Var(&c.urlMaxSize, "url-max-size", defaultShowURLMaxSize, "maximum size in bytes of a fetched URL")
+	cmdFlags.DurationVar(&c.maxAge, "max-age", 0, "age beyond which a plan is reported as stale")
+	cmdFlags.StringVar(&c.decryptCmd, "decrypt-cmd", "", "command to decrypt each file with")
+	cmdFlags.StringVar(&c.runID, "run", "", "remote run whose plan to show")
+	cmdFlags.BoolVar(&c.diff, "diff", false, "show the differences between two states")
+	cmdFlags.BoolVar(&c.backup, "backup", false, "show the backup of the current state")
+	cmdFlags.StringVar(&c.fromEnv, "from-env", "", "environment variable holding a base64-encoded file to show")
+	cmdFlags.BoolVar(&c.onlyErrors, "only-errors", false, "show only error diagnostics")
+	cmdFlags.BoolVar(&c.watch, "watch", false, "show the current state repeatedly")
+	cmdFlags.DurationVar(&c.watchInterval, "interval", defaultShowWatchInterval, "interval between renders with -watch")
+	return cmdFlags
+}
+
+// showOutput shows the current state or the files at the given paths as
+// for showArgs, writing the output to the file given by -out if it is set,
+// and returns the exit status.
+func (c *ShowCommand) showOutput(args []string) int {
+	if c.outPath == "" {
+		return c.showArgs(args)
+	}
+
+	// The output is captured so that it can be written to the file only once
+	// it is complete. Color codes don't belong in a file, so color is
+	// disabled.
+	ui := c.Ui
+	var buf bytes.Buffer
+	c.Ui = &showCaptureUi{Ui: ui, output: &buf}
+	c.color = false
+	code := c.showArgs(args)
+	c.Ui = ui
+	if code != 0 {
+		return code
+	}
+	if err := writeFileAtomic(c.outPath, buf.Bytes()); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write output: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// showArgs shows the current state or the files at the given paths, and
+// returns the exit status.
+func (c *ShowCommand) showArgs(args []string) int {
+	if c.runID != "" {
+		return c.showRun()
+	}
+	if c.diff {
+		return c.showDiff(args[0], args[1])
+	}
+	if c.backup {
+		return c.showBackup()
+	}
+	if c.watch {
+		return c.showWatch()
+	}
+	if c.fromEnv != "" {
+		// The path is only used to describe the file, since readShowFile
+		// reads it from the environment variable instead.
+		return c.show("$" + c.fromEnv)
+	}
+	switch len(args) {
+	case 0:
+		return c.show("")
+	case 1:
+		return c.show(args[0])
+	default:
+		return c.showMultiple(args)
+	}
+}
+
+// showBackup shows the backup of the current workspace's state that the
+// local backend writes each time it updates the state, which is the state as
+// it was before the most recent update, and returns the exit status.
+func (c *ShowCommand) showBackup() int {
 	b, backendDiags := c.Backend(nil)
-	diags = diags.Append(backendDiags)
 	if backendDiags.HasErrors() {
-		c.showDiagnostics(diags)
+		c.showDiagnostics(backendDiags)
 		return 1
 	}
-
-	// We require a local backend
-	local, ok := b.(backend.Local)
-	if !ok {
-		c.showDiagnostics(diags) // in case of any warnings in here
-		c.Ui.Error(ErrUnsupportedLocalOp)
+	// A backend that stores state elsewhere is wrapped in a local backend
+	// for its operations, but keeps no backup of its own.
+	lb, ok := b.(*local.Local)
+	if !ok || lb.Backend != nil {
+		c.Ui.Error(`The -backup option requires the "local" backend, which keeps a backup of the previous state alongside the state file, but the configured backend stores state elsewhere.`)
 		return 1
 	}
 
-	// the show command expects the config dir to always be the cwd
-	cwd, err := os.Getwd()
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Error getting cwd: %s", err))
+	_, _, backupPath := lb.StatePaths(c.Workspace())
+	if backupPath == "" {
+		c.Ui.Error("Backups of the state are disabled, so there is no backup to show.")
+		return 1
+	}
+	if _, err := os.Stat(backupPath); err != nil {
+		if os.IsNotExist(err) {
+			c.Ui.Error(fmt.Sprintf("No state backup found at %s. A backup is written only when the state is updated, so there is none until it has been updated at least once.", backupPath))
+		} else {
+			c.Ui.Error(fmt.Sprintf("Failed to read the state backup: %s", err))
+		}
 		return 1
 	}
+	return c.show(backupPath)
+}
 
-	// Build the operation
-	opReq := c.Operation(b)
-	opReq.ConfigDir = cwd
-	opReq.ConfigLoader, err = c.initConfigLoader()
-	if err != nil {
-		diags = diags.Append(err)
+// showDiff outputs the differences between the state files at the given
+// paths, for -diff, and returns the exit status.
+//
+// The differences are described as the changes that would turn the old
+// state into the new one, as computed by stateDiffChanges, and are shown in
+// the same way as the changes of a plan.
+func (c *ShowCommand) showDiff(oldPath, newPath string) int {
+	var diffStates [2]*states.State
+	for i, path := range []string{oldPath, newPath} {
+		_, _, plan, state, code := c.readShowFile(path)
+		if code != 0 {
+			return code
+		}
+		if plan != nil {
+			c.Ui.Error(fmt.Sprintf("The -diff option compares two state files, but %s is a plan file.", path))
+			return 1
+		}
+		diffStates[i] = c.filter.State(state)
+	}
+
+	_, schemas, diags := c.backendSchemas()
+	if diags.HasErrors() {
 		c.showDiagnostics(diags)
 		return 1
 	}
 
-	// Get the context
-	ctx, _, ctxDiags := local.Context(opReq)
-	diags = diags.Append(ctxDiags)
-	if ctxDiags.HasErrors() {
-		c.showDiagnostics(diags)
+	changes, err := stateDiffChanges(diffStates[0], diffStates[1], schemas)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to compare the states: %s", err))
 		return 1
 	}
 
-	schemas := ctx.Schemas()
+	if c.jsonOutput {
+		src, err := jsonplan.MarshalChanges(changes, diffStates[0], schemas, c.noSensitive)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal the differences to json: %s", err))
+			return 1
+		}
+		return c.outputJSON(src)
+	}
+
+	dispPlan := format.NewPlan(changes, schemas)
+	dispPlan.ShowUnchanged = c.showAll
+	if dispPlan.Empty() {
+		c.Ui.Output("The states have no differences.")
+		return 0
+	}
+	stats := dispPlan.Stats()
+	c.Ui.Output(dispPlan.Format(c.Colorize()))
+	c.Ui.Output("\n" + c.Colorize().Color(fmt.Sprintf(
+		"[reset][bold]Difference:[reset] %d added, %d changed, %d removed.",
+		stats.ToAdd, stats.ToChange, stats.ToDestroy,
+	)))
+	return 0
+}
+
+// stateDiffChanges returns the changes that would turn the old state into
+// the new one: a create for each managed resource instance object that is
+// only in the new state, a delete for each that is only in the old state,
+// and an update for each whose attribute values differ between them. Data
+// resources are left out, since they are read afresh by each run rather
+// than managed.
+//
+// The objects are decoded with the given schemas, so both states must be
+// in the form of the current schema versions of their resource types.
+func stateDiffChanges(old, new *states.State, schemas *terraform.Schemas) (*plans.Changes, error) {
+	type diffObject struct {
+		addr     addrs.AbsResourceInstance
+		deposed  states.DeposedKey
+		provider addrs.AbsProviderConfig
+		obj      *states.ResourceInstanceObjectSrc
+	}
+	collect := func(s *states.State) map[string]diffObject {
+		ret := map[string]diffObject{}
+		if s == nil {
+			return ret
+		}
+		for _, m := range s.Modules {
+			for _, rs := range m.Resources {
+				if rs.Addr.Mode != addrs.ManagedResourceMode {
+					continue
+				}
+				for k, is := range rs.Instances {
+					addr := rs.Addr.Absolute(m.Addr).Instance(k)
+					add := func(deposed states.DeposedKey, obj *states.ResourceInstanceObjectSrc) {
+						ret[addr.String()+"\x00"+string(deposed)] = diffObject{addr, deposed, rs.ProviderConfig, obj}
+					}
+					if is.Current != nil {
+						add(states.NotDeposed, is.Current)
+					}
+					for dk, obj := range is.Deposed {
+						add(dk, obj)
+					}
+				}
+			}
+		}
+		return ret
+	}
+	before, after := collect(old), collect(new)
 
-	env := c.Workspace()
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range after {
+		keys = append(keys, k)
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
 
-	var planErr, stateErr error
-	var path string
-	var plan *plans.Plan
-	var state *states.State
-	if len(args) > 0 {
-		path = args[0]
-		pr, err := planfile.Open(path)
+	changes := plans.NewChanges()
+	for _, k := range keys {
+		b, hadOld := before[k]
+		a, hasNew := after[k]
+		o := a
+		if !hasNew {
+			o = b
+		}
+
+		schema := schemas.ResourceTypeConfig(o.provider.ProviderConfig.Type, o.addr.Resource.Resource.Type)
+		if schema == nil {
+			return nil, fmt.Errorf("no schema found for %s (in provider %s)", o.addr, o.provider.ProviderConfig.Type)
+		}
+		ty := schema.ImpliedType()
+		decode := func(d diffObject, ok bool) (cty.Value, error) {
+			if !ok {
+				return cty.NullVal(ty), nil
+			}
+			obj, err := d.obj.Decode(ty)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("failed to decode %s: %s", d.addr, err)
+			}
+			return obj.Value, nil
+		}
+		beforeV, err := decode(b, hadOld)
+		if err != nil {
+			return nil, err
+		}
+		afterV, err := decode(a, hasNew)
 		if err != nil {
-			f, err := os.Open(path)
+			return nil, err
+		}
+
+		action := plans.Update
+		switch {
+		case !hadOld:
+			action = plans.Create
+		case !hasNew:
+			action = plans.Delete
+		case beforeV.RawEquals(afterV):
+			continue
+		}
+
+		beforeDV, err := plans.NewDynamicValue(beforeV, ty)
+		if err != nil {
+			return nil, err
+		}
+		afterDV, err := plans.NewDynamicValue(afterV, ty)
+		if err != nil {
+			return nil, err
+		}
+		changes.Resources = append(changes.Resources, &plans.ResourceInstanceChangeSrc{
+			Addr:         o.addr,
+			DeposedKey:   o.deposed,
+			ProviderAddr: o.provider,
+			ChangeSrc: plans.ChangeSrc{
+				Action: action,
+				Before: beforeDV,
+				After:  afterDV,
+			},
+		})
+	}
+	return changes, nil
+}
+
+// writeFileAtomic writes the given content to the file at the given path,
+// creating or replacing it. The content is written to a temporary file in
+// the same directory first and then renamed into place, so that the file is
+// never seen partially written.
+func writeFileAtomic(path string, src []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	_, err = f.Write(src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// show outputs the plan or state file at the given path, or the current
+// state if the path is empty, according to the options set by the flags,
+// and returns the exit status.
+func (c *ShowCommand) show(path string) int {
+	var err error
+	var plan *plans.Plan
+	var state, priorState *states.State
+	var config *configs.Config
+	var schemas *terraform.Schemas
+	var planSum [sha256.Size]byte
+	if path != "" {
+		// The file is read fully into memory so that a plan or state piped
+		// in on stdin can be sniffed for both formats, in the same way as a
+		// file on disk.
+		var src []byte
+		var pr *planfile.Reader
+		var code int
+		src, pr, plan, state, code = c.readShowFile(path)
+		if code != 0 {
+			return code
+		}
+		if plan != nil {
+			// The state of a plan file is the prior state that the plan was
+			// created against, not a state to be shown.
+			priorState, state = state, nil
+			planSum = sha256.Sum256(src)
+		}
+
+		if plan != nil && c.maxAge > 0 {
+			c.showDiagnostics(planAgeDiagnostics(plan, c.maxAge, time.Now()))
+		}
+
+		if c.check {
+			if plan != nil {
+				// The configuration snapshot isn't otherwise decoded until
+				// it's needed for the schemas, so we check it here too.
+				if _, configDiags := pr.ReadConfig(); configDiags.HasErrors() {
+					c.showDiagnostics(configDiags)
+					return 2
+				}
+			}
+			return 0
+		}
+
+		if plan != nil && c.priorStatePath != "" {
+			priorState, err = c.readPriorState()
 			if err != nil {
-				c.Ui.Error(fmt.Sprintf("Error loading file: %s", err))
+				c.Ui.Error(fmt.Sprintf("Failed to load state from -state: %s", err))
 				return 1
 			}
-			defer f.Close()
+			c.showDiagnostics(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Showing the plan against a different state",
+				fmt.Sprintf(
+					"The plan is shown against the state in %s rather than the prior state it was created with. "+
+						"The planned changes were computed from the original prior state, and have not been "+
+						"recomputed, so they may not be what applying the plan to this state would actually do.",
+					c.priorStatePath,
+				),
+			))
+		}
 
-			var stateFile *statefile.File
-			stateFile, err = statefile.Read(f)
-			if err != nil {
-				stateErr = err
-			} else {
-				state = stateFile.State
+		if plan != nil {
+			// A plan file is self-contained, so its schemas come from the
+			// configuration snapshot embedded within it rather than from
+			// the backend and the configuration in the working directory,
+			// which may not even be initialized.
+			var diags tfdiags.Diagnostics
+			config, schemas, diags = c.planFileSchemas(pr, plan, priorState)
+			if diags.HasErrors() {
+				c.showDiagnostics(diags)
+				return 1
 			}
-		} else {
-			plan, err = pr.ReadPlan()
-			if err != nil {
-				planErr = err
+		} else if state != nil {
+			var diags tfdiags.Diagnostics
+			_, schemas, diags = c.backendSchemas()
+			if diags.HasErrors() {
+				c.showDiagnostics(diags)
+				return 1
 			}
 		}
 	} else {
+		b, backendSchemas, diags := c.backendSchemas()
+		if diags.HasErrors() {
+			c.showDiagnostics(diags)
+			return 1
+		}
+		schemas = backendSchemas
+
 		// Get the state
+		env := c.Workspace()
 		stateStore, err := b.StateMgr(env)
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
@@ -134,51 +681,1635 @@ func (c *ShowCommand) Run(args []string) int {
 
 		state = stateStore.State()
 		if state == nil {
+			if c.filter != nil {
+				c.Ui.Error(fmt.Sprintf(errShowNoInstanceFound, showTargetsString(c.filter)))
+				return 1
+			}
+			if c.module != "" {
+				c.Ui.Error(fmt.Sprintf("No resources found in module %s.", c.moduleAddr))
+				return 1
+			}
+			if c.jsonOutput {
+				return c.outputStateJSON(nil, schemas)
+			}
+			if c.jsonStream {
+				return c.outputStateJSONStream(nil, schemas)
+			}
+			if c.addresses {
+				// There are no addresses to list.
+				return 0
+			}
 			c.Ui.Output("No state.")
 			return 0
 		}
 	}
 
-	if plan == nil && state == nil {
-		c.Ui.Error(fmt.Sprintf(
-			"Terraform couldn't read the given file as a state or plan file.\n"+
-				"The errors while attempting to read the file as each format are\n"+
-				"shown below.\n\n"+
-				"State read error: %s\n\nPlan read error: %s",
-			stateErr,
-			planErr))
-		return 1
-	}
-
 	if plan != nil {
-		dispPlan := format.NewPlan(plan.Changes)
-		c.Ui.Output(dispPlan.Format(c.Colorize()))
-		return 0
-	}
+		if c.filter != nil && !c.jsonOutput {
+			c.Ui.Error("The -target option can only be used together with -json when showing a plan.")
+			return 1
+		}
+		if c.module != "" {
+			c.Ui.Error("The -module option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if len(c.types) > 0 {
+			c.Ui.Error("The -type option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if len(c.where) > 0 {
+			c.Ui.Error("The -where option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.deposed != states.NotDeposed {
+			c.Ui.Error("The -deposed option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.jsonStream {
+			c.Ui.Error("The -json-stream option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.hclOutput {
+			c.Ui.Error("The -hcl option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.tableOutput {
+			c.Ui.Error("The -format=table option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.addresses {
+			c.Ui.Error("The -addresses option can only be used when showing a state, not a plan.")
+			return 1
+		}
+		if c.brief {
+			c.Ui.Error("The -brief option can only be used when showing a state, not a plan.")
+			return 1
+		}
 
-	c.Ui.Output(format.State(&format.StateOpts{
-		State:   state,
-		Color:   c.Colorize(),
-		Schemas: schemas,
-	}))
-	return 0
-}
+		var planned *states.State
+		if c.withState {
+			planned, err = plannedState(plan, priorState, schemas)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Failed to determine the planned state: %s", err))
+				return 1
+			}
+		}
 
-func (c *ShowCommand) Help() string {
-	helpText := `
-Usage: terraform show [options] [path]
+		c.showDiagnostics(targetingDiagnostics(plan))
 
-  Reads and outputs a Terraform state or plan file in a human-readable
-  form. If no path is specified, the current state will be shown.
+		if c.jsonOutput {
+			c.showDiagnostics(schemaVersionDiagnostics(c.filter.State(priorState), schemas))
 
-Options:
+			plugins := c.providerPluginSet()
+			var cachePath string
+			if c.jsonCacheDir != "" {
+				cachePath, err = c.jsonCachePath(planSum, plugins)
+				if err != nil {
+					c.Ui.Error(fmt.Sprintf("Failed to find the cached JSON plan: %s", err))
+					return 1
+				}
+				if cached := readJSONCache(cachePath); cached != nil {
+					log.Printf("[DEBUG] show: using the cached JSON plan in %s", cachePath)
+					return c.outputJSON(cached)
+				}
+			}
 
-  -no-color           If specified, output won't contain any color.
+			opts := &jsonplan.MarshalOpts{
+				NoSensitive: c.noSensitive,
+				NoValues:    c.noValues,
+				ChangesOnly: c.changesOnly,
+				WithConfig:  c.jsonConfig,
+				Filter:      c.filter,
+			}
+			jsonPlan, err := jsonplan.Marshal(config, plan, priorState, planned, schemas, plugins, opts)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Failed to marshal plan to json: %s", err))
+				return 1
+			}
+			if cachePath != "" {
+				// The cache only saves time, so failing to write it doesn't
+				// prevent the plan from being shown.
+				if err := writeJSONCache(cachePath, jsonPlan); err != nil {
+					c.showDiagnostics(tfdiags.Sourceless(
+						tfdiags.Warning,
+						"Failed to cache the JSON plan",
+						fmt.Sprintf("The JSON plan could not be written to the cache in %s: %s.", c.jsonCacheDir, err),
+					))
+				}
+			}
+			return c.outputJSON(jsonPlan)
+		}
 
-`
-	return strings.TrimSpace(helpText)
-}
+		dispPlan := format.NewPlan(plan.Changes, schemas)
+		dispPlan.ShowUnchanged = c.showAll
+		c.Ui.Output(dispPlan.Format(c.Colorize()))
+		if !dispPlan.Empty() {
+			c.Ui.Output("\n" + dispPlan.Summary(c.Colorize()))
+		}
+		if planned != nil {
+			c.Ui.Output("\n------------------------------------------------------------------------\n")
+			c.Ui.Output(c.Colorize().Color("[reset][bold]Planned state after apply:[reset]\n"))
+			return c.outputState(&format.StateOpts{
+				State:   planned,
+				Color:   c.Colorize(),
+				Schemas: schemas,
+				Summary: true,
+			})
+		}
+		return 0
+	}
 
-func (c *ShowCommand) Synopsis() string {
-	return "Inspect Terraform state or plan"
-}
+	if c.withState {
+		c.Ui.Error("The -with-state option can only be used when showing a plan, not a state.")
+		return 1
+	}
+	if c.changesOnly {
+		c.Ui.Error("The -json-changes-only option can only be used when showing a plan, not a state.")
+		return 1
+	}
+	if c.noValues {
+		c.Ui.Error("The -json-no-values option can only be used when showing a plan, not a state.")
+		return 1
+	}
+	if c.jsonConfig {
+		c.Ui.Error("The -json-config option can only be used when showing a plan, not a state.")
+		return 1
+	}
+	if c.priorStatePath != "" {
+		c.Ui.Error("The -state option can only be used when showing a plan, not a state.")
+		return 1
+	}
+	if c.showAll {
+		c.Ui.Error("The -show-all option can only be used when showing a plan, or with -diff, not a state.")
+		return 1
+	}
+
+	// The options below each narrow the state further, so the targets are
+	// checked again after each of them rather than rendering nothing.
+	targetsMissing := func() bool {
+		if c.filter != nil && !c.filter.State(state).HasResources() {
+			c.Ui.Error(fmt.Sprintf(errShowNoInstanceFound, showTargetsString(c.filter)))
+			return true
+		}
+		return false
+	}
+	if targetsMissing() {
+		return 1
+	}
+	if c.deposed != states.NotDeposed {
+		var available []string
+		state, available = deposedState(c.filter.State(state), c.deposed)
+		if state == nil {
+			msg := fmt.Sprintf("No deposed object %s found for %s.", c.deposed, showTargetsString(c.filter))
+			if len(available) > 0 {
+				msg += fmt.Sprintf(" The available deposed keys are: %s.", strings.Join(available, ", "))
+			} else {
+				msg += " It has no deposed objects."
+			}
+			c.Ui.Error(msg)
+			return 1
+		}
+	}
+	if c.module != "" {
+		state = moduleState(state, c.moduleAddr)
+		if state == nil {
+			c.Ui.Error(fmt.Sprintf("No resources found in module %s.", c.moduleAddr))
+			return 1
+		}
+		if targetsMissing() {
+			return 1
+		}
+	}
+	if len(c.types) > 0 {
+		state = resourceTypeState(state, c.types)
+		if targetsMissing() {
+			return 1
+		}
+
+		// Unlike the other filters, the types are often a guess at what
+		// might be in the state, so finding nothing isn't an error. JSON
+		// output is still produced so that scripts can process the empty
+		// result in the usual way.
+		if !c.filter.State(state).HasResources() && !c.jsonOutput && !c.jsonStream && !c.addresses {
+			c.Ui.Output(fmt.Sprintf("No resources of type %s found.", showResourceTypesString(c.types)))
+			return 0
+		}
+	}
+	if len(c.where) > 0 {
+		state = whereState(state, c.where)
+		if targetsMissing() {
+			return 1
+		}
+
+		// As with -type, finding nothing isn't an error.
+		if !c.filter.State(state).HasResources() && !c.jsonOutput && !c.jsonStream && !c.addresses {
+			c.Ui.Output(fmt.Sprintf("No resource instances found where %s.", showWhereString(c.where)))
+			return 0
+		}
+	}
+
+	if c.hclOutput {
+		c.Ui.Output(stateImportBlocks(c.filter.State(state)))
+		return 0
+	}
+	if c.addresses {
+		for _, addr := range stateAddresses(c.filter.State(state)) {
+			c.Ui.Output(addr)
+		}
+		return 0
+	}
+	if c.tableOutput {
+		c.Ui.Output(format.StateTable(&format.StateOpts{
+			State:   c.filter.State(state),
+			Color:   c.Colorize(),
+			Schemas: schemas,
+		}))
+		return 0
+	}
+	if c.jsonOutput {
+		return c.outputStateJSON(state, schemas)
+	}
+	if c.jsonStream {
+		return c.outputStateJSONStream(state, schemas)
+	}
+
+	// The JSON output is filtered as it is marshaled, but the human-readable
+	// output must be filtered here.
+	opts := &format.StateOpts{
+		State:   c.filter.State(state),
+		Color:   c.Colorize(),
+		Schemas: schemas,
+		Summary: c.filter == nil,
+		Brief:   c.brief,
+	}
+	if c.filter != nil && !c.brief {
+		// Only the resource blocks themselves are of interest for targeted
+		// instances, so the leading reset is trimmed as in "state show".
+		output := format.State(opts)
+		if i := strings.Index(output, "#"); i >= 0 {
+			output = output[i:]
+		}
+		c.Ui.Output(output)
+		return 0
+	}
+	return c.outputState(opts)
+}
+
+// outputState streams the human-readable rendering of a state to the Ui, so
+// that the rendering of a large state is never held in memory whole, and
+// returns the exit status.
+func (c *ShowCommand) outputState(opts *format.StateOpts) int {
+	w := &uiOutputWriter{Ui: c.Ui}
+	if err := format.StreamState(w, opts); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
+		return 1
+	}
+	w.Close()
+	return 0
+}
+
+// uiOutputWriter is an io.Writer that passes what is written to it to the
+// Output method of a Ui, holding back any partial line until Close. The
+// result is the same as a single call to Output with everything written.
+type uiOutputWriter struct {
+	Ui      cli.Ui
+	partial []byte
+}
+
+func (w *uiOutputWriter) Write(p []byte) (int, error) {
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		w.Ui.Output(string(w.partial) + string(p[:i]))
+		w.partial = append(w.partial[:0], p[i+1:]...)
+	} else {
+		w.partial = append(w.partial, p...)
+	}
+	return len(p), nil
+}
+
+func (w *uiOutputWriter) Close() error {
+	w.Ui.Output(string(w.partial))
+	w.partial = nil
+	return nil
+}
+
+// readPriorState reads the state file given by the -state option.
+func (c *ShowCommand) readPriorState() (*states.State, error) {
+	f, err := os.Open(c.priorStatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stateFile, err := statefile.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	return stateFile.State, nil
+}
+
+// showMultiple outputs each of the plan or state files at the given paths in
+// turn, and returns the exit status. It stops at the first file that can't
+// be shown, returning its exit status.
+//
+// The human-readable output for each file is preceded by a header naming the
+// file. The JSON output is instead a JSON array with the document for each
+// file, in the same order as the paths. Newline-delimited JSON output needs
+// no header because the header line of each file's output marks where it
+// starts.
+func (c *ShowCommand) showMultiple(paths []string) int {
+	if !c.jsonOutput {
+		for i, path := range paths {
+			if !c.check && !c.jsonStream {
+				if i > 0 {
+					c.Ui.Output("")
+				}
+				c.Ui.Output(fmt.Sprintf("==> %s <==", path))
+			}
+			if code := c.show(path); code != 0 {
+				return code
+			}
+		}
+		return 0
+	}
+
+	// Each document is captured compactly so that the array can be indented
+	// and colorized as a whole if -json-pretty is set.
+	ui, pretty, stats := c.Ui, c.jsonPretty, c.jsonStats
+	defer func() {
+		c.Ui, c.jsonPretty, c.jsonStats = ui, pretty, stats
+	}()
+	c.jsonPretty, c.jsonStats = false, false
+
+	docs := make([]json.RawMessage, 0, len(paths))
+	for _, path := range paths {
+		var buf bytes.Buffer
+		c.Ui = &showCaptureUi{Ui: ui, output: &buf}
+		if code := c.show(path); code != 0 {
+			return code
+		}
+		docs = append(docs, json.RawMessage(bytes.TrimSpace(buf.Bytes())))
+	}
+
+	c.Ui, c.jsonPretty, c.jsonStats = ui, pretty, stats
+	src, err := json.Marshal(docs)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to marshal json: %s", err))
+		return 1
+	}
+	return c.outputJSON(src)
+}
+
+// showCaptureUi is a cli.Ui that captures the normal output in a buffer and
+// passes everything else through to the wrapped Ui.
+type showCaptureUi struct {
+	cli.Ui
+	output *bytes.Buffer
+}
+
+func (u *showCaptureUi) Output(msg string) {
+	u.output.WriteString(msg)
+	u.output.WriteString("\n")
+}
+
+// plannedState returns the state that is expected to result from applying
+// the given plan to the given prior state, which may be nil. Values that
+// will not be known until after apply are set to null.
+func plannedState(plan *plans.Plan, prior *states.State, schemas *terraform.Schemas) (*states.State, error) {
+	ret := prior.DeepCopy()
+	if ret == nil {
+		ret = states.NewState()
+	}
+	if plan.Changes == nil {
+		return ret, nil
+	}
+
+	for _, rc := range plan.Changes.Resources {
+		addr := rc.Addr
+		ms := ret.EnsureModule(addr.Module)
+
+		switch {
+		case rc.Action == plans.NoOp:
+			continue
+		case rc.DeposedKey != states.NotDeposed:
+			// Deposed objects are only ever destroyed.
+			ms.ForgetResourceInstanceDeposed(addr.Resource, rc.DeposedKey)
+			continue
+		case rc.Action == plans.Delete:
+			ms.SetResourceInstanceCurrent(addr.Resource, nil, rc.ProviderAddr)
+			continue
+		}
+
+		providerType := rc.ProviderAddr.ProviderConfig.Type
+		ps := schemas.ProviderSchema(providerType)
+		if ps == nil {
+			return nil, fmt.Errorf("no schema found for provider %s", providerType)
+		}
+		schema := ps.SchemaForResourceAddr(addr.Resource.Resource)
+		if schema == nil {
+			return nil, fmt.Errorf("no schema found for %s (in provider %s)", addr, providerType)
+		}
+		ty := schema.ImpliedType()
+		change, err := rc.Decode(ty)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode change for %s: %s", addr, err)
+		}
+
+		obj := &states.ResourceInstanceObject{
+			Value:   change.After,
+			Private: rc.Private,
+			Status:  states.ObjectReady,
+		}
+		if ri := ret.ResourceInstance(addr); ri != nil && ri.Current != nil {
+			obj.Dependencies = ri.Current.Dependencies
+		}
+		src, err := obj.Encode(ty, ps.SchemaVersionForResourceAddr(addr.Resource.Resource))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode planned object for %s: %s", addr, err)
+		}
+		ms.SetResourceInstanceCurrent(addr.Resource, src, rc.ProviderAddr)
+	}
+
+	for _, oc := range plan.Changes.Outputs {
+		if !oc.Addr.Module.IsRoot() {
+			continue
+		}
+		ms := ret.EnsureModule(oc.Addr.Module)
+		if oc.Action == plans.Delete {
+			ms.RemoveOutputValue(oc.Addr.OutputValue.Name)
+			continue
+		}
+		change, err := oc.Decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode change for %s: %s", oc.Addr, err)
+		}
+		ms.SetOutputValue(oc.Addr.OutputValue.Name, cty.UnknownAsNull(change.After), oc.Sensitive)
+	}
+
+	return ret, nil
+}
+
+// unsupportedVersionMessage returns a message explaining how to read a file
+// that could not be read because it was created by a different version of
+// Terraform, if the given error reports that, or an empty string otherwise.
+func unsupportedVersionMessage(err error) string {
+	switch err := err.(type) {
+	case *planfile.UnsupportedVersionError:
+		if err.TerraformVersion == "" || err.TerraformVersion == tfversion.String() {
+			return fmt.Sprintf(
+				"The plan file uses format version %d, which is not supported by Terraform v%s.\n\n"+
+					"Plan files can only be read by the version of Terraform that created them.",
+				err.FormatVersion, tfversion.String())
+		}
+		remedy := fmt.Sprintf("use Terraform v%s", err.TerraformVersion)
+		if v, vErr := version.NewVersion(err.TerraformVersion); vErr == nil && v.GreaterThan(tfversion.SemVer) {
+			remedy = fmt.Sprintf("upgrade Terraform to v%s", err.TerraformVersion)
+		}
+		return fmt.Sprintf(
+			"The plan file was created by Terraform v%s, but this is Terraform v%s.\n\n"+
+				"Plan files can only be read by the version of Terraform that created them.\n"+
+				"To read this file, %s.",
+			err.TerraformVersion, tfversion.String(), remedy)
+	case *statefile.UnsupportedVersionError:
+		if err.TerraformVersion == "" {
+			return fmt.Sprintf(
+				"The state file uses format version %d, which is not supported by Terraform v%s.\n"+
+					"It was probably created by a newer version of Terraform.\n\n"+
+					"To read this file, upgrade Terraform to the version that created it.",
+				err.FormatVersion, tfversion.String())
+		}
+		return fmt.Sprintf(
+			"The state file was created by Terraform v%s, which is newer than this\n"+
+				"version of Terraform (v%s).\n\n"+
+				"To read this file, upgrade Terraform to at least v%s.",
+			err.TerraformVersion, tfversion.String(), err.TerraformVersion)
+	default:
+		return ""
+	}
+}
+
+// LoadPlanOrState reads the plan or state file at the given path, in the
+// same way as the show command. The file may be gzip-compressed.
+//
+// If the file is a plan file then the plan is returned along with the prior
+// state that it was created against, if the plan file includes one. If it
+// is a state file then the plan is nil. If it is neither then the error is
+// a *PlanOrStateError.
+func LoadPlanOrState(path string) (*plans.Plan, *states.State, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error loading file: %s", err)
+	}
+	_, plan, state, err := readPlanOrState(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plan, state, nil
+}
+
+// PlanOrStateError is the error returned when a file can be read as neither
+// a plan file nor a state file.
+type PlanOrStateError struct {
+	// PlanErr and StateErr are the errors from reading the file as each
+	// format. StateErr is nil if the file is a plan file that is itself
+	// invalid, since it isn't then read as a state file.
+	PlanErr, StateErr error
+}
+
+func (e *PlanOrStateError) Error() string {
+	return fmt.Sprintf(
+		"Terraform couldn't read the given file as a state or plan file.\n"+
+			"The errors while attempting to read the file as each format are\n"+
+			"shown below.\n\n"+
+			"State read error: %s\n\nPlan read error: %s",
+		e.StateErr,
+		e.PlanErr)
+}
+
+// readPlanOrState is the implementation of LoadPlanOrState for a file that
+// has already been read into memory, which also returns the plan file
+// reader if the file is a plan file so that the caller can read the
+// configuration snapshot within it.
+func readPlanOrState(src []byte) (*planfile.Reader, *plans.Plan, *states.State, error) {
+	// Plan and state files may be archived with gzip compression, so
+	// a compressed file is transparently decompressed first.
+	if bytes.HasPrefix(src, gzipMagic) {
+		var err error
+		src, err = gunzip(src)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("The file appears gzip-compressed but could not be decompressed: %s", err)
+		}
+	}
+
+	pr, err := planfile.NewReader(bytes.NewReader(src), int64(len(src)))
+	if err != nil {
+		planErr := err
+		stateFile, err := statefile.Read(bytes.NewReader(src))
+		if err != nil {
+			return nil, nil, nil, &PlanOrStateError{PlanErr: planErr, StateErr: err}
+		}
+		return nil, nil, stateFile.State, nil
+	}
+
+	plan, err := pr.ReadPlan()
+	if err != nil {
+		return nil, nil, nil, &PlanOrStateError{PlanErr: err}
+	}
+
+	// The plan file also carries a snapshot of the prior state that the
+	// plan was created against, which the JSON output uses to describe
+	// objects the plan doesn't otherwise fully capture.
+	var priorState *states.State
+	stateFile, err := pr.ReadStateFile()
+	switch {
+	case err == nil:
+		priorState = stateFile.State
+	case err != statefile.ErrNoState:
+		return nil, nil, nil, &PlanOrStateError{PlanErr: err}
+	}
+
+	return pr, plan, priorState, nil
+}
+
+// showTargetsString returns the target addresses of the given filter as a
+// list for use in messages.
+func showTargetsString(filter *addrfilter.Filter) string {
+	targets := filter.Targets()
+	strs := make([]string, len(targets))
+	for i, target := range targets {
+		strs[i] = target.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+// outputStateJSON writes the JSON representation of the resources of the
+// given state that are selected by -target, which may be nil if there is no
+// state at all, and returns the exit status.
+func (c *ShowCommand) outputStateJSON(state *states.State, schemas *terraform.Schemas) int {
+	c.showDiagnostics(schemaVersionDiagnostics(c.filter.State(state), schemas))
+
+	jsonState, err := jsonstate.Marshal(state, schemas, &jsonstate.MarshalOpts{NoSensitive: c.noSensitive, Filter: c.filter})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to marshal state to json: %s", err))
+		return 1
+	}
+	return c.outputJSON(jsonState)
+}
+
+// outputStateJSONStream writes the newline-delimited JSON representation of
+// the resources of the given state that are selected by -target, which may
+// be nil if there is no state at all, and returns the exit status.
+func (c *ShowCommand) outputStateJSONStream(state *states.State, schemas *terraform.Schemas) int {
+	c.showDiagnostics(schemaVersionDiagnostics(c.filter.State(state), schemas))
+
+	err := jsonstate.MarshalStream(&cli.UiWriter{Ui: c.Ui}, state, schemas, &jsonstate.MarshalOpts{NoSensitive: c.noSensitive, Filter: c.filter})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to marshal state to json: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// outputJSON writes the given JSON document, indenting and colorizing it if
+// -json-pretty was set, and returns the exit status. If -json-stats was set
+// then a summary of the document follows on stderr, so that it can't be
+// mistaken for part of the document.
+func (c *ShowCommand) outputJSON(src []byte) int {
+	if c.jsonUi != nil && len(c.jsonUi.diags) > 0 && bytes.HasPrefix(src, []byte("{")) {
+		var err error
+		src, err = appendJSONDiagnostics(src, c.jsonUi.diags)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal diagnostics to json: %s", err))
+			return 1
+		}
+		c.jsonUi.diags = nil
+	}
+
+	out := string(src)
+	if c.jsonPretty {
+		var err error
+		out, err = format.JSON(src, c.Colorize())
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to format json: %s", err))
+			return 1
+		}
+	}
+	c.Ui.Output(out)
+
+	if c.jsonStats {
+		ui := c.Ui
+		if c.jsonUi != nil {
+			// The summary belongs on stderr rather than among the
+			// diagnostics, which describe the document it summarizes.
+			ui = c.jsonUi.Ui
+		}
+		ui.Warn(jsonStatsSummary(src, len(out)))
+	}
+	return 0
+}
+
+// planAgeDiagnostics returns a warning if the given plan was created longer
+// than maxAge before now, or nothing if it wasn't or its creation time isn't
+// known.
+func planAgeDiagnostics(plan *plans.Plan, maxAge time.Duration, now time.Time) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if plan.Timestamp.IsZero() {
+		return diags
+	}
+	age := now.Sub(plan.Timestamp)
+	if age <= maxAge {
+		return diags
+	}
+	return diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"The plan may be stale",
+		fmt.Sprintf(
+			"The plan was created at %s, %s ago, which is longer ago than the maximum age of %s. "+
+				"The infrastructure or the configuration may have changed since then, so applying the plan "+
+				"may not have the intended effect. Consider creating a new plan instead.",
+			plan.Timestamp.UTC().Format(time.RFC3339), age.Round(time.Second), maxAge,
+		),
+	))
+}
+
+// showJSONUi is the Ui used while showing with -json. Errors and warnings
+// written as text would corrupt the JSON for a consumer that captures
+// stderr along with stdout, so they are instead collected as diagnostics to
+// be included in the JSON document, after which they are removed. If there
+// is no document, because of an error, they make up a document of their
+// own, as in {"diagnostics": [...]}.
+type showJSONUi struct {
+	cli.Ui
+	diags tfdiags.Diagnostics
+}
+
+func (u *showJSONUi) Error(msg string) {
+	u.diags = u.diags.Append(textDiagnostic(tfdiags.Error, msg))
+}
+
+func (u *showJSONUi) Warn(msg string) {
+	u.diags = u.diags.Append(textDiagnostic(tfdiags.Warning, msg))
+}
+
+// textDiagnostic returns a diagnostic of the given severity for a message
+// written as text, taking its first line as the summary and the rest as the
+// detail.
+func textDiagnostic(severity tfdiags.Severity, msg string) tfdiags.Diagnostic {
+	msg = strings.TrimSpace(msg)
+	summary, detail := msg, ""
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		summary, detail = msg[:i], strings.TrimSpace(msg[i+1:])
+	}
+	return tfdiags.Sourceless(severity, summary, detail)
+}
+
+// showDiagnostics is like Meta.showDiagnostics, except that while showing
+// with -json the diagnostics are collected to be included in the JSON
+// output instead.
+//
+// With -only-errors, warnings are left out. They are only ever shown, so
+// leaving them out doesn't change the exit status.
+func (c *ShowCommand) showDiagnostics(vals ...interface{}) {
+	if c.onlyErrors {
+		var diags, errs tfdiags.Diagnostics
+		diags = diags.Append(vals...)
+		for _, diag := range diags {
+			if diag.Severity() == tfdiags.Error {
+				errs = append(errs, diag)
+			}
+		}
+		if len(errs) == 0 {
+			return
+		}
+		vals = []interface{}{errs}
+	}
+	if c.jsonUi == nil {
+		c.Meta.showDiagnostics(vals...)
+		return
+	}
+	c.jsonUi.diags = c.jsonUi.diags.Append(vals...)
+}
+
+// jsonDiagnostic is the representation of a diagnostic in the JSON output.
+type jsonDiagnostic struct {
+	// Severity is either "error" or "warning".
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+
+	// StateError and PlanError are set only for the error that a file
+	// couldn't be read as either a state or a plan, to the errors from
+	// reading it as each, so that a wrapper needn't parse them out of the
+	// detail. StateError is omitted if the file wasn't read as a state, as
+	// described for PlanOrStateError.
+	StateError string `json:"state_error,omitempty"`
+	PlanError  string `json:"plan_error,omitempty"`
+}
+
+// planOrStateDiagnostic is the diagnostic for a PlanOrStateError, which is
+// described in the same way as the text error.
+type planOrStateDiagnostic struct {
+	err *PlanOrStateError
+}
+
+func (d *planOrStateDiagnostic) Severity() tfdiags.Severity {
+	return tfdiags.Error
+}
+
+func (d *planOrStateDiagnostic) Description() tfdiags.Description {
+	return textDiagnostic(tfdiags.Error, d.err.Error()).Description()
+}
+
+func (d *planOrStateDiagnostic) Source() tfdiags.Source {
+	return tfdiags.Source{}
+}
+
+func (d *planOrStateDiagnostic) FromExpr() *tfdiags.FromExpr {
+	return nil
+}
+
+// appendJSONDiagnostics returns the given JSON object with a "diagnostics"
+// property added, listing the given diagnostics.
+func appendJSONDiagnostics(src []byte, diags tfdiags.Diagnostics) ([]byte, error) {
+	jsonDiags := make([]jsonDiagnostic, len(diags))
+	for i, diag := range diags {
+		desc := diag.Description()
+		jsonDiags[i] = jsonDiagnostic{
+			Severity: "warning",
+			Summary:  desc.Summary,
+			Detail:   desc.Detail,
+		}
+		if diag.Severity() == tfdiags.Error {
+			jsonDiags[i].Severity = "error"
+		}
+		if diag, ok := diag.(*planOrStateDiagnostic); ok {
+			if diag.err.StateErr != nil {
+				jsonDiags[i].StateError = diag.err.StateErr.Error()
+			}
+			if diag.err.PlanErr != nil {
+				jsonDiags[i].PlanError = diag.err.PlanErr.Error()
+			}
+		}
+	}
+	diagsSrc, err := json.Marshal(jsonDiags)
+	if err != nil {
+		return nil, err
+	}
+
+	// The property is spliced in rather than added by decoding and
+	// re-encoding the object, which would sort its other properties.
+	src = bytes.TrimSpace(src)
+	body := bytes.TrimSpace(src[1 : len(src)-1])
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if len(body) > 0 {
+		buf.Write(body)
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"diagnostics":`)
+	buf.Write(diagsSrc)
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonStatsSummary returns a line summarizing the given JSON plan or state
+// document, or array of such documents, of which size bytes were written.
+func jsonStatsSummary(src []byte, size int) string {
+	type doc struct {
+		PlannedValues   json.RawMessage            `json:"planned_values"`
+		ResourceChanges []json.RawMessage          `json:"resource_changes"`
+		OutputChanges   map[string]json.RawMessage `json:"output_changes"`
+		Values          struct {
+			RootModule *jsonStatsModule `json:"root_module"`
+		} `json:"values"`
+	}
+	var docs []doc
+	if err := json.Unmarshal(src, &docs); err != nil {
+		// The document isn't an array, so it must be a single document.
+		docs = make([]doc, 1)
+		if err := json.Unmarshal(src, &docs[0]); err != nil {
+			// We produced this document ourselves, so this should never
+			// happen.
+			return fmt.Sprintf("JSON output: %s", formatByteSize(size))
+		}
+	}
+
+	var plans, resourceChanges, outputChanges, resources int
+	for _, d := range docs {
+		if d.PlannedValues != nil {
+			plans++
+			resourceChanges += len(d.ResourceChanges)
+			outputChanges += len(d.OutputChanges)
+			continue
+		}
+		resources += d.Values.RootModule.count()
+	}
+
+	var parts []string
+	if plans > 0 {
+		parts = append(parts,
+			format.Pluralize(resourceChanges, "resource change"),
+			format.Pluralize(outputChanges, "output change"),
+		)
+	}
+	if plans < len(docs) {
+		parts = append(parts, format.Pluralize(resources, "resource"))
+	}
+	parts = append(parts, formatByteSize(size))
+	return "JSON output: " + strings.Join(parts, ", ")
+}
+
+// jsonStatsModule is the part of a module in a JSON state document that is
+// needed to count the resources within it.
+type jsonStatsModule struct {
+	Resources    []json.RawMessage `json:"resources"`
+	ChildModules []jsonStatsModule `json:"child_modules"`
+}
+
+func (m *jsonStatsModule) count() int {
+	if m == nil {
+		return 0
+	}
+	ret := len(m.Resources)
+	for i := range m.ChildModules {
+		ret += m.ChildModules[i].count()
+	}
+	return ret
+}
+
+// formatByteSize returns the given number of bytes in a human-readable form,
+// such as "3.2 MiB", using binary multiples.
+func formatByteSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return format.Pluralize(n, "byte")
+	}
+	size := float64(n) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if size < unit {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f TiB", size)
+}
+
+// schemaVersionDiagnostics returns a warning listing the resource instance
+// objects in the given state, which may be nil, whose recorded schema
+// version is newer than the current schema version of the installed
+// provider. Such objects were written by a newer version of the provider,
+// such as when a plan is applied after downgrading it.
+func schemaVersionDiagnostics(state *states.State, schemas *terraform.Schemas) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if state == nil {
+		return diags
+	}
+
+	var lines []string
+	for _, ms := range state.Modules {
+		for _, rs := range ms.Resources {
+			ps := schemas.ProviderSchema(rs.ProviderConfig.ProviderConfig.Type)
+			if ps == nil {
+				continue
+			}
+			current := ps.SchemaVersionForResourceAddr(rs.Addr)
+			for key, is := range rs.Instances {
+				addr := rs.Addr.Instance(key).Absolute(ms.Addr)
+				if is.Current != nil && is.Current.SchemaVersion > current {
+					lines = append(lines, fmt.Sprintf(
+						"  - %s (schema version %d, provider supports %d)",
+						addr, is.Current.SchemaVersion, current,
+					))
+				}
+				for dk, obj := range is.Deposed {
+					if obj.SchemaVersion > current {
+						lines = append(lines, fmt.Sprintf(
+							"  - %s, deposed object %s (schema version %d, provider supports %d)",
+							addr, dk, obj.SchemaVersion, current,
+						))
+					}
+				}
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return diags
+	}
+	sort.Strings(lines)
+
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Objects recorded by a newer provider",
+		fmt.Sprintf(
+			"The following resource instance objects were recorded with a newer schema version than the installed provider supports:\n\n%s\n\n"+
+				"This usually means that they were written by a newer version of the provider than the one now installed. Their values are shown as recorded, and may not conform to the schema of the installed provider.",
+			strings.Join(lines, "\n"),
+		),
+	))
+	return diags
+}
+
+// targetingDiagnostics returns a warning that the given plan is partial if it
+// was created with -target, listing the target addresses, so that it isn't
+// mistaken for a plan of the whole configuration.
+func targetingDiagnostics(plan *plans.Plan) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if len(plan.TargetAddrs) == 0 {
+		return diags
+	}
+
+	lines := make([]string, len(plan.TargetAddrs))
+	for i, target := range plan.TargetAddrs {
+		lines[i] = "  - " + target.String()
+	}
+	sort.Strings(lines)
+
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Resource targeting is in effect",
+		fmt.Sprintf(
+			"This plan was created with the -target option, for only the following objects and whatever they depend on:\n\n%s\n\n"+
+				"It is partial: applying it makes none of the changes the configuration calls for to any other object, so it may not show everything that a full plan would.",
+			strings.Join(lines, "\n"),
+		),
+	))
+	return diags
+}
+
+// moduleState returns a new state containing only the modules of the given
+// state that are either the given module instance or one of its descendents,
+// or nil if those modules have no resources.
+//
+// A step of the given address without an instance key matches all of the
+// instances of that module call, so that "module.foo" matches the
+// resources in both "module.foo[0]" and "module.foo[1]".
+func moduleState(state *states.State, addr addrs.ModuleInstance) *states.State {
+	ret := states.NewState()
+	for _, ms := range state.Modules {
+		if !moduleContains(addr, ms.Addr) {
+			continue
+		}
+		ret.Modules[ms.Addr.String()] = ms
+	}
+	if !ret.HasResources() {
+		return nil
+	}
+	return ret
+}
+
+// moduleContains returns true if the given other address is either the
+// given module instance or a descendent of it, treating any step of the
+// module address that has no instance key as matching all instances.
+func moduleContains(m, other addrs.ModuleInstance) bool {
+	if len(other) < len(m) {
+		return false
+	}
+	for i, step := range m {
+		if step.Name != other[i].Name {
+			return false
+		}
+		if step.InstanceKey != addrs.NoKey && step.InstanceKey != other[i].InstanceKey {
+			return false
+		}
+	}
+	return true
+}
+
+// showResourceType is a resource type given with the -type option, along
+// with whether it refers to managed resources or to data sources.
+type showResourceType struct {
+	Mode addrs.ResourceMode
+	Type string
+}
+
+func (t showResourceType) String() string {
+	if t.Mode == addrs.DataResourceMode {
+		return "data." + t.Type
+	}
+	return t.Type
+}
+
+// showWhere is a condition given with the -where option, which matches the
+// resource instances whose top-level attribute of the given name has the
+// given value.
+type showWhere struct {
+	Name, Value string
+}
+
+func (w showWhere) String() string {
+	return fmt.Sprintf("%s = %q", w.Name, w.Value)
+}
+
+// parseShowWhere parses the value of a -where option, such as
+// "instance_type=t3.micro", and returns false if the value is not valid.
+// Everything after the first equals sign is the value, which may be empty.
+func parseShowWhere(raw string) (showWhere, bool) {
+	i := strings.IndexByte(raw, '=')
+	if i < 0 {
+		return showWhere{}, false
+	}
+	w := showWhere{
+		Name:  strings.TrimSpace(raw[:i]),
+		Value: raw[i+1:],
+	}
+	return w, hclsyntax.ValidIdentifier(w.Name)
+}
+
+// showWhereString returns the given conditions as a list for use in
+// messages, such as `ami = "foo" and id = "bar"`.
+func showWhereString(where []showWhere) string {
+	strs := make([]string, len(where))
+	for i, w := range where {
+		strs[i] = w.String()
+	}
+	return strings.Join(strs, " and ")
+}
+
+// whereState returns a new state containing only the resource instances of
+// the given state whose current objects match all of the given conditions,
+// in the same modules, along with any deposed objects they have. The output
+// values are not included, since they aren't resources.
+func whereState(state *states.State, where []showWhere) *states.State {
+	ret := states.NewState()
+	for _, ms := range state.Modules {
+		for key, rs := range ms.Resources {
+			var instances map[addrs.InstanceKey]*states.ResourceInstance
+			for k, is := range rs.Instances {
+				if is.Current == nil || !objectMatchesWhere(is.Current, where) {
+					continue
+				}
+				if instances == nil {
+					instances = make(map[addrs.InstanceKey]*states.ResourceInstance)
+				}
+				instances[k] = is
+			}
+			if instances == nil {
+				continue
+			}
+			ret.EnsureModule(ms.Addr).Resources[key] = &states.Resource{
+				Addr:           rs.Addr,
+				EachMode:       rs.EachMode,
+				Instances:      instances,
+				ProviderConfig: rs.ProviderConfig,
+			}
+		}
+	}
+	return ret
+}
+
+// deposedState returns a new state containing only the deposed objects of
+// the given state with the given key, without their current objects, or nil
+// and the sorted keys of the deposed objects there are if there are none
+// with the given key. Deposed keys are chosen at random, so in practice
+// there is at most one such object, but each is kept if there are several.
+func deposedState(state *states.State, key states.DeposedKey) (*states.State, []string) {
+	var ret *states.State
+	var available []string
+	for _, ms := range state.Modules {
+		for rKey, rs := range ms.Resources {
+			for k, is := range rs.Instances {
+				obj, ok := is.Deposed[key]
+				if !ok {
+					for dk := range is.Deposed {
+						available = append(available, string(dk))
+					}
+					continue
+				}
+				if ret == nil {
+					ret = states.NewState()
+				}
+				retRs := ret.EnsureModule(ms.Addr).Resources[rKey]
+				if retRs == nil {
+					retRs = &states.Resource{
+						Addr:           rs.Addr,
+						EachMode:       rs.EachMode,
+						Instances:      make(map[addrs.InstanceKey]*states.ResourceInstance),
+						ProviderConfig: rs.ProviderConfig,
+					}
+					ret.EnsureModule(ms.Addr).Resources[rKey] = retRs
+				}
+				retRs.Instances[k] = &states.ResourceInstance{
+					Deposed: map[states.DeposedKey]*states.ResourceInstanceObjectSrc{
+						key: obj,
+					},
+				}
+			}
+		}
+	}
+	if ret == nil {
+		sort.Strings(available)
+		return nil, available
+	}
+	return ret, nil
+}
+
+// stateImportBlocks returns HCL import blocks for the current objects of the
+// managed resource instances of the given state, for -hcl, in order of their
+// addresses. Each imports the object with its "id" attribute to the address
+// of its instance, as a starting point for configuration that manages the
+// same objects, such as in another working directory. An instance without
+// an id gets a comment in place of an import block.
+func stateImportBlocks(state *states.State) string {
+	type instance struct {
+		addr addrs.AbsResourceInstance
+		obj  *states.ResourceInstanceObjectSrc
+	}
+	var instances []instance
+	if state != nil {
+		for _, ms := range state.Modules {
+			for _, rs := range ms.Resources {
+				if rs.Addr.Mode != addrs.ManagedResourceMode {
+					continue
+				}
+				for k, is := range rs.Instances {
+					if is.Current == nil {
+						continue
+					}
+					instances = append(instances, instance{rs.Addr.Instance(k).Absolute(ms.Addr), is.Current})
+				}
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return "# No managed resource instances to import."
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instanceAddrLess(instances[i].addr, instances[j].addr)
+	})
+
+	var buf bytes.Buffer
+	for i, inst := range instances {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		id, ok := objectID(inst.obj)
+		if !ok {
+			fmt.Fprintf(&buf, "# %s has no id to import it with.\n", inst.addr)
+			continue
+		}
+		fmt.Fprintf(&buf, "import {\n  to = %s\n  id = %s\n}\n", inst.addr, hclwrite.TokensForValue(cty.StringVal(id)).Bytes())
+	}
+	return strings.TrimSpace(string(hclwrite.Format(buf.Bytes())))
+}
+
+// stateAddresses returns the absolute address of each resource instance in
+// the given state, for -addresses, in order as for instanceAddrLess.
+func stateAddresses(state *states.State) []string {
+	var instances []addrs.AbsResourceInstance
+	if state != nil {
+		for _, ms := range state.Modules {
+			for _, rs := range ms.Resources {
+				for k := range rs.Instances {
+					instances = append(instances, rs.Addr.Instance(k).Absolute(ms.Addr))
+				}
+			}
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instanceAddrLess(instances[i], instances[j])
+	})
+
+	ret := make([]string, len(instances))
+	for i, addr := range instances {
+		ret[i] = addr.String()
+	}
+	return ret
+}
+
+// instanceAddrLess returns true if the resource instance address a sorts
+// before b, ordering by module and then by resource, and then by instance
+// key so that numeric keys are in numeric order.
+func instanceAddrLess(a, b addrs.AbsResourceInstance) bool {
+	if a.Module.String() != b.Module.String() {
+		return a.Module.String() < b.Module.String()
+	}
+	if a.Resource.Resource.String() != b.Resource.Resource.String() {
+		return a.Resource.Resource.String() < b.Resource.Resource.String()
+	}
+	return addrs.InstanceKeyLess(a.Resource.Key, b.Resource.Key)
+}
+
+// objectID returns the value of the "id" attribute of the given object, or
+// false if it has no id, or if the id is null, empty or not a string.
+func objectID(obj *states.ResourceInstanceObjectSrc) (string, bool) {
+	if obj.AttrsJSON == nil {
+		id := obj.AttrsFlat["id"]
+		return id, id != ""
+	}
+	var attrs struct {
+		ID interface{} `json:"id"`
+	}
+	if err := json.Unmarshal(obj.AttrsJSON, &attrs); err != nil {
+		return "", false
+	}
+	id, ok := attrs.ID.(string)
+	return id, ok && id != ""
+}
+
+// objectMatchesWhere returns true if each of the given conditions names a
+// top-level attribute of the given object whose value is the condition's
+// value. Values are compared as strings, so a number or bool attribute
+// matches its usual string form, such as "8080" or "true". An attribute
+// that is absent or null, or that has a collection or object value,
+// matches nothing.
+func objectMatchesWhere(obj *states.ResourceInstanceObjectSrc, where []showWhere) bool {
+	var attrs map[string]interface{}
+	if obj.AttrsJSON != nil {
+		dec := json.NewDecoder(bytes.NewReader(obj.AttrsJSON))
+		dec.UseNumber()
+		if err := dec.Decode(&attrs); err != nil {
+			return false
+		}
+	}
+
+	for _, w := range where {
+		var got string
+		if attrs != nil {
+			switch v := attrs[w.Name].(type) {
+			case string:
+				got = v
+			case json.Number:
+				got = v.String()
+			case bool:
+				got = strconv.FormatBool(v)
+			default:
+				return false
+			}
+		} else {
+			// A legacy flatmap has only string values, and its nested
+			// values have keys containing dots, which never match a
+			// top-level name.
+			v, ok := obj.AttrsFlat[w.Name]
+			if !ok {
+				return false
+			}
+			got = v
+		}
+		if got != w.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// parseShowResourceType parses the value of a -type option, which is a
+// resource type name with a "data." prefix if it refers to data sources,
+// and returns false if the value is not valid.
+func parseShowResourceType(raw string) (showResourceType, bool) {
+	t := showResourceType{
+		Mode: addrs.ManagedResourceMode,
+		Type: raw,
+	}
+	if strings.HasPrefix(raw, "data.") {
+		t.Mode = addrs.DataResourceMode
+		t.Type = strings.TrimPrefix(raw, "data.")
+	}
+	return t, hclsyntax.ValidIdentifier(t.Type)
+}
+
+// showResourceTypesString returns the given resource types as a list for
+// use in messages, such as "aws_iam_role or data.aws_iam_policy".
+func showResourceTypesString(types []showResourceType) string {
+	strs := make([]string, len(types))
+	for i, t := range types {
+		strs[i] = t.String()
+	}
+	if len(strs) == 1 {
+		return strs[0]
+	}
+	return strings.Join(strs[:len(strs)-1], ", ") + " or " + strs[len(strs)-1]
+}
+
+// resourceTypeState returns a new state containing only the resources of
+// the given state that match any of the given types, in the same modules.
+// The output values are not included, since they aren't resources.
+func resourceTypeState(state *states.State, types []showResourceType) *states.State {
+	ret := states.NewState()
+	for _, ms := range state.Modules {
+		for key, rs := range ms.Resources {
+			for _, t := range types {
+				if rs.Addr.Mode == t.Mode && rs.Addr.Type == t.Type {
+					ret.EnsureModule(ms.Addr).Resources[key] = rs
+					break
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// backendSchemas loads the configured backend and returns it along with the
+// schemas for the providers required by the configuration in the current
+// working directory and by the backend's current state.
+func (c *ShowCommand) backendSchemas() (backend.Backend, *terraform.Schemas, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	// Load the backend
+	b, backendDiags := c.Backend(nil)
+	diags = diags.Append(backendDiags)
+	if backendDiags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	// We require a local backend
+	local, ok := b.(backend.Local)
+	if !ok {
+		diags = diags.Append(fmt.Errorf(ErrUnsupportedLocalOp))
+		return nil, nil, diags
+	}
+
+	// the show command expects the config dir to always be the cwd
+	cwd, err := os.Getwd()
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("Error getting cwd: %s", err))
+		return nil, nil, diags
+	}
+
+	// Build the operation
+	opReq := c.Operation(b)
+	opReq.ConfigDir = cwd
+	opReq.ConfigLoader, err = c.initConfigLoader()
+	if err != nil {
+		diags = diags.Append(err)
+		return nil, nil, diags
+	}
+
+	// Get the context
+	ctx, _, ctxDiags := local.Context(opReq)
+	diags = diags.Append(ctxDiags)
+	if ctxDiags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	return b, ctx.Schemas(), diags
+}
+
+// planFileSchemas returns the configuration snapshot embedded in the given
+// plan file along with the schemas for the providers required by that
+// configuration and the plan's prior state, without consulting the backend
+// or the current working directory.
+func (c *ShowCommand) planFileSchemas(pr *planfile.Reader, plan *plans.Plan, priorState *states.State) (*configs.Config, *terraform.Schemas, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	config, configDiags := pr.ReadConfig()
+	diags = diags.Append(configDiags)
+	if configDiags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	opts := c.contextOpts()
+	opts.Config = config
+	opts.State = priorState
+	opts.Changes = plan.Changes
+	opts.Targets = plan.TargetAddrs
+	if plan.ProviderSHA256s != nil {
+		// Use the provider plugins the plan was created with, in the same
+		// way as when the plan is applied.
+		opts.ProviderSHA256s = plan.ProviderSHA256s
+	}
+
+	ctx, ctxDiags := terraform.NewContext(opts)
+	diags = diags.Append(ctxDiags)
+	if ctxDiags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	return config, ctx.Schemas(), diags
+}
+
+func (c *ShowCommand) Help() string {
+	helpText := `
+Usage: terraform show [options] [path ...]
+
+  Reads and outputs a Terraform state or plan file in a human-readable
+  form. If no path is specified, the current state will be shown. If the
+  path is "-", the state or plan file is read from stdin, and if it is an
+  http or https URL, the file is downloaded with a GET request.
+
+  If more than one path is given, the files are shown in turn, each after
+  a header line naming it. With -json, the output is instead a JSON array
+  of the documents for each file.
+
+  The exit status is 0 on success, including when there is no state, 1 for
+  usage and other errors, and 2 if the given file could not be read as
+  either a state or a plan file.
+
+Options:
+
+  -no-color           If specified, output won't contain any color.
+
+  -check              If specified, only check that the given file is a valid
+                      plan or state file for this version of Terraform,
+                      without producing any output. Can't be used with -json.
+
+  -json               If specified, output the Terraform plan or state in
+                      a machine-readable form. Errors and warnings are
+                      included in the output under "diagnostics".
+
+  -addresses          If specified when showing a state, output the absolute
+                      address of each resource instance, one per line and
+                      sorted, such as for use with -target. Can be combined
+                      with the filtering options.
+
+  -brief              If specified when showing a state, output only the
+                      address and resource type of each resource instance,
+                      one per line, leaving out its attributes and the
+                      output values. Can be combined with the filtering
+                      options.
+
+  -show-all           If specified when showing a plan, or with -diff or
+                      -run, list every attribute of each resource instance
+                      to be updated or replaced, rather than only those
+                      that change.
+
+  -format=FORMAT      The form of the output: "tree", the default, for the
+                      human-readable form; "table" to show a state as a
+                      table of its resource instances with their types, ids
+                      and statuses; or "json", which is the same as -json.
+
+  -diff               If specified along with the paths to two state files,
+                      show the differences between them, as the changes
+                      to the managed resource instances that would turn
+                      the first state into the second. With -json, the
+                      differences are output as "resource_changes".
+
+  -backup             If specified, show the backup of the current workspace's
+                      state that the "local" backend keeps, which is the
+                      state before its most recent update, instead of the
+                      state itself.
+
+  -from-env=NAME      If specified, show the plan or state file whose content
+                      is base64-encoded in the given environment variable,
+                      instead of a file on disk. Takes no path.
+
+  -run=RUN_ID         If specified, show the plan of the given run in Terraform
+                      Enterprise instead of a file, using the configured
+                      "remote" backend. Without -json, the plan's changes
+                      are shown in the same form as for a plan file, but
+                      with no attribute known to be sensitive unless the
+                      run's plan marks it so.
+
+  -json-schema        If specified, output the JSON Schema describing the
+                      -json output for a plan, without showing any file.
+
+  -hcl                If specified when showing a state, output an HCL import
+                      block for each managed resource instance, importing
+                      its object by its id attribute, as a starting point
+                      for configuration to manage the same objects.
+
+  -json-stream        If specified, output the Terraform state as
+                      newline-delimited JSON: a header line carrying the
+                      format version, then one line for each resource
+                      instance. Can't be used with a plan, -json or -check.
+
+  -json-changes-only  If specified along with -json when showing a plan,
+                      resources that the plan leaves unchanged are omitted
+                      from both the resource changes and the planned values.
+
+  -json-no-values     If specified along with -json when showing a plan,
+                      every value in the plan is replaced with null, leaving
+                      its structure: the resources, their actions and the
+                      paths of the attributes that change.
+
+  -json-config        If specified along with -json when showing a plan, the
+                      configuration the plan was created from is included
+                      as "configuration", with its expressions unevaluated.
+
+  -json-cache=DIR     If specified along with -json when showing a plan, the
+                      JSON output is cached in the given directory, keyed
+                      by the content of the plan file and the options, and
+                      reused when the same plan is shown again.
+
+  -json-stats         If specified along with -json, a line summarizing the
+                      JSON output, including its size, is written to
+                      stderr after it.
+
+  -json-pretty        If specified along with -json, the JSON output is
+                      indented, and colorized unless -no-color is set.
+                      -pretty is a shorter equivalent.
+
+  -no-sensitive       If specified along with -json or -json-stream,
+                      sensitive values are omitted from the output entirely
+                      rather than marked as sensitive, so that it is safe to
+                      share. The values of a plan's input variables are
+                      redacted too. The result is incomplete, and not
+                      suitable for use in place of the full JSON output.
+
+  -module=ADDRESS     If specified, show only the resources from the state
+                      that belong to the given module, such as module.db,
+                      or to any of its descendent modules.
+
+  -out=PATH           If specified, write the output to the given file, without
+                      color, instead of to stdout. The file is replaced
+                      only once the output is complete. Can't be used with
+                      -check.
+
+  -state=PATH         If specified when showing a plan, the plan is shown
+                      against the state file at the given path in place of
+                      the prior state it was created with, such as for use
+                      with -with-state or -json. The planned changes are not
+                      recomputed, so they may not match what applying the
+                      plan to that state would do.
+
+  -target=ADDRESS     If specified, show only the resource instances within
+                      the given module, resource or resource instance
+                      address, such as module.db.aws_instance.this[0]. Can
+                      be given more than once. When showing a plan, requires
+                      -json, and also limits the resource changes.
+
+  -deposed=KEY        If specified along with -target, show only the deposed
+                      object with the given key of the targeted resource
+                      instance, such as one left by an interrupted
+                      create-before-destroy replacement.
+
+  -type=TYPE          If specified, show only the resources from the state
+                      of the given type, such as aws_iam_role, or with a
+                      "data." prefix the data sources of that type. Can be
+                      given more than once to show several types.
+
+  -where=NAME=VALUE   If specified, show only the resource instances from
+                      the state whose top-level attribute of the given name
+                      has the given value, such as instance_type=t3.micro.
+                      Can be given more than once to require all of several
+                      conditions.
+
+  -decrypt-cmd=CMD    If specified, each file to show is first passed on stdin
+                      to the given shell command, whose stdout is read as
+                      the state or plan file instead, such as to decrypt it.
+                      The command runs with the same access as Terraform.
+
+  -max-age=DURATION   If specified when showing a plan, warn if the plan was
+                      created longer ago than the given duration, such as
+                      24h, since it may no longer reflect the infrastructure.
+
+  -only-errors        If specified, warnings are not shown, only errors. The
+                      exit status is the same either way.
+
+  -watch              If specified, show the current state again after every
+                      -interval, clearing the screen each time, until
+                      interrupted. If the output isn't to a terminal, the
+                      state is shown only once. Takes no path.
+
+  -interval=2s        The time between each showing of the state with -watch.
+
+  -url-timeout=30s    The time to allow for downloading a file given as a URL.
+
+  -url-max-size=N     The maximum size in bytes of a file given as a URL.
+                      Defaults to 100 MiB.
+
+  -with-state         If specified when showing a plan, also output the full
+                      state that is expected to result from applying the
+                      plan. With -json, it is included as "planned_state".
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShowCommand) Synopsis() string {
+	return "Inspect Terraform state or plan"
+}
+
+const errShowNoInstanceFound = `No instance found for the given address %s!
+
+The -target option requires that at least one resource instance in the state
+is within one of the given module, resource or resource instance addresses.
+To view the available instances, use "terraform state list".`
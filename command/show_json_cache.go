@@ -0,0 +1,93 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+// jsonCachePath returns the path of the file in the -json-cache directory for
+// the JSON representation of the plan file with the given SHA-256 checksum,
+// as shown with the current options and the given provider plugins.
+//
+// The representation also depends on the -state file, if any, and on the
+// versions of the providers, whose schemas are used to decode the plan, so
+// they are part of the key along with every option that changes the output.
+// The configuration and the prior state otherwise come from the plan file
+// itself. The cached document is always compact, and is formatted for
+// -json-pretty only once it's read, so that option isn't part of the key.
+func (c *ShowCommand) jsonCachePath(planSum [sha256.Size]byte, plugins discovery.PluginMetaSet) (string, error) {
+	h := sha256.New()
+	h.Write(planSum[:])
+	fmt.Fprintf(h, "\nno-sensitive=%t no-values=%t changes-only=%t with-state=%t config=%t\n", c.noSensitive, c.noValues, c.changesOnly, c.withState, c.jsonConfig)
+	fmt.Fprintf(h, "target=%s\n", showTargetsString(c.filter))
+
+	if c.priorStatePath != "" {
+		src, err := ioutil.ReadFile(c.priorStatePath)
+		if err != nil {
+			return "", err
+		}
+		stateSum := sha256.Sum256(src)
+		fmt.Fprintf(h, "state=%x\n", stateSum)
+	}
+
+	var versions []string
+	for p := range plugins {
+		versions = append(versions, fmt.Sprintf("%s=%s", p.Name, p.Version))
+	}
+	sort.Strings(versions)
+	fmt.Fprintf(h, "providers=%s\n", strings.Join(versions, ","))
+
+	return filepath.Join(c.jsonCacheDir, hex.EncodeToString(h.Sum(nil))+".json"), nil
+}
+
+// readJSONCache returns the cached JSON plan in the given file, or nil if
+// there is none, or if it's in a different format version than the current
+// one, such as if it was written by another version of Terraform.
+func readJSONCache(path string) []byte {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var doc struct {
+		FormatVersion string `json:"format_version"`
+	}
+	if err := json.Unmarshal(src, &doc); err != nil || doc.FormatVersion != jsonplan.FormatVersion {
+		log.Printf("[DEBUG] show: ignoring the cached JSON plan in %s, in format version %q", path, doc.FormatVersion)
+		return nil
+	}
+	return src
+}
+
+// writeJSONCache writes the given JSON plan to the given cache file. The file
+// is replaced only once it's complete, so that a concurrent reader never
+// sees part of it, and is readable only by the current user since a plan
+// may include sensitive values.
+func writeJSONCache(path string, src []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(src); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
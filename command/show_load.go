@@ -0,0 +1,187 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/httpclient"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plans/planfile"
+	"github.com/hashicorp/terraform/states"
+)
+
+// readShowFile reads the plan or state file at the given path, which may
+// also be stdinArg or a URL, decrypting it with -decrypt-cmd if that is set.
+// With -from-env, the file is instead decoded from the environment variable,
+// whatever the path. It returns the content of the file along with the result of
+// readPlanOrState.
+//
+// If the file can't be read, readShowFile writes an error and returns the
+// exit status to return for it, which is 2 if the file is not a valid plan
+// or state file, and 1 otherwise. The exit status is 0 on success.
+func (c *ShowCommand) readShowFile(path string) ([]byte, *planfile.Reader, *plans.Plan, *states.State, int) {
+	var src []byte
+	var err error
+	switch {
+	case c.fromEnv != "":
+		src, err = readShowEnv(c.fromEnv)
+	case path == stdinArg:
+		src, err = ioutil.ReadAll(c.input)
+	case isShowURL(path):
+		src, err = fetchShowURL(path, c.urlTimeout, c.urlMaxSize)
+	default:
+		src, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading file: %s", err))
+		return nil, nil, nil, nil, 1
+	}
+	if c.decryptCmd != "" {
+		src, err = decryptShowFile(src, c.decryptCmd)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error decrypting file: %s", err))
+			return nil, nil, nil, nil, 1
+		}
+	}
+
+	pr, plan, state, err := readPlanOrState(src)
+	if err != nil {
+		// A file written by another version of Terraform is a common
+		// source of confusion, so it gets a more helpful message of its
+		// own.
+		if err, ok := err.(*PlanOrStateError); ok {
+			for _, err := range []error{err.PlanErr, err.StateErr} {
+				if msg := unsupportedVersionMessage(err); msg != "" {
+					c.Ui.Error(msg)
+					return nil, nil, nil, nil, 2
+				}
+			}
+		}
+
+		if posErr, ok := err.(*PlanOrStateError); ok && c.jsonUi != nil {
+			c.showDiagnostics(&planOrStateDiagnostic{posErr})
+		} else {
+			c.Ui.Error(err.Error())
+		}
+		// This is distinct from the usage errors and other failures
+		// above so that a script can tell that the file itself is the
+		// problem.
+		return nil, nil, nil, nil, 2
+	}
+	return src, pr, plan, state, 0
+}
+
+const (
+	// defaultShowURLTimeout is the default for the -url-timeout option.
+	defaultShowURLTimeout = 30 * time.Second
+
+	// defaultShowURLMaxSize is the default for the -url-max-size option.
+	defaultShowURLMaxSize = 100 << 20
+)
+
+// readShowEnv returns the content of a plan or state file from the named
+// environment variable, for -from-env, in which it is base64-encoded. Any
+// whitespace in the value is ignored, so that it may be wrapped over several
+// lines, as the base64 command does by default.
+func readShowEnv(name string) ([]byte, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("the environment variable %s given by -from-env is not set", name)
+	}
+	v = strings.Join(strings.Fields(v), "")
+	if v == "" {
+		return nil, fmt.Errorf("the environment variable %s given by -from-env is empty", name)
+	}
+	src, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("the environment variable %s given by -from-env is not valid base64: %s", name, err)
+	}
+	return src, nil
+}
+
+// isShowURL returns true if the given path argument is an http or https URL
+// rather than a path on the local filesystem.
+func isShowURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// fetchShowURL returns the body of the response to a GET request for the
+// given URL, or an error if the request fails, the response status is not
+// successful, or the body is larger than maxSize bytes.
+func fetchShowURL(rawURL string, timeout time.Duration, maxSize int64) ([]byte, error) {
+	client := httpclient.New()
+	client.Timeout = timeout
+
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("GET %s returned HTTP status %s", rawURL, resp.Status)
+	}
+
+	// We read one byte more than the limit so that we can tell a body of
+	// exactly the maximum size from one that is too large.
+	src, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %s", rawURL, err)
+	}
+	if int64(len(src)) > maxSize {
+		return nil, fmt.Errorf("response from %s is larger than the maximum of %d bytes; use -url-max-size to raise the limit", rawURL, maxSize)
+	}
+	return src, nil
+}
+
+// decryptShowFile returns the output of the given shell command when given
+// the content of a file on its stdin, for -decrypt-cmd. The command's stderr
+// is captured so that it can be included in the error if the command fails,
+// rather than mixed in with the output of the show command.
+func decryptShowFile(src []byte, command string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("/bin/sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(src)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%q failed: %s\n%s", command, err, msg)
+		}
+		return nil, fmt.Errorf("%q failed: %s", command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// gzipMagic is the header that identifies a gzip-compressed file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gunzip returns the decompressed content of the given gzip-compressed data.
+func gunzip(src []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
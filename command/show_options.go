@@ -0,0 +1,279 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// showOutput is the form of the output of the show command, chosen by at
+// most one of the flags in showOutputFlags. It is used as a bit in the set
+// of the forms that an option can be used with.
+type showOutput uint
+
+const (
+	showOutputTree showOutput = 1 << iota
+	showOutputJSON
+	showOutputJSONStream
+	showOutputCheck
+	showOutputHCL
+	showOutputTable
+	showOutputAddresses
+	showOutputBrief
+
+	showOutputAll = showOutputTree | showOutputJSON | showOutputJSONStream |
+		showOutputCheck | showOutputHCL | showOutputTable | showOutputAddresses |
+		showOutputBrief
+)
+
+// showSource is what the show command shows, chosen by at most one of the
+// flags in showSourceFlags, or otherwise by whether any paths are given. As
+// for showOutput, it is used as a bit in a set.
+type showSource uint
+
+const (
+	showSourceState showSource = 1 << iota
+	showSourceFiles
+	showSourceRun
+	showSourceDiff
+	showSourceBackup
+	showSourceEnv
+	showSourceWatch
+
+	showSourceAll = showSourceState | showSourceFiles | showSourceRun |
+		showSourceDiff | showSourceBackup | showSourceEnv | showSourceWatch
+)
+
+// showMode describes one of the output forms or sources: its name, which is
+// that of the flag that chooses it if flag is set and a description of it
+// otherwise, and for a source, the output forms that it can be used with.
+type showMode struct {
+	name    string
+	flag    bool
+	outputs showOutput
+}
+
+// showOutputModes describes each of the output forms. Each can be used with
+// any source whose showSourceModes entry allows it.
+var showOutputModes = map[showOutput]showMode{
+	showOutputTree:       {name: "the default output"},
+	showOutputJSON:       {name: "-json", flag: true},
+	showOutputJSONStream: {name: "-json-stream", flag: true},
+	showOutputCheck:      {name: "-check", flag: true},
+	showOutputHCL:        {name: "-hcl", flag: true},
+	showOutputTable:      {name: "-format=table", flag: true},
+	showOutputAddresses:  {name: "-addresses", flag: true},
+	showOutputBrief:      {name: "-brief", flag: true},
+}
+
+// showSourceModes describes each of the sources, with the output forms that
+// can be used with it.
+var showSourceModes = map[showSource]showMode{
+	// There's no file to check without a path.
+	showSourceState:  {name: "the current state", outputs: showOutputAll &^ showOutputCheck},
+	showSourceFiles:  {name: "the path to a file", outputs: showOutputAll},
+	showSourceRun:    {name: "-run", flag: true, outputs: showOutputTree | showOutputJSON},
+	showSourceDiff:   {name: "-diff", flag: true, outputs: showOutputTree | showOutputJSON},
+	showSourceBackup: {name: "-backup", flag: true, outputs: showOutputAll &^ showOutputCheck},
+	showSourceEnv:    {name: "-from-env", flag: true, outputs: showOutputAll},
+	showSourceWatch: {name: "-watch", flag: true, outputs: showOutputTree | showOutputTable |
+		showOutputAddresses | showOutputBrief},
+}
+
+// showOutputFlags and showSourceFlags are the names of the flags that choose
+// each output form and source. The -format flag chooses an output form too,
+// as described for showOutputFormat.
+var showOutputFlags = map[string]showOutput{
+	"json":        showOutputJSON,
+	"json-stream": showOutputJSONStream,
+	"check":       showOutputCheck,
+	"hcl":         showOutputHCL,
+	"addresses":   showOutputAddresses,
+	"brief":       showOutputBrief,
+}
+
+var showSourceFlags = map[string]showSource{
+	"run":      showSourceRun,
+	"diff":     showSourceDiff,
+	"backup":   showSourceBackup,
+	"from-env": showSourceEnv,
+	"watch":    showSourceWatch,
+}
+
+// showOption describes the output forms and sources that a flag of the show
+// command can be used with, for a flag that chooses neither.
+type showOption struct {
+	outputs showOutput
+	sources showSource
+}
+
+// showOptions describes each of the flags of the show command other than
+// those that choose an output form or source. A flag that isn't listed can
+// be used with any of them.
+var showOptions = map[string]showOption{
+	"show-all":          {showOutputTree, showSourceAll},
+	"json-pretty":       {showOutputJSON, showSourceAll},
+	"pretty":            {showOutputJSON, showSourceAll},
+	"json-stats":        {showOutputJSON, showSourceAll},
+	"json-cache":        {showOutputJSON, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"json-changes-only": {showOutputJSON, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"json-no-values":    {showOutputJSON, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"json-config":       {showOutputJSON, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"no-sensitive":      {showOutputJSON | showOutputJSONStream, showSourceAll &^ showSourceRun},
+	"with-state":        {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"state":             {showOutputAll &^ showOutputCheck, showSourceFiles},
+	"out":               {showOutputAll &^ showOutputCheck, showSourceAll &^ showSourceWatch},
+	"decrypt-cmd":       {showOutputAll, showSourceFiles | showSourceDiff},
+	"max-age":           {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"target":            {showOutputAll, showSourceAll &^ showSourceRun},
+	"module":            {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"type":              {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"where":             {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"deposed":           {showOutputAll, showSourceAll &^ (showSourceRun | showSourceDiff)},
+	"interval":          {showOutputAll, showSourceWatch},
+}
+
+// showOutputFormat returns the output form chosen by the given value of the
+// -format flag, and false if it isn't valid. The default "tree" form chooses
+// nothing, and "json" is the same as -json.
+func showOutputFormat(value string) (showOutput, bool) {
+	switch value {
+	case "tree":
+		return 0, true
+	case "table":
+		return showOutputTable, true
+	case "json":
+		return showOutputJSON, true
+	default:
+		return 0, false
+	}
+}
+
+// showFlagsGiven returns the names of the flags that were given in the given
+// set with a value other than false or an empty string, which are the same
+// as not giving them.
+func showFlagsGiven(flags *flag.FlagSet) map[string]bool {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		if v := f.Value.String(); v != "false" && v != "" {
+			given[f.Name] = true
+		}
+	})
+	return given
+}
+
+// showModes returns the output form and source chosen by the given flags and
+// the number of paths given, checking that each of the other flags given can
+// be used with them as described by showOptions. The format is the output
+// form chosen by -format, if any.
+//
+// If the flags can't be used together, showModes returns a message
+// describing the first conflict found, with the flags in order of their
+// names.
+func showModes(given map[string]bool, format showOutput, paths int) (showOutput, showSource, string) {
+	names := make([]string, 0, len(given))
+	for name := range given {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := showOutputTree
+	if format != 0 {
+		output = format
+	}
+	var source showSource
+	for _, name := range names {
+		if o, ok := showOutputFlags[name]; ok && o != output {
+			if output != showOutputTree {
+				return 0, 0, fmt.Sprintf("The %s and -%s options can't be used together.", showOutputModes[output].name, name)
+			}
+			output = o
+		}
+		if s, ok := showSourceFlags[name]; ok {
+			if source != 0 {
+				return 0, 0, fmt.Sprintf("The %s and -%s options can't be used together.", showSourceModes[source].name, name)
+			}
+			source = s
+		}
+	}
+
+	switch {
+	case source == showSourceDiff:
+		if paths != 2 {
+			return 0, 0, "The -diff option requires the paths to two state files, the old one and then the new one."
+		}
+	case source != 0:
+		if paths > 0 {
+			return 0, 0, fmt.Sprintf("The %s option takes no path.", showSourceModes[source].name)
+		}
+	case paths > 0:
+		source = showSourceFiles
+	default:
+		source = showSourceState
+	}
+
+	// Every source can be used with the default output, so a conflict
+	// between them is always down to the flag choosing the output.
+	if showSourceModes[source].outputs&output == 0 {
+		var allowed showSource
+		for s, mode := range showSourceModes {
+			if mode.outputs&output != 0 {
+				allowed |= s
+			}
+		}
+		return 0, 0, showSourceConflict(showOutputModes[output].name, allowed, source)
+	}
+	for _, name := range names {
+		opt, ok := showOptions[name]
+		if !ok {
+			continue
+		}
+		if opt.outputs&output == 0 {
+			return 0, 0, showOutputConflict("-"+name, opt.outputs, output)
+		}
+		if opt.sources&source == 0 {
+			return 0, 0, showSourceConflict("-"+name, opt.sources, source)
+		}
+	}
+	return output, source, ""
+}
+
+// showOutputConflict returns the message for the given flag, which can be
+// used only with the given output forms, being used with the given one.
+func showOutputConflict(flagName string, allowed, output showOutput) string {
+	if mode := showOutputModes[output]; mode.flag {
+		return fmt.Sprintf("The %s and %s options can't be used together.", flagName, mode.name)
+	}
+	var names []string
+	for o := showOutputTree; o&showOutputAll != 0; o <<= 1 {
+		if allowed&o != 0 {
+			names = append(names, showOutputModes[o].name)
+		}
+	}
+	return fmt.Sprintf("The %s option can only be used together with %s.", flagName, joinOr(names))
+}
+
+// showSourceConflict is like showOutputConflict, but for a flag that can be
+// used only with the given sources.
+func showSourceConflict(flagName string, allowed, source showSource) string {
+	if mode := showSourceModes[source]; mode.flag {
+		return fmt.Sprintf("The %s and %s options can't be used together.", flagName, mode.name)
+	}
+	var names []string
+	for s := showSourceState; s&showSourceAll != 0; s <<= 1 {
+		if allowed&s != 0 {
+			names = append(names, showSourceModes[s].name)
+		}
+	}
+	return fmt.Sprintf("The %s option can only be used together with %s.", flagName, joinOr(names))
+}
+
+// joinOr joins the given names as a list ending with "or", such as
+// "a, b or c".
+func joinOr(names []string) string {
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
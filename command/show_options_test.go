@@ -0,0 +1,102 @@
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestShowModes(t *testing.T) {
+	tests := []struct {
+		flags      []string
+		format     showOutput
+		paths      int
+		wantOutput showOutput
+		wantSource showSource
+		wantMsg    string
+	}{
+		{nil, 0, 0, showOutputTree, showSourceState, ""},
+		{nil, 0, 1, showOutputTree, showSourceFiles, ""},
+		{[]string{"json", "json-pretty", "target"}, 0, 1, showOutputJSON, showSourceFiles, ""},
+		{[]string{"json-pretty"}, showOutputJSON, 0, showOutputJSON, showSourceState, ""},
+		{[]string{"json"}, showOutputJSON, 0, showOutputJSON, showSourceState, ""},
+		{[]string{"run", "json", "out"}, 0, 0, showOutputJSON, showSourceRun, ""},
+		{[]string{"run", "show-all"}, 0, 0, showOutputTree, showSourceRun, ""},
+		{[]string{"diff", "no-sensitive", "json"}, 0, 2, showOutputJSON, showSourceDiff, ""},
+		{[]string{"watch", "brief", "interval"}, 0, 0, showOutputBrief, showSourceWatch, ""},
+		{[]string{"from-env", "check"}, 0, 0, showOutputCheck, showSourceEnv, ""},
+
+		{[]string{"check", "json"}, 0, 1, 0, 0, "The -check and -json options can't be used together."},
+		{[]string{"hcl"}, showOutputTable, 0, 0, 0, "The -format=table and -hcl options can't be used together."},
+		{[]string{"backup", "run"}, 0, 0, 0, 0, "The -backup and -run options can't be used together."},
+		{[]string{"run"}, 0, 1, 0, 0, "The -run option takes no path."},
+		{[]string{"diff"}, 0, 1, 0, 0, "The -diff option requires the paths to two state files, the old one and then the new one."},
+		{[]string{"check"}, 0, 0, 0, 0, "The -check option can only be used together with the path to a file or -from-env."},
+		{[]string{"watch", "json"}, 0, 0, 0, 0, "The -json and -watch options can't be used together."},
+		{[]string{"json-stats"}, 0, 0, 0, 0, "The -json-stats option can only be used together with -json."},
+		{[]string{"no-sensitive"}, 0, 0, 0, 0, "The -no-sensitive option can only be used together with -json or -json-stream."},
+		{[]string{"show-all", "brief"}, 0, 0, 0, 0, "The -show-all and -brief options can't be used together."},
+		{[]string{"json", "json-config", "diff"}, 0, 2, 0, 0, "The -json-config and -diff options can't be used together."},
+		{[]string{"state"}, 0, 0, 0, 0, "The -state option can only be used together with the path to a file."},
+		{[]string{"interval"}, 0, 1, 0, 0, "The -interval option can only be used together with -watch."},
+		{[]string{"out", "check"}, 0, 1, 0, 0, "The -out and -check options can't be used together."},
+	}
+
+	for _, test := range tests {
+		given := make(map[string]bool)
+		for _, name := range test.flags {
+			given[name] = true
+		}
+		name := strings.Join(test.flags, ",")
+		t.Run(name, func(t *testing.T) {
+			output, source, msg := showModes(given, test.format, test.paths)
+			if msg != test.wantMsg {
+				t.Fatalf("wrong message\ngot:  %s\nwant: %s", msg, test.wantMsg)
+			}
+			if output != test.wantOutput || source != test.wantSource {
+				t.Errorf("wrong modes %d, %d; want %d, %d", output, source, test.wantOutput, test.wantSource)
+			}
+		})
+	}
+}
+
+// Every flag of the show command is either one that chooses an output form
+// or source, or is described by showOptions, or can be used with anything.
+func TestShowModes_flagsKnown(t *testing.T) {
+	anywhere := map[string]bool{
+		"format":       true,
+		"json-schema":  true,
+		"url-timeout":  true,
+		"url-max-size": true,
+		"only-errors":  true,
+	}
+	for name := range showOptions {
+		if _, ok := showOutputFlags[name]; ok {
+			t.Errorf("-%s both chooses an output form and is an option", name)
+		}
+		if _, ok := showSourceFlags[name]; ok {
+			t.Errorf("-%s both chooses a source and is an option", name)
+		}
+		if anywhere[name] {
+			t.Errorf("-%s is described by showOptions, but listed as usable anywhere", name)
+		}
+	}
+	for _, name := range showFlagNames() {
+		_, option := showOptions[name]
+		_, output := showOutputFlags[name]
+		_, source := showSourceFlags[name]
+		if !option && !output && !source && !anywhere[name] {
+			t.Errorf("-%s is not described by the tables in show_options.go", name)
+		}
+	}
+}
+
+// showFlagNames returns the names of all of the flags of the show command.
+func showFlagNames() []string {
+	var names []string
+	c := &ShowCommand{}
+	c.flagSet(&showFlags{}).VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
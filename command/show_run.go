@@ -0,0 +1,401 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/backend/remote"
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// showRun outputs the plan of the remote run given by -run, downloading it
+// from the remote backend, and returns the exit status.
+//
+// The remote backend provides the plan only in its JSON form, in the format
+// of the version of Terraform that the run used, so without -json the plan
+// is decoded as described for runPlanChanges and then rendered in the same
+// way as a local plan file.
+func (c *ShowCommand) showRun() int {
+	b, backendDiags := c.Backend(nil)
+	if backendDiags.HasErrors() {
+		c.showDiagnostics(backendDiags)
+		return 1
+	}
+	rb, ok := b.(*remote.Remote)
+	if !ok {
+		c.Ui.Error(`The -run option requires the "remote" backend, which runs Terraform in Terraform Enterprise, but the configured backend is not remote.`)
+		return 1
+	}
+
+	src, err := rb.RunPlanJSON(context.Background(), c.runID)
+	if err != nil {
+		c.showDiagnostics(err)
+		return 1
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(src)
+	}
+	changes, schemas, err := runPlanChanges(src)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read the plan of run %s: %s", c.runID, err))
+		return 1
+	}
+	dispPlan := format.NewPlan(changes, schemas)
+	dispPlan.ShowUnchanged = c.showAll
+	c.Ui.Output(dispPlan.Format(c.Colorize()))
+	if !dispPlan.Empty() {
+		c.Ui.Output("\n" + dispPlan.Summary(c.Colorize()))
+	}
+	return 0
+}
+
+// runPlanChanges returns the resource instance and root module output value
+// changes of the given JSON plan of a remote run, along with schemas that
+// describe the changed resource types well enough to render them.
+//
+// The provider schemas the run used aren't available locally, so each
+// resource type is given an attribute for each top-level property of its
+// objects' values, of any type, which is sensitive if any part of it is
+// marked as sensitive. The values keep the structure they have in the JSON
+// plan, with the values that are marked as unknown made unknown.
+//
+// Only the parts of the format that are the same in every version of it
+// are used, so that a plan made by a newer version of Terraform can be
+// rendered too. A change with an action unknown to this version of
+// Terraform is left out.
+func runPlanChanges(src []byte) (*plans.Changes, *terraform.Schemas, error) {
+	var doc struct {
+		ResourceChanges []struct {
+			Address      string        `json:"address"`
+			Mode         string        `json:"mode"`
+			Type         string        `json:"type"`
+			ProviderName string        `json:"provider_name"`
+			Deposed      string        `json:"deposed"`
+			Change       runPlanChange `json:"change"`
+		} `json:"resource_changes"`
+		OutputChanges map[string]struct {
+			runPlanChange
+			Sensitive bool `json:"sensitive"`
+		} `json:"output_changes"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	// The attributes of each resource type are gathered from all of its
+	// changes first, since every object of the type must have them all.
+	schemas := &terraform.Schemas{
+		Providers: make(map[string]*terraform.ProviderSchema),
+	}
+	blocks := make([]*configschema.Block, len(doc.ResourceChanges))
+	for i, rc := range doc.ResourceChanges {
+		ps := schemas.Providers[rc.ProviderName]
+		if ps == nil {
+			ps = &terraform.ProviderSchema{
+				ResourceTypes: make(map[string]*configschema.Block),
+				DataSources:   make(map[string]*configschema.Block),
+			}
+			schemas.Providers[rc.ProviderName] = ps
+		}
+		types := ps.ResourceTypes
+		if rc.Mode == "data" {
+			types = ps.DataSources
+		}
+		block := types[rc.Type]
+		if block == nil {
+			block = &configschema.Block{
+				Attributes: make(map[string]*configschema.Attribute),
+			}
+			types[rc.Type] = block
+		}
+		rc.Change.addAttributes(block)
+		blocks[i] = block
+	}
+	for i, rc := range doc.ResourceChanges {
+		rc.Change.markSensitive(blocks[i])
+	}
+
+	changes := plans.NewChanges()
+	for i, rc := range doc.ResourceChanges {
+		action, ok := runPlanAction(rc.Change.Actions)
+		if !ok {
+			continue
+		}
+		addr, diags := addrs.ParseAbsResourceInstanceStr(rc.Address)
+		if diags.HasErrors() {
+			return nil, nil, fmt.Errorf("invalid resource address %q: %s", rc.Address, diags.Err())
+		}
+		ty := blocks[i].ImpliedType()
+		change := &plans.ResourceInstanceChange{
+			Addr:         addr,
+			DeposedKey:   states.DeposedKey(rc.Deposed),
+			ProviderAddr: addrs.ProviderConfig{Type: rc.ProviderName}.Absolute(addrs.RootModuleInstance),
+			Change: plans.Change{
+				Action: action,
+				Before: runPlanObject(runPlanValue(rc.Change.Before, nil), ty),
+				After:  runPlanObject(runPlanValue(rc.Change.After, rc.Change.AfterUnknown), ty),
+			},
+			RequiredReplace: rc.Change.requiredReplace(),
+		}
+		cs, err := change.Encode(ty)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", rc.Address, err)
+		}
+		changes.Resources = append(changes.Resources, cs)
+	}
+
+	for name, oc := range doc.OutputChanges {
+		action, ok := runPlanAction(oc.Actions)
+		if !ok {
+			continue
+		}
+		change := &plans.OutputChange{
+			Addr: addrs.OutputValue{Name: name}.Absolute(addrs.RootModuleInstance),
+			Change: plans.Change{
+				Action: action,
+				Before: runPlanValue(oc.Before, nil),
+				After:  runPlanValue(oc.After, oc.AfterUnknown),
+			},
+			Sensitive: oc.Sensitive,
+		}
+		cs, err := change.Encode()
+		if err != nil {
+			return nil, nil, fmt.Errorf("output %s: %s", name, err)
+		}
+		changes.Outputs = append(changes.Outputs, cs)
+	}
+
+	return changes, schemas, nil
+}
+
+// runPlanChange decodes the parts of a change in a JSON plan that
+// runPlanChanges uses. The values are decoded generically, as by
+// json.Unmarshal into an interface{} with numbers kept as json.Number.
+type runPlanChange struct {
+	Actions         []string        `json:"actions"`
+	Before          interface{}     `json:"before"`
+	After           interface{}     `json:"after"`
+	AfterUnknown    interface{}     `json:"after_unknown"`
+	BeforeSensitive interface{}     `json:"before_sensitive"`
+	AfterSensitive  interface{}     `json:"after_sensitive"`
+	ReplacePaths    [][]interface{} `json:"replace_paths"`
+}
+
+// addAttributes adds to the given block an attribute of any type for each
+// top-level property of the change's values.
+func (c *runPlanChange) addAttributes(block *configschema.Block) {
+	for _, v := range []interface{}{c.Before, c.After, c.AfterUnknown} {
+		m, _ := v.(map[string]interface{})
+		for name := range m {
+			if block.Attributes[name] == nil {
+				block.Attributes[name] = &configschema.Attribute{
+					Type:     cty.DynamicPseudoType,
+					Optional: true,
+				}
+			}
+		}
+	}
+}
+
+// markSensitive marks as sensitive each attribute of the given block, as
+// added by addAttributes for every change to the resource type, that any
+// part of is marked as sensitive in either of the change's values.
+func (c *runPlanChange) markSensitive(block *configschema.Block) {
+	for _, v := range []interface{}{c.BeforeSensitive, c.AfterSensitive} {
+		if v == true {
+			// The whole object is sensitive.
+			for _, attr := range block.Attributes {
+				attr.Sensitive = true
+			}
+			continue
+		}
+		m, _ := v.(map[string]interface{})
+		for name, marks := range m {
+			if attr := block.Attributes[name]; attr != nil && runPlanMarked(marks) {
+				attr.Sensitive = true
+			}
+		}
+	}
+}
+
+// requiredReplace returns the change's replace_paths as paths into its
+// values, in the same form as the paths that format.NewPlan gives their
+// leaves: the first step is an attribute, and every later one is an index,
+// by name for a property of an object.
+func (c *runPlanChange) requiredReplace() cty.PathSet {
+	var paths []cty.Path
+Paths:
+	for _, steps := range c.ReplacePaths {
+		var path cty.Path
+		for i, step := range steps {
+			switch step := step.(type) {
+			case string:
+				if i == 0 {
+					path = path.GetAttr(step)
+				} else {
+					path = path.Index(cty.StringVal(step))
+				}
+			case json.Number:
+				n, err := step.Int64()
+				if err != nil {
+					continue Paths
+				}
+				path = path.Index(cty.NumberIntVal(n))
+			default:
+				continue Paths
+			}
+		}
+		paths = append(paths, path)
+	}
+	return cty.NewPathSet(paths...)
+}
+
+// runPlanAction returns the action for the given actions of a change in a
+// JSON plan, and false if they aren't known to this version of Terraform.
+func runPlanAction(actions []string) (plans.Action, bool) {
+	switch strings.Join(actions, ",") {
+	case "no-op":
+		return plans.NoOp, true
+	case "create":
+		return plans.Create, true
+	case "read":
+		return plans.Read, true
+	case "update":
+		return plans.Update, true
+	case "delete":
+		return plans.Delete, true
+	case "delete,create":
+		return plans.DeleteThenCreate, true
+	case "create,delete":
+		return plans.CreateThenDelete, true
+	default:
+		return plans.NoOp, false
+	}
+}
+
+// runPlanValue returns the value for the given generically-decoded JSON
+// value, which is null if v is nil, with each part that the given mirror of
+// its structure marks with true made unknown. A part marked as unknown
+// needn't be present in v, since a JSON plan leaves out unknown values.
+//
+// Objects and arrays become object and tuple values, as for the implied type
+// of the JSON, so every part of the result has the type of what is known of
+// it, and the unknown parts have no type constraint.
+func runPlanValue(v, unknown interface{}) cty.Value {
+	if unknown == true {
+		return cty.UnknownVal(cty.DynamicPseudoType)
+	}
+	switch v := v.(type) {
+	case string:
+		return cty.StringVal(v)
+	case bool:
+		return cty.BoolVal(v)
+	case json.Number:
+		f, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return cty.DynamicVal
+		}
+		return cty.NumberVal(f)
+	case []interface{}:
+		us, _ := unknown.([]interface{})
+		if len(v) == 0 {
+			return cty.EmptyTupleVal
+		}
+		elems := make([]cty.Value, len(v))
+		for i, ev := range v {
+			var eu interface{}
+			if i < len(us) {
+				eu = us[i]
+			}
+			elems[i] = runPlanValue(ev, eu)
+		}
+		return cty.TupleVal(elems)
+	case map[string]interface{}, nil:
+		vm, _ := v.(map[string]interface{})
+		um, _ := unknown.(map[string]interface{})
+		if v == nil && !runPlanMarked(um) {
+			return cty.NullVal(cty.DynamicPseudoType)
+		}
+		attrs := make(map[string]cty.Value)
+		for name, av := range vm {
+			attrs[name] = runPlanValue(av, um[name])
+		}
+		for name, au := range um {
+			if _, ok := vm[name]; !ok && runPlanMarked(au) {
+				attrs[name] = runPlanValue(nil, au)
+			}
+		}
+		if len(attrs) == 0 {
+			return cty.EmptyObjectVal
+		}
+		return cty.ObjectVal(attrs)
+	default:
+		return cty.DynamicVal
+	}
+}
+
+// runPlanObject returns the given value, as returned by runPlanValue for the
+// values of a resource change, as an object of the given type, which has an
+// attribute of any type for each of its properties, as described for
+// runPlanChanges. Any attribute that the value lacks is null.
+func runPlanObject(val cty.Value, ty cty.Type) cty.Value {
+	switch {
+	case val.IsNull():
+		return cty.NullVal(ty)
+	case !val.IsKnown():
+		return cty.UnknownVal(ty)
+	case !val.Type().IsObjectType():
+		// Should never happen, since a resource's values are always an
+		// object.
+		return cty.NullVal(ty)
+	}
+	attrs := make(map[string]cty.Value)
+	for name := range ty.AttributeTypes() {
+		if val.Type().HasAttribute(name) {
+			attrs[name] = val.GetAttr(name)
+		} else {
+			attrs[name] = cty.NullVal(cty.DynamicPseudoType)
+		}
+	}
+	if len(attrs) == 0 {
+		return cty.EmptyObjectVal
+	}
+	return cty.ObjectVal(attrs)
+}
+
+// runPlanMarked returns whether any part of the given mirror of the
+// structure of a JSON plan value, such as its after_unknown or
+// after_sensitive property, is marked with true.
+func runPlanMarked(marks interface{}) bool {
+	switch marks := marks.(type) {
+	case bool:
+		return marks
+	case []interface{}:
+		for _, m := range marks {
+			if runPlanMarked(m) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, m := range marks {
+			if runPlanMarked(m) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,10 +1,37 @@
 package command
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 func TestShow(t *testing.T) {
@@ -82,6 +109,64 @@ func TestShow_plan(t *testing.T) {
 	}
 }
 
+func TestShow_planSummary(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+	if code := c.Run([]string{"-no-color", planPath}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	got := strings.TrimSpace(ui.OutputWriter.String())
+	if want := "\n\nPlan: 1 to add, 0 to change, 0 to destroy."; !strings.HasSuffix(got, want) {
+		t.Errorf("output does not end with the summary\n%s", got)
+	}
+}
+
+func TestShow_jsonSchema(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run([]string{"-json-schema"}); code != 0 {
+		t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+	}
+	var got struct {
+		Properties struct {
+			FormatVersion struct {
+				Const string `json:"const"`
+			} `json:"format_version"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if got.Properties.FormatVersion.Const != jsonplan.FormatVersion {
+		t.Errorf("wrong format version %q; want %q", got.Properties.FormatVersion.Const, jsonplan.FormatVersion)
+	}
+
+	for _, args := range [][]string{{"-json-schema", "terraform.tfstate"}, {"-json-schema", "-json"}} {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run(args); code != 1 {
+			t.Errorf("wrong exit status %d for %q; want 1\n%s", code, args, ui.OutputWriter.String())
+		}
+	}
+}
+
 func TestShow_state(t *testing.T) {
 	originalState := testState()
 	statePath := testStateFile(t, originalState)
@@ -101,3 +186,3834 @@ func TestShow_state(t *testing.T) {
 		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
 	}
 }
+
+func TestShow_stateJSON(t *testing.T) {
+	statePath := testStateFile(t, testState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	want := map[string]interface{}{
+		"format_version": "0.1",
+		"values": map[string]interface{}{
+			"root_module": map[string]interface{}{
+				"resources": []interface{}{
+					map[string]interface{}{
+						"address":        "test_instance.foo",
+						"mode":           "managed",
+						"type":           "test_instance",
+						"name":           "foo",
+						"provider_name":  "test",
+						"status":         "ready",
+						"schema_version": 0.0,
+						"values": map[string]interface{}{
+							"id":  "bar",
+							"ami": nil,
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestShow_stateJSONPretty(t *testing.T) {
+	statePath := testStateFile(t, testState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) string {
+		t.Helper()
+		ui := new(cli.MockUi)
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+				Color:            true,
+			},
+		}
+		if code := c.Run(append(args, statePath)); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		return strings.TrimSuffix(ui.OutputWriter.String(), "\n")
+	}
+
+	// The default JSON output is a compact single line, for scripts.
+	compact := run("-json")
+	if strings.Contains(compact, "\n") {
+		t.Fatalf("default JSON output is not a single line\n%s", compact)
+	}
+
+	var want bytes.Buffer
+	if err := json.Indent(&want, []byte(compact), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if got := run("-json", "-json-pretty", "-no-color"); got != want.String() {
+		t.Fatalf("wrong output\ngot:\n%s\nwant:\n%s", got, want.String())
+	}
+
+	colored := run("-json", "-json-pretty")
+	if !strings.Contains(colored, "\033[") {
+		t.Fatalf("output is not colorized\n%s", colored)
+	}
+}
+
+func TestShow_jsonPrettyWithoutJSON(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	if code := c.Run([]string{"-json-pretty"}); code != 1 {
+		t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "only be used together with -json"; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestShow_noStateJSON(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	got := strings.TrimSpace(ui.OutputWriter.String())
+	want := `{"format_version":"0.1","values":{}}`
+	if got != want {
+		t.Fatalf("wrong output\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestShow_planJSON(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		planPath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got struct {
+		ResourceChanges []struct {
+			Address string
+			Change  struct {
+				Actions []string
+				After   map[string]interface{}
+			}
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if len(got.ResourceChanges) != 1 {
+		t.Fatalf("wrong number of resource changes %d; want 1", len(got.ResourceChanges))
+	}
+	rc := got.ResourceChanges[0]
+	if rc.Address != "test_instance.foo" {
+		t.Errorf("wrong address %q", rc.Address)
+	}
+	if got, want := rc.Change.Actions, []string{"create"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong actions %#v; want %#v", got, want)
+	}
+	if got, want := rc.Change.After["ami"], "bar"; got != want {
+		t.Errorf("wrong ami %#v; want %#v", got, want)
+	}
+}
+
+// A plan file carries its own configuration snapshot, so it can be shown
+// from a working directory that has no configuration and has never been
+// initialized.
+func TestShow_planJSONNoConfig(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		planPath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if _, ok := got["resource_changes"]; !ok {
+		t.Fatalf("output has no resource changes\n%s", ui.OutputWriter.String())
+	}
+	if ts, ok := got["timestamp"].(string); !ok {
+		t.Errorf("output has no timestamp\n%s", ui.OutputWriter.String())
+	} else if _, err := time.Parse(time.RFC3339, ts); err != nil {
+		t.Errorf("invalid timestamp: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(td, DefaultDataDir)); !os.IsNotExist(err) {
+		t.Errorf("showing a plan file initialized the working directory")
+	}
+}
+
+func TestShow_planJSONNoConfigSnapshot(t *testing.T) {
+	// Copy a valid plan file without its configuration snapshot, which
+	// is the only source of configuration for showing a plan.
+	zr, err := zip.OpenReader(showFixturePlanFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "tfconfig/") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: &buf,
+	}
+
+	if code := c.Run([]string{"-json", "-"}); code != 1 {
+		t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+	}
+	if got, want := ui.OutputWriter.String(), "Plan file has no configuration"; !strings.Contains(got, want) {
+		t.Errorf("error does not contain %q\n%s", want, got)
+	}
+}
+
+func TestShow_planJSONPretty(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) string {
+		t.Helper()
+		ui := new(cli.MockUi)
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run(append(args, planPath)); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		return strings.TrimSuffix(ui.OutputWriter.String(), "\n")
+	}
+
+	compact := run("-json")
+	pretty := run("-json", "-pretty")
+	if !strings.Contains(pretty, "\n  \"") {
+		t.Fatalf("output is not indented\n%s", pretty)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(pretty)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != compact {
+		t.Errorf("wrong result after compacting\ngot:  %s\nwant: %s", got, compact)
+	}
+}
+
+func TestShow_stdinPlanJSON(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	src, err := ioutil.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: bytes.NewReader(src),
+	}
+
+	args := []string{
+		"-json",
+		"-",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	if got, want := ui.OutputWriter.String(), `"resource_changes":[{"address":"test_instance.foo"`; !strings.Contains(got, want) {
+		t.Fatalf("output does not contain %s\n%s", want, got)
+	}
+}
+
+func TestShow_stdinState(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStateForTesting(testState(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: &buf,
+	}
+
+	args := []string{
+		"-no-color",
+		"-",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	if got, want := ui.OutputWriter.String(), "# test_instance.foo:"; !strings.Contains(got, want) {
+		t.Fatalf("output does not contain %q\n%s", want, got)
+	}
+}
+
+func TestShow_stdinInvalid(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: strings.NewReader("not a plan or a state"),
+	}
+
+	args := []string{
+		"-json",
+		"-",
+	}
+	if code := c.Run(args); code != 2 {
+		t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.OutputWriter.String())
+	}
+
+	if got, want := ui.OutputWriter.String(), "couldn't read the given file as a state or plan file"; !strings.Contains(got, want) {
+		t.Fatalf("error does not contain %q\n%s", want, got)
+	}
+}
+
+func TestShow_targetJSON(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-target=module.db.test_instance.foo[0]",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	want := map[string]interface{}{
+		"format_version": "0.1",
+		"values": map[string]interface{}{
+			"root_module": map[string]interface{}{
+				"child_modules": []interface{}{
+					map[string]interface{}{
+						"address": "module.db",
+						"resources": []interface{}{
+							map[string]interface{}{
+								"address":        "module.db.test_instance.foo[0]",
+								"mode":           "managed",
+								"type":           "test_instance",
+								"name":           "foo",
+								"index":          0.0,
+								"provider_name":  "test",
+								"status":         "ready",
+								"schema_version": 0.0,
+								"values": map[string]interface{}{
+									"id":  "db0",
+									"ami": "baz",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestShow_target(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-no-color",
+		"-target=module.db.test_instance.foo[0]",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	got := ui.OutputWriter.String()
+	if !strings.HasPrefix(got, "# module.db.test_instance.foo[0]:") {
+		t.Fatalf("wrong output\n%s", got)
+	}
+	if !strings.Contains(got, `"baz"`) {
+		t.Errorf("output is missing the instance attributes\n%s", got)
+	}
+	if strings.Contains(got, "test_instance.foo[1]") || strings.Contains(got, `"bar"`) {
+		t.Errorf("output includes other instances\n%s", got)
+	}
+}
+
+func TestShow_targetNotFound(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	for _, target := range []string{
+		"module.db.test_instance.foo[2]",
+		"module.web.test_instance.foo[0]",
+		"test_instance.bar",
+	} {
+		t.Run(target, func(t *testing.T) {
+			ui := new(cli.MockUi)
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			args := []string{
+				"-json",
+				"-target=" + target,
+				statePath,
+			}
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+			if got, want := ui.OutputWriter.String(), "No instance found for the given address "+target; !strings.Contains(got, want) {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestShow_targetNarrowedNotFound(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	// The targets match the state, but not once it's narrowed by the other
+	// options.
+	for name, args := range map[string][]string{
+		"type":   {"-target=test_instance.foo", "-type=test_other"},
+		"where":  {"-target=test_instance.foo", "-where=id=nothing"},
+		"module": {"-target=test_instance.foo", "-module=module.db"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			args = append([]string{"-no-color"}, args...)
+			if code := c.Run(append(args, statePath)); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+			if got, want := ui.ErrorWriter.String(), "No instance found for the given address test_instance.foo"; !strings.Contains(got, want) {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+			}
+			if got := ui.OutputWriter.String(); got != "" {
+				t.Errorf("unexpected output\n%s", got)
+			}
+		})
+	}
+}
+
+func TestShow_targetMultiple(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-no-color",
+		"-target=module.db.module.replica",
+		"-target=test_instance.foo",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	got := ui.OutputWriter.String()
+	for _, want := range []string{
+		"# test_instance.foo:",
+		"# module.db.module.replica.test_instance.foo:",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "module.db.test_instance.foo[") || strings.Contains(got, "module.dbx") {
+		t.Errorf("output includes other instances\n%s", got)
+	}
+}
+
+func TestShow_targetPlanJSON(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	tests := map[string][]string{
+		"test_instance.foo": {"test_instance.foo"},
+		"test_instance.bar": nil,
+	}
+	for target, want := range tests {
+		t.Run(target, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run([]string{"-json", "-target=" + target, planPath}); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			var got struct {
+				ResourceChanges []struct {
+					Address string
+				} `json:"resource_changes"`
+			}
+			if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+				t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+			}
+			var gotAddrs []string
+			for _, rc := range got.ResourceChanges {
+				gotAddrs = append(gotAddrs, rc.Address)
+			}
+			if !reflect.DeepEqual(gotAddrs, want) {
+				t.Errorf("wrong resource changes %#v; want %#v", gotAddrs, want)
+			}
+		})
+	}
+
+	t.Run("without json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-target=test_instance.foo", planPath}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_moduleJSON(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-module=module.db",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got struct {
+		Values struct {
+			RootModule struct {
+				Resources    []interface{} `json:"resources"`
+				ChildModules []struct {
+					Address   string `json:"address"`
+					Resources []struct {
+						Address string `json:"address"`
+					} `json:"resources"`
+					ChildModules []struct {
+						Address   string `json:"address"`
+						Resources []struct {
+							Address string `json:"address"`
+						} `json:"resources"`
+					} `json:"child_modules"`
+				} `json:"child_modules"`
+			} `json:"root_module"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+
+	root := got.Values.RootModule
+	if len(root.Resources) != 0 {
+		t.Errorf("root module resources were not filtered out\n%s", ui.OutputWriter.String())
+	}
+	if len(root.ChildModules) != 1 || root.ChildModules[0].Address != "module.db" {
+		t.Fatalf("wrong child modules\n%s", ui.OutputWriter.String())
+	}
+	if got, want := len(root.ChildModules[0].Resources), 2; got != want {
+		t.Errorf("wrong number of resources in module.db %d; want %d", got, want)
+	}
+	db := root.ChildModules[0]
+	if len(db.ChildModules) != 1 || len(db.ChildModules[0].Resources) != 1 {
+		t.Fatalf("descendent module was not included\n%s", ui.OutputWriter.String())
+	}
+	if got, want := db.ChildModules[0].Resources[0].Address, "module.db.module.replica.test_instance.foo"; got != want {
+		t.Errorf("wrong descendent resource %q; want %q", got, want)
+	}
+}
+
+func TestShow_module(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-no-color",
+		"-module=module.db",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	got := ui.OutputWriter.String()
+	for _, want := range []string{
+		"# module.db.test_instance.foo[0]:",
+		"# module.db.test_instance.foo[1]:",
+		"# module.db.module.replica.test_instance.foo:",
+		"3 managed resources and 0 data sources across 2 modules.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q\n%s", want, got)
+		}
+	}
+	for _, notWant := range []string{
+		"# test_instance.foo:",
+		"# module.dbx.test_instance.foo:",
+	} {
+		if strings.Contains(got, notWant) {
+			t.Errorf("output includes %q\n%s", notWant, got)
+		}
+	}
+}
+
+func TestShow_type(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, typeName string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: typeName,
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(`{"id":"foo"}`),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		db := addrs.RootModuleInstance.Child("db", addrs.NoKey)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "test_instance")
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "test_other")
+		set(addrs.RootModuleInstance, addrs.DataResourceMode, "test_data_source")
+		set(db, addrs.ManagedResourceMode, "test_instance")
+	})
+	statePath := testStateFile(t, state)
+
+	all := []string{
+		"# test_instance.foo:",
+		"# test_other.foo:",
+		"# data.test_data_source.foo:",
+		"# module.db.test_instance.foo:",
+	}
+	tests := map[string]struct {
+		args []string
+		want []string
+	}{
+		"managed": {
+			[]string{"-type=test_instance"},
+			[]string{"# test_instance.foo:", "# module.db.test_instance.foo:"},
+		},
+		"data": {
+			[]string{"-type=data.test_data_source"},
+			[]string{"# data.test_data_source.foo:"},
+		},
+		"several": {
+			[]string{"-type=test_instance", "-type=test_other"},
+			[]string{"# test_instance.foo:", "# test_other.foo:", "# module.db.test_instance.foo:"},
+		},
+		"with module": {
+			[]string{"-type=test_instance", "-module=module.db"},
+			[]string{"# module.db.test_instance.foo:"},
+		},
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	p := showFixtureProvider()
+	p.GetSchemaReturn.DataSources = map[string]*configschema.Block{
+		"test_data_source": {
+			Attributes: map[string]*configschema.Attribute{
+				"id": {Type: cty.String, Computed: true},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(p),
+					Ui:               ui,
+				},
+			}
+
+			args := append([]string{"-no-color"}, test.args...)
+			if code := c.Run(append(args, statePath)); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+
+			got := ui.OutputWriter.String()
+			want := map[string]bool{}
+			for _, line := range test.want {
+				want[line] = true
+			}
+			for _, line := range all {
+				if strings.Contains(got, line) != want[line] {
+					t.Errorf("output includes %q is %t; want %t\n%s", line, !want[line], want[line], got)
+				}
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(p),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-type=test_nothing", "-type=data.test_instance", statePath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		if want := "No resources of type test_nothing or data.test_instance found."; got != want {
+			t.Errorf("wrong output\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("not found json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(p),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-json", "-type=test_nothing", statePath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if !json.Valid([]byte(got)) || strings.Contains(got, "test_instance") {
+			t.Errorf("wrong output\n%s", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-type=data.", statePath}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "Error parsing resource type: data."; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestShow_where(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(name string, attrs string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(attrs),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		set("web", `{"id":"web","ami":"ami-1"}`)
+		set("db", `{"id":"db","ami":"ami-1"}`)
+		set("other", `{"id":"other","ami":"ami-2"}`)
+		set("empty", `{"id":"empty","ami":null}`)
+	})
+	statePath := testStateFile(t, state)
+
+	all := []string{
+		"# test_instance.web:",
+		"# test_instance.db:",
+		"# test_instance.other:",
+		"# test_instance.empty:",
+	}
+	tests := map[string]struct {
+		args []string
+		want []string
+	}{
+		"one": {
+			[]string{"-where=ami=ami-1"},
+			[]string{"# test_instance.web:", "# test_instance.db:"},
+		},
+		"all of several": {
+			[]string{"-where=ami=ami-1", "-where=id=db"},
+			[]string{"# test_instance.db:"},
+		},
+		"with type": {
+			[]string{"-type=test_instance", "-where=id=other"},
+			[]string{"# test_instance.other:"},
+		},
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			args := append([]string{"-no-color"}, test.args...)
+			if code := c.Run(append(args, statePath)); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+
+			got := ui.OutputWriter.String()
+			want := map[string]bool{}
+			for _, line := range test.want {
+				want[line] = true
+			}
+			for _, line := range all {
+				if strings.Contains(got, line) != want[line] {
+					t.Errorf("output includes %q is %t; want %t\n%s", line, !want[line], want[line], got)
+				}
+			}
+		})
+	}
+
+	for name, arg := range map[string]string{
+		"missing attribute": "-where=instance_type=t3.micro",
+		"null attribute":    "-where=ami=",
+	} {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+			if code := c.Run([]string{arg, statePath}); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			got := strings.TrimSpace(ui.OutputWriter.String())
+			if !strings.HasPrefix(got, "No resource instances found where ") {
+				t.Errorf("wrong output\n%s", got)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-json", "-where=ami=ami-2", statePath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if !strings.Contains(got, `"address":"test_instance.other"`) || strings.Contains(got, "test_instance.web") {
+			t.Errorf("wrong output\n%s", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-where=ami", statePath}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "Error parsing -where condition: ami"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestShow_deposed(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		addr := func(name string) addrs.AbsResourceInstance {
+			return addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: name,
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+		}
+		provider := addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance)
+		obj := func(id string) *states.ResourceInstanceObjectSrc {
+			return &states.ResourceInstanceObjectSrc{
+				AttrsJSON: []byte(`{"id":"` + id + `","ami":"ami-1"}`),
+				Status:    states.ObjectReady,
+			}
+		}
+		s.SetResourceInstanceCurrent(addr("web"), obj("current"), provider)
+		s.SetResourceInstanceDeposed(addr("web"), states.DeposedKey("00000001"), obj("first"), provider)
+		s.SetResourceInstanceDeposed(addr("web"), states.DeposedKey("00000002"), obj("second"), provider)
+		s.SetResourceInstanceCurrent(addr("db"), obj("db"), provider)
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(append(args, statePath))
+	}
+
+	t.Run("found", func(t *testing.T) {
+		ui, code := run("-no-color", "-target=test_instance.web", "-deposed=00000002")
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if !strings.Contains(got, "# test_instance.web: (deposed object 00000002)") || !strings.Contains(got, `"second"`) {
+			t.Errorf("deposed object not shown\n%s", got)
+		}
+		for _, id := range []string{`"current"`, `"first"`, `"db"`} {
+			if strings.Contains(got, id) {
+				t.Errorf("output includes %s\n%s", id, got)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui, code := run("-json", "-target=test_instance.web", "-deposed=00000001")
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if !strings.Contains(got, `"deposed_key":"00000001"`) || strings.Contains(got, `"second"`) || strings.Contains(got, `"current"`) {
+			t.Errorf("wrong output\n%s", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ui, code := run("-target=test_instance.web", "-deposed=ffffffff")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		got, want := ui.ErrorWriter.String(), "No deposed object ffffffff found for test_instance.web. The available deposed keys are: 00000001, 00000002."
+		if !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		ui, code := run("-target=test_instance.db", "-deposed=00000001")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "It has no deposed objects."; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("no target", func(t *testing.T) {
+		ui, code := run("-deposed=00000001")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -deposed option requires -target"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestShow_hcl(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, name string, key addrs.InstanceKey, attrs string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(attrs),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(module),
+			)
+		}
+		child := addrs.RootModuleInstance.Child("child", addrs.IntKey(0))
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "web", addrs.IntKey(10), `{"id":"i-10","ami":"ami-1"}`)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "web", addrs.IntKey(2), `{"id":"i-2","ami":"ami-1"}`)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "db", addrs.StringKey("a"), `{"id":"db \"a\"","ami":"ami-2"}`)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "new", addrs.NoKey, `{"id":null,"ami":"ami-3"}`)
+		set(addrs.RootModuleInstance, addrs.DataResourceMode, "lookup", addrs.NoKey, `{"id":"data","ami":"ami-4"}`)
+		set(child, addrs.ManagedResourceMode, "web", addrs.NoKey, `{"id":"i-child","ami":"ami-1"}`)
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("state", func(t *testing.T) {
+		ui, code := run("-hcl", statePath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		want := strings.TrimSpace(`
+import {
+  to = test_instance.db["a"]
+  id = "db \"a\""
+}
+
+# test_instance.new has no id to import it with.
+
+import {
+  to = test_instance.web[2]
+  id = "i-2"
+}
+
+import {
+  to = test_instance.web[10]
+  id = "i-10"
+}
+
+import {
+  to = module.child[0].test_instance.web
+  id = "i-child"
+}
+`)
+		if got != want {
+			t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		ui, code := run("-hcl", "-where=ami=ami-2", statePath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if !strings.Contains(got, `to = test_instance.db["a"]`) || strings.Contains(got, "test_instance.web") {
+			t.Errorf("wrong output\n%s", got)
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		ui, code := run("-hcl", showFixturePlanFile(t))
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -hcl option can only be used when showing a state"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("with -json", func(t *testing.T) {
+		ui, code := run("-hcl", "-json", statePath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_fromEnv(t *testing.T) {
+	const envVar = "TF_TEST_SHOW_FROM_ENV"
+	defer os.Unsetenv(envVar)
+	defer testChdir(t, testFixturePath("show"))()
+
+	var stateBuf bytes.Buffer
+	if err := writeStateForTesting(testState(), &stateBuf); err != nil {
+		t.Fatal(err)
+	}
+	planSrc, err := ioutil.ReadFile(showFixturePlanFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("state", func(t *testing.T) {
+		os.Setenv(envVar, base64.StdEncoding.EncodeToString(stateBuf.Bytes()))
+		ui, code := run("-no-color", "-from-env="+envVar)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got, want := ui.OutputWriter.String(), "# test_instance.foo:"; !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q\n%s", want, got)
+		}
+	})
+
+	t.Run("wrapped plan", func(t *testing.T) {
+		// The base64 command wraps its output at 76 characters.
+		encoded := base64.StdEncoding.EncodeToString(planSrc)
+		var wrapped strings.Builder
+		for len(encoded) > 76 {
+			wrapped.WriteString(encoded[:76] + "\n")
+			encoded = encoded[76:]
+		}
+		wrapped.WriteString(encoded + "\n")
+		os.Setenv(envVar, wrapped.String())
+
+		ui, code := run("-json", "-from-env="+envVar)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got, want := ui.OutputWriter.String(), `"resource_changes":[{"address":"test_instance.foo"`; !strings.Contains(got, want) {
+			t.Errorf("output does not contain %s\n%s", want, got)
+		}
+	})
+
+	tests := map[string]struct {
+		value   *string
+		wantErr string
+	}{
+		"unset": {
+			nil,
+			"is not set",
+		},
+		"empty": {
+			new(string),
+			"is empty",
+		},
+		"invalid base64": {
+			func() *string { v := "not base64!"; return &v }(),
+			"is not valid base64",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.value == nil {
+				os.Unsetenv(envVar)
+			} else {
+				os.Setenv(envVar, *test.value)
+			}
+			ui, code := run("-from-env=" + envVar)
+			if code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+			want := "the environment variable " + envVar + " given by -from-env " + test.wantErr
+			if got := ui.ErrorWriter.String(); !strings.Contains(got, want) {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+
+	t.Run("with a path", func(t *testing.T) {
+		ui, code := run("-from-env="+envVar, "terraform.tfstate")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -from-env option takes no path"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestShow_backup(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	write := func(path string, s *states.State) {
+		t.Helper()
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := writeStateForTesting(s, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(DefaultStateFilename, states.NewState())
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	ui, code := run("-backup", "-addresses")
+	if code != 1 {
+		t.Fatalf("wrong exit status %d without a backup; want 1\n%s", code, ui.OutputWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "No state backup found at terraform.tfstate.backup."; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+
+	write(DefaultStateFilename+DefaultBackupExtension, testState())
+	ui, code = run("-backup", "-addresses")
+	if code != 0 {
+		t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+	}
+	if got, want := ui.OutputWriter.String(), "test_instance.foo\n"; got != want {
+		t.Errorf("wrong output\ngot:  %q\nwant: %q", got, want)
+	}
+
+	if ui, code := run("-backup", DefaultStateFilename); code != 1 {
+		t.Fatalf("wrong exit status %d with a path; want 1\n%s", code, ui.OutputWriter.String())
+	}
+}
+
+func TestShow_addresses(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, mode addrs.ResourceMode, name string, key addrs.InstanceKey) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: mode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(key).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(`{"id":"x","ami":"ami-1"}`),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(module),
+			)
+		}
+		child := addrs.RootModuleInstance.Child("child", addrs.NoKey)
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "web", addrs.IntKey(10))
+		set(addrs.RootModuleInstance, addrs.ManagedResourceMode, "web", addrs.IntKey(2))
+		set(addrs.RootModuleInstance, addrs.DataResourceMode, "lookup", addrs.NoKey)
+		set(child, addrs.ManagedResourceMode, "db", addrs.StringKey("a"))
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"all": {
+			[]string{"-addresses", statePath},
+			"data.test_instance.lookup\ntest_instance.web[2]\ntest_instance.web[10]\nmodule.child.test_instance.db[\"a\"]\n",
+		},
+		"module": {
+			[]string{"-addresses", "-module=module.child", statePath},
+			"module.child.test_instance.db[\"a\"]\n",
+		},
+		"no matches": {
+			[]string{"-addresses", "-type=test_other", statePath},
+			"",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui, code := run(test.args...)
+			if code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			if got := ui.OutputWriter.String(); got != test.want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("plan", func(t *testing.T) {
+		ui, code := run("-addresses", showFixturePlanFile(t))
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -addresses option can only be used when showing a state"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("with -json", func(t *testing.T) {
+		ui, code := run("-addresses", "-json", statePath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_brief(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(module addrs.ModuleInstance, name string, ami string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(addrs.NoKey).Absolute(module),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(`{"id":"x","ami":"` + ami + `"}`),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(module),
+			)
+		}
+		set(addrs.RootModuleInstance, "web", "ami-web")
+		set(addrs.RootModuleInstance.Child("child", addrs.NoKey), "db", "ami-db")
+		s.SetOutputValue(addrs.OutputValue{Name: "secret"}.Absolute(addrs.RootModuleInstance), cty.StringVal("output-value"), false)
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"all": {
+			[]string{"-brief", statePath},
+			"test_instance.web (test_instance)\n\nmodule.child.test_instance.db (test_instance)\n\n2 managed resources and 0 data sources across 2 modules.\n",
+		},
+		"module": {
+			[]string{"-brief", "-module=module.child", statePath},
+			"module.child.test_instance.db (test_instance)\n\n1 managed resource and 0 data sources across 1 module.\n",
+		},
+		"target": {
+			[]string{"-brief", "-target=test_instance.web", statePath},
+			"test_instance.web (test_instance)\n",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui, code := run(test.args...)
+			if code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			got := ui.OutputWriter.String()
+			for _, value := range []string{"ami-web", "ami-db", "output-value", "ami", "="} {
+				if strings.Contains(got, value) {
+					t.Errorf("brief output contains %q\n%s", value, got)
+				}
+			}
+			if got != test.want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("plan", func(t *testing.T) {
+		ui, code := run("-brief", showFixturePlanFile(t))
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -brief option can only be used when showing a state"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("with -json", func(t *testing.T) {
+		ui, code := run("-brief", "-json", statePath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_formatTable(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		set := func(name string, status states.ObjectStatus, attrs string) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(attrs),
+					Status:    status,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		set("web", states.ObjectReady, `{"id":"i-web","ami":"ami-1"}`)
+		set("db", states.ObjectTainted, `{"id":"i-db","ami":"ami-2"}`)
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("table", func(t *testing.T) {
+		ui, code := run("-no-color", "-format=table", statePath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		want := strings.TrimSpace(`
+ADDRESS            TYPE           ID     STATUS
+test_instance.db   test_instance  i-db   tainted
+test_instance.web  test_instance  i-web  ready
+`)
+		if got != want {
+			t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui, code := run("-format=json", statePath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &doc); err != nil {
+			t.Fatalf("output is not JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		ui, code := run("-format=table", showFixturePlanFile(t))
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -format=table option can only be used when showing a state"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ui, code := run("-format=yaml", statePath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -format option must be one of tree, table or json."; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("with -json", func(t *testing.T) {
+		ui, code := run("-format=table", "-json", statePath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_diff(t *testing.T) {
+	stateFile := func(instances map[string]string) string {
+		t.Helper()
+		return testStateFile(t, states.BuildState(func(s *states.SyncState) {
+			for name, attrs := range instances {
+				s.SetResourceInstanceCurrent(
+					addrs.Resource{
+						Mode: addrs.ManagedResourceMode,
+						Type: "test_instance",
+						Name: name,
+					}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+					&states.ResourceInstanceObjectSrc{
+						AttrsJSON: []byte(attrs),
+						Status:    states.ObjectReady,
+					},
+					addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+				)
+			}
+		}))
+	}
+	oldPath := stateFile(map[string]string{
+		"web":  `{"id":"i-web","ami":"ami-1"}`,
+		"db":   `{"id":"i-db","ami":"ami-1"}`,
+		"same": `{"id":"i-same","ami":"ami-1"}`,
+	})
+	newPath := stateFile(map[string]string{
+		"web":   `{"id":"i-web","ami":"ami-2"}`,
+		"cache": `{"id":"i-cache","ami":"ami-1"}`,
+		"same":  `{"id":"i-same","ami":"ami-1"}`,
+	})
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("human", func(t *testing.T) {
+		ui, code := run("-no-color", "-diff", oldPath, newPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		want := strings.TrimSpace(`
+  + test_instance.cache
+      ami: "ami-1"
+      id:  "i-cache"
+
+  - test_instance.db
+
+  ~ test_instance.web
+      ami: "ami-1" => "ami-2"
+      # (1 unchanged attribute hidden)
+
+Difference: 1 added, 1 changed, 1 removed.
+`)
+		if got != want {
+			t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("show all", func(t *testing.T) {
+		ui, code := run("-no-color", "-show-all", "-diff", oldPath, newPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		want := `
+  ~ test_instance.web
+      ami: "ami-1" => "ami-2"
+      id:  "i-web"
+`
+		if got := ui.OutputWriter.String(); !strings.Contains(got, want) {
+			t.Errorf("wrong output\ngot:\n%s\nwant it to include:\n%s", got, want)
+		}
+	})
+
+	t.Run("show all for a state", func(t *testing.T) {
+		ui, code := run("-show-all", oldPath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -show-all option can only be used when showing a plan"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui, code := run("-json", "-diff", oldPath, newPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		var doc struct {
+			FormatVersion   string `json:"format_version"`
+			ResourceChanges []struct {
+				Address string `json:"address"`
+				Change  struct {
+					Actions []string `json:"actions"`
+				} `json:"change"`
+			} `json:"resource_changes"`
+		}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &doc); err != nil {
+			t.Fatalf("output is not JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		var got []string
+		for _, rc := range doc.ResourceChanges {
+			got = append(got, rc.Address+" "+strings.Join(rc.Change.Actions, ","))
+		}
+		want := []string{
+			"test_instance.cache create",
+			"test_instance.db delete",
+			"test_instance.web update",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong changes\ngot:  %#v\nwant: %#v", got, want)
+		}
+		if doc.FormatVersion != jsonplan.FormatVersion {
+			t.Errorf("wrong format_version %q", doc.FormatVersion)
+		}
+	})
+
+	t.Run("no differences", func(t *testing.T) {
+		ui, code := run("-diff", oldPath, oldPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got, want := strings.TrimSpace(ui.OutputWriter.String()), "The states have no differences."; got != want {
+			t.Errorf("wrong output\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		planPath := showFixturePlanFile(t)
+		ui, code := run("-diff", oldPath, planPath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "but "+planPath+" is a plan file"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("one path", func(t *testing.T) {
+		ui, code := run("-diff", oldPath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -diff option requires the paths to two state files"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestShow_run(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("local backend", func(t *testing.T) {
+		ui, code := run("-run=run-abc123")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), `The -run option requires the "remote" backend`; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("with a path", func(t *testing.T) {
+		ui, code := run("-run=run-abc123", "terraform.tfstate")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "The -run option takes no path"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+}
+
+func TestRunPlanChanges(t *testing.T) {
+	src := []byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "test_instance.new",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {"ami": "bar", "tags": {"Name": "new"}},
+        "after_unknown": {"id": true, "tags": {}},
+        "after_sensitive": {"password": true}
+      }
+    },
+    {
+      "address": "test_instance.same",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {
+        "actions": ["no-op"],
+        "before": {"id": "same", "ami": "bar"},
+        "after": {"id": "same", "ami": "bar"}
+      }
+    },
+    {
+      "address": "test_instance.changed",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {
+        "actions": ["update"],
+        "before": {"id": "changed", "ami": "foo", "password": "secret"},
+        "after": {"id": "changed", "ami": "bar", "password": "hunter2"},
+        "after_unknown": {}
+      }
+    },
+    {
+      "address": "test_instance.replaced",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"id": "replaced", "ami": "foo"},
+        "after": {"ami": "bar"},
+        "after_unknown": {"id": true},
+        "replace_paths": [["ami"]]
+      }
+    },
+    {
+      "address": "test_instance.replaced",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "deposed": "deadbeef",
+      "change": {
+        "actions": ["delete"],
+        "before": {"id": "old", "ami": "foo"},
+        "after": null
+      }
+    },
+    {
+      "address": "data.test_data_source.lookup",
+      "mode": "data",
+      "type": "test_data_source",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {
+        "actions": ["read"],
+        "before": null,
+        "after": {"filter": "x"},
+        "after_unknown": {"result": true}
+      }
+    },
+    {
+      "address": "test_instance.future",
+      "mode": "managed",
+      "type": "test_instance",
+      "provider_name": "registry.terraform.io/hashicorp/test",
+      "change": {"actions": ["forget"], "before": {"id": "future"}}
+    }
+  ],
+  "output_changes": {
+    "ip": {"actions": ["create"], "before": null, "after": "10.0.0.1", "sensitive": false}
+  }
+}`)
+	changes, schemas, err := runPlanChanges(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	dispPlan := format.NewPlan(changes, schemas)
+	color := &colorstring.Colorize{Colors: colorstring.DefaultColors, Disable: true}
+	got := strings.TrimSpace(dispPlan.Format(color)) + "\n\n" + dispPlan.Summary(color)
+	want := strings.TrimSpace(`
+<= data.test_data_source.lookup
+      filter:    "x"
+      result:    <computed>
+
+  ~ test_instance.changed
+      ami:       "foo" => "bar"
+      # (1 unchanged attribute hidden)
+      password:  (sensitive value) => (sensitive value) (attribute changed)
+
+  + test_instance.new
+      ami:       "bar"
+      id:        <computed>
+      tags.Name: "new"
+
+-/+ test_instance.replaced (new resource required) (destroy before create)
+      ami:       "foo" => "bar" (forces new resource)
+      id:        "replaced" => <computed>
+
+  - test_instance.replaced (deposed)
+
+Changes to Outputs:
+  + ip = "10.0.0.1"
+
+Plan: 2 to add, 1 to change, 2 to destroy.
+`)
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	changes, _, err = runPlanChanges([]byte(`{"format_version":"0.1"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !format.NewPlan(changes, schemas).Empty() {
+		t.Errorf("plan with no changes is not empty")
+	}
+
+	if _, _, err := runPlanChanges([]byte(`{"resource_changes":[{"address":"not an address","change":{"actions":["create"]}}]}`)); err == nil {
+		t.Errorf("succeeded with an invalid address; want error")
+	}
+}
+
+func TestObjectMatchesWhere(t *testing.T) {
+	tests := map[string]struct {
+		obj  *states.ResourceInstanceObjectSrc
+		want bool
+	}{
+		"string": {
+			&states.ResourceInstanceObjectSrc{AttrsJSON: []byte(`{"a":"8080","b":"true"}`)},
+			true,
+		},
+		"number and bool": {
+			&states.ResourceInstanceObjectSrc{AttrsJSON: []byte(`{"a":8080,"b":true}`)},
+			true,
+		},
+		"different": {
+			&states.ResourceInstanceObjectSrc{AttrsJSON: []byte(`{"a":8081,"b":true}`)},
+			false,
+		},
+		"collection": {
+			&states.ResourceInstanceObjectSrc{AttrsJSON: []byte(`{"a":["8080"],"b":true}`)},
+			false,
+		},
+		"flatmap": {
+			&states.ResourceInstanceObjectSrc{AttrsFlat: map[string]string{"a": "8080", "b": "true"}},
+			true,
+		},
+		"flatmap missing": {
+			&states.ResourceInstanceObjectSrc{AttrsFlat: map[string]string{"a": "8080"}},
+			false,
+		},
+	}
+
+	where := []showWhere{{"a", "8080"}, {"b", "true"}}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := objectMatchesWhere(test.obj, where); got != test.want {
+				t.Errorf("wrong result %t; want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestShow_moduleNotFound(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-module=module.web",
+		statePath,
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "No resources found in module module.web."; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestShow_invalidFile(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, "garbage")
+	if err := ioutil.WriteFile(path, []byte("\x00garbage\xff"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{path}, {"-json", path}} {
+		ui := new(cli.MockUi)
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+
+		if code := c.Run(args); code != 2 {
+			t.Fatalf("wrong exit status %d for %q; want 2\n%s", code, args, ui.ErrorWriter.String())
+		}
+		// With -json, the error is included in the JSON output instead.
+		got := ui.ErrorWriter.String()
+		if args[0] == "-json" {
+			got = ui.OutputWriter.String()
+		}
+		if want := "couldn't read the given file as a state or plan file"; !strings.Contains(got, want) {
+			t.Errorf("error does not contain %q\n%s", want, got)
+		}
+	}
+}
+
+func TestShow_gzipPlanJSON(t *testing.T) {
+	src, err := ioutil.ReadFile(showFixturePlanFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: &buf,
+	}
+
+	args := []string{
+		"-json",
+		"-",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if _, ok := got["resource_changes"]; !ok {
+		t.Fatalf("output has no resource changes\n%s", ui.OutputWriter.String())
+	}
+}
+
+func TestShow_gzipState(t *testing.T) {
+	src, err := ioutil.ReadFile(testStateFile(t, testState()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, "terraform.tfstate.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-no-color",
+		path,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+	if got, want := ui.OutputWriter.String(), "# test_instance.foo:"; !strings.Contains(got, want) {
+		t.Errorf("output does not contain %q\n%s", want, got)
+	}
+}
+
+func TestShow_gzipCorrupt(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+		input: bytes.NewReader([]byte{0x1f, 0x8b, 0x08, 0x00, 0xde, 0xad}),
+	}
+
+	if code := c.Run([]string{"-"}); code != 2 {
+		t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.ErrorWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "appears gzip-compressed but could not be decompressed"; !strings.Contains(got, want) {
+		t.Errorf("error does not contain %q\n%s", want, got)
+	}
+}
+
+func TestShow_check(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	garbagePath := filepath.Join(td, "garbage")
+	if err := ioutil.WriteFile(garbagePath, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	futurePath := filepath.Join(td, "future.tfstate")
+	if err := ioutil.WriteFile(futurePath, []byte(`{"version": 99, "serial": 1, "lineage": "x"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		args []string
+		want int
+	}{
+		"plan":           {[]string{"-check", showFixturePlanFile(t)}, 0},
+		"state":          {[]string{"-check", testStateFile(t, testState())}, 0},
+		"garbage":        {[]string{"-check", garbagePath}, 2},
+		"future version": {[]string{"-check", futurePath}, 2},
+		"no path":        {[]string{"-check"}, 1},
+		"with json":      {[]string{"-check", "-json", testStateFile(t, testState())}, 1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			code := c.Run(test.args)
+			if code != test.want {
+				t.Fatalf("wrong exit status %d; want %d\n%s", code, test.want, ui.ErrorWriter.String())
+			}
+			if got := ui.OutputWriter.String(); got != "" {
+				t.Errorf("unexpected output\n%s", got)
+			}
+			if code != 0 && ui.ErrorWriter.String() == "" {
+				t.Errorf("no error message for failure")
+			}
+		})
+	}
+}
+
+func TestShow_jsonStream(t *testing.T) {
+	statePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json-stream",
+		"-module=module.db",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var gotTypes, gotAddrs []string
+	for _, line := range strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n") {
+		var obj struct {
+			Type     string `json:"type"`
+			Resource struct {
+				Address string `json:"address"`
+			} `json:"resource"`
+		}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("line is not valid JSON: %s\n%s", err, line)
+		}
+		gotTypes = append(gotTypes, obj.Type)
+		if obj.Type == "resource" {
+			gotAddrs = append(gotAddrs, obj.Resource.Address)
+		}
+	}
+
+	wantTypes := []string{"header", "resource", "resource", "resource"}
+	if !reflect.DeepEqual(gotTypes, wantTypes) {
+		t.Errorf("wrong line types\ngot:  %#v\nwant: %#v", gotTypes, wantTypes)
+	}
+	wantAddrs := []string{
+		"module.db.test_instance.foo[0]",
+		"module.db.test_instance.foo[1]",
+		"module.db.module.replica.test_instance.foo",
+	}
+	if !reflect.DeepEqual(gotAddrs, wantAddrs) {
+		t.Errorf("wrong resources\ngot:  %#v\nwant: %#v", gotAddrs, wantAddrs)
+	}
+}
+
+func TestShow_jsonStreamInvalid(t *testing.T) {
+	tests := map[string][]string{
+		"with json":  {"-json-stream", "-json", testStateFile(t, testState())},
+		"with check": {"-json-stream", "-check", testStateFile(t, testState())},
+		"plan":       {"-json-stream", showFixturePlanFile(t)},
+	}
+
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+			if got, want := ui.ErrorWriter.String(), "-json-stream"; !strings.Contains(got, want) {
+				t.Errorf("error does not mention %q\n%s", want, got)
+			}
+		})
+	}
+}
+
+func TestShow_noSensitive(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want int
+	}{
+		"state json":   {[]string{"-json", "-no-sensitive", testStateFile(t, testState())}, 0},
+		"state stream": {[]string{"-json-stream", "-no-sensitive", testStateFile(t, testState())}, 0},
+		"plan json":    {[]string{"-json", "-no-sensitive", showFixturePlanFile(t)}, 0},
+		"without json": {[]string{"-no-sensitive", testStateFile(t, testState())}, 1},
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run(test.args); code != test.want {
+				t.Fatalf("wrong exit status %d; want %d\n%s", code, test.want, ui.ErrorWriter.String())
+			}
+			if test.want != 0 {
+				return
+			}
+			for _, line := range strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n") {
+				if !json.Valid([]byte(line)) {
+					t.Fatalf("output is not valid JSON\n%s", ui.OutputWriter.String())
+				}
+			}
+		})
+	}
+}
+
+func TestShow_jsonNoValues(t *testing.T) {
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	ui, code := run("-json", "-json-no-values", showFixturePlanFile(t))
+	if code != 0 {
+		t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+	}
+	var got struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Change  struct {
+				After map[string]interface{} `json:"after"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if len(got.ResourceChanges) != 1 || got.ResourceChanges[0].Address != "test_instance.foo" {
+		t.Fatalf("wrong resource changes\n%s", ui.OutputWriter.String())
+	}
+	if after := got.ResourceChanges[0].Change.After; len(after) == 0 || after["ami"] != nil {
+		t.Errorf("wrong planned value %#v; want ami to be null", after)
+	}
+	if strings.Contains(ui.OutputWriter.String(), `"bar"`) {
+		t.Errorf("output contains a value\n%s", ui.OutputWriter.String())
+	}
+
+	for name, args := range map[string][]string{
+		"without json": {"-json-no-values", showFixturePlanFile(t)},
+		"state":        {"-json", "-json-no-values", testStateFile(t, testState())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if ui, code := run(args...); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_jsonConfig(t *testing.T) {
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	ui, code := run("-json", "-json-config", showFixturePlanFile(t))
+	if code != 0 {
+		t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+	}
+	var got struct {
+		Configuration *struct {
+			RootModule struct {
+				Resources []struct {
+					Address     string                            `json:"address"`
+					Expressions map[string]map[string]interface{} `json:"expressions"`
+				} `json:"resources"`
+			} `json:"root_module"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if got.Configuration == nil {
+		t.Fatalf("no configuration in the output\n%s", ui.OutputWriter.String())
+	}
+	resources := got.Configuration.RootModule.Resources
+	if len(resources) != 1 || resources[0].Address != "test_instance.foo" {
+		t.Fatalf("wrong resources\n%s", ui.OutputWriter.String())
+	}
+	if ami := resources[0].Expressions["ami"]["constant_value"]; ami != "bar" {
+		t.Errorf("wrong ami expression %#v; want constant value \"bar\"", resources[0].Expressions["ami"])
+	}
+
+	ui, code = run("-json", showFixturePlanFile(t))
+	if code != 0 {
+		t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), `"configuration"`) {
+		t.Errorf("configuration included without -json-config\n%s", ui.OutputWriter.String())
+	}
+
+	for name, args := range map[string][]string{
+		"without json": {"-json-config", showFixturePlanFile(t)},
+		"state":        {"-json", "-json-config", testStateFile(t, testState())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if ui, code := run(args...); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_jsonChangesOnly(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want int
+	}{
+		"plan json":    {[]string{"-json", "-json-changes-only", showFixturePlanFile(t)}, 0},
+		"without json": {[]string{"-json-changes-only", showFixturePlanFile(t)}, 1},
+		"state":        {[]string{"-json", "-json-changes-only", testStateFile(t, testState())}, 1},
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run(test.args); code != test.want {
+				t.Fatalf("wrong exit status %d; want %d\n%s", code, test.want, ui.ErrorWriter.String())
+			}
+			if test.want != 0 {
+				return
+			}
+			var got struct {
+				ResourceChanges []struct {
+					Address string
+				} `json:"resource_changes"`
+			}
+			if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+				t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+			}
+			if len(got.ResourceChanges) != 1 || got.ResourceChanges[0].Address != "test_instance.foo" {
+				t.Errorf("wrong resource changes %#v", got.ResourceChanges)
+			}
+		})
+	}
+}
+
+func TestShow_jsonStats(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"plan":   {[]string{"-json", "-json-stats", showFixturePlanFile(t)}, "JSON output: 1 resource change, 0 output changes, %s"},
+		"state":  {[]string{"-json", "-json-stats", testStateFile(t, testState())}, "JSON output: 1 resource, %s"},
+		"pretty": {[]string{"-json", "-json-pretty", "-json-stats", testStateFile(t, testState())}, "JSON output: 1 resource, %s"},
+		"multiple": {
+			[]string{"-json", "-json-stats", showFixturePlanFile(t), testStateFile(t, testState())},
+			"JSON output: 1 resource change, 0 output changes, 1 resource, %s",
+		},
+	}
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run(test.args); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			out := strings.TrimSuffix(ui.OutputWriter.String(), "\n")
+			if !json.Valid([]byte(out)) {
+				t.Fatalf("output is not valid JSON:\n%s", out)
+			}
+			got := strings.TrimSpace(ui.ErrorWriter.String())
+			if want := fmt.Sprintf(test.want, formatByteSize(len(out))); got != want {
+				t.Errorf("wrong summary\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+
+	t.Run("without json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-json-stats", showFixturePlanFile(t)}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int]string{
+		0:                      "0 bytes",
+		1:                      "1 byte",
+		1023:                   "1023 bytes",
+		1024:                   "1.0 KiB",
+		1536:                   "1.5 KiB",
+		3355443:                "3.2 MiB",
+		5 * 1024 * 1024 * 1024: "5.0 GiB",
+	}
+	for n, want := range tests {
+		if got := formatByteSize(n); got != want {
+			t.Errorf("wrong result for %d\ngot:  %s\nwant: %s", n, got, want)
+		}
+	}
+}
+
+func TestShow_jsonNewerSchemaVersion(t *testing.T) {
+	state := states.BuildState(func(s *states.SyncState) {
+		for name, version := range map[string]uint64{"foo": 5, "bar": 0} {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: name,
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{
+					Status:        states.ObjectReady,
+					SchemaVersion: version,
+					AttrsJSON:     []byte(`{"id":"` + name + `"}`),
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+	statePath := testStateFile(t, state)
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			Color:            false,
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+	if code := c.Run([]string{"-json", statePath}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	if !json.Valid(ui.OutputWriter.Bytes()) {
+		t.Fatalf("output is not valid JSON\n%s", ui.OutputWriter.String())
+	}
+	diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes())
+	if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Summary != "Objects recorded by a newer provider" {
+		t.Fatalf("missing warning\n%#v", diags)
+	}
+	warning := diags[0].Detail
+	if !strings.Contains(warning, "test_instance.foo (schema version 5, provider supports 0)") {
+		t.Errorf("warning does not list test_instance.foo\n%s", warning)
+	}
+	if strings.Contains(warning, "test_instance.bar") {
+		t.Errorf("warning lists test_instance.bar, which is up to date\n%s", warning)
+	}
+}
+
+func TestShow_targetedPlan(t *testing.T) {
+	_, snap := testModuleWithSnapshot(t, "show")
+	plan := testPlan(t)
+	plan.TargetAddrs = []addrs.Targetable{
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_instance",
+			Name: "foo",
+		}.Absolute(addrs.RootModuleInstance),
+		addrs.RootModuleInstance.Child("db", addrs.NoKey),
+	}
+	targetedPath := testPlanFile(t, snap, states.NewState(), plan)
+	fullPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("human", func(t *testing.T) {
+		ui, code := run("-no-color", targetedPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.ErrorWriter.String()
+		for _, want := range []string{
+			"Resource targeting is in effect",
+			"  - module.db\n  - test_instance.foo\n",
+			"It is partial",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("warning does not include %q\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui, code := run("-json", targetedPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		var got struct {
+			ApplyablePartial bool     `json:"applyable_partial"`
+			Targets          []string `json:"targets"`
+		}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		if !got.ApplyablePartial {
+			t.Errorf("applyable_partial is not set\n%s", ui.OutputWriter.String())
+		}
+		if want := []string{"module.db", "test_instance.foo"}; !reflect.DeepEqual(got.Targets, want) {
+			t.Errorf("wrong targets %#v; want %#v", got.Targets, want)
+		}
+		diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes())
+		if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Summary != "Resource targeting is in effect" {
+			t.Errorf("wrong diagnostics\n%#v", diags)
+		}
+	})
+
+	t.Run("not targeted", func(t *testing.T) {
+		ui, code := run("-no-color", fullPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); strings.Contains(got, "targeting") {
+			t.Errorf("unexpected warning for a plan without targets\n%s", got)
+		}
+
+		ui, code = run("-json", fullPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		if got["applyable_partial"] != false {
+			t.Errorf("wrong applyable_partial %#v; want false", got["applyable_partial"])
+		}
+		if _, ok := got["targets"]; ok {
+			t.Errorf("unexpected targets %#v", got["targets"])
+		}
+		if diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes()); len(diags) != 0 {
+			t.Errorf("unexpected diagnostics\n%#v", diags)
+		}
+	})
+}
+
+func TestShow_jsonDiagnostics(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	statePath := testStateFile(t, testState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	t.Run("with document", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-json", "-state=" + statePath, planPath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); got != "" {
+			t.Errorf("unexpected text on stderr\n%s", got)
+		}
+
+		var got struct {
+			FormatVersion string `json:"format_version"`
+		}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		if got.FormatVersion == "" {
+			t.Errorf("diagnostics replaced the plan\n%s", ui.OutputWriter.String())
+		}
+		diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes())
+		if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Summary != "Showing the plan against a different state" {
+			t.Errorf("wrong diagnostics\n%#v", diags)
+		}
+	})
+
+	t.Run("without document", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		c.input = strings.NewReader("not a plan or a state")
+		if code := c.Run([]string{"-json", "-"}); code != 2 {
+			t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.ErrorWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); got != "" {
+			t.Errorf("unexpected text on stderr\n%s", got)
+		}
+
+		var got map[string]json.RawMessage
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		if len(got) != 1 {
+			t.Errorf("output has properties other than diagnostics\n%s", ui.OutputWriter.String())
+		}
+		diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes())
+		if len(diags) != 1 || diags[0].Severity != "error" || !strings.Contains(diags[0].Summary, "couldn't read the given file as a state or plan file") {
+			t.Fatalf("wrong diagnostics\n%#v", diags)
+		}
+		if diags[0].StateError == "" || diags[0].PlanError == "" {
+			t.Errorf("diagnostic is missing the errors of each format\n%#v", diags[0])
+		}
+		if !strings.Contains(diags[0].Detail, diags[0].StateError) || !strings.Contains(diags[0].Detail, diags[0].PlanError) {
+			t.Errorf("errors of each format differ from the detail\n%#v", diags[0])
+		}
+	})
+
+	t.Run("without -json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-state=" + statePath, planPath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "Showing the plan against a different state"; !strings.Contains(got, want) {
+			t.Errorf("warning does not contain %q\n%s", want, got)
+		}
+	})
+}
+
+func TestShow_maxAge(t *testing.T) {
+	_, snap := testModuleWithSnapshot(t, "show")
+	plan := testPlan(t)
+	plan.Timestamp = time.Now().Add(-48 * time.Hour)
+	planPath := testPlanFile(t, snap, states.NewState(), plan)
+	defer testChdir(t, testFixturePath("show"))()
+
+	for name, test := range map[string]struct {
+		args []string
+		want bool
+	}{
+		"stale":     {[]string{"-max-age=24h"}, true},
+		"not stale": {[]string{"-max-age=72h"}, false},
+		"no flag":   {nil, false},
+		"check":     {[]string{"-check", "-max-age=1h"}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+			if code := c.Run(append(test.args, planPath)); code != 0 {
+				t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+			}
+			got := strings.Contains(ui.ErrorWriter.String(), "The plan may be stale")
+			if got != test.want {
+				t.Errorf("wrong warning; want warning: %t\n%s", test.want, ui.ErrorWriter.String())
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-json", "-max-age=24h", planPath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		diags := showJSONDiagnostics(t, ui.OutputWriter.Bytes())
+		if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Summary != "The plan may be stale" {
+			t.Errorf("wrong diagnostics\n%#v", diags)
+		}
+	})
+
+	for _, arg := range []string{"-max-age=-1h", "-max-age=a day"} {
+		t.Run(arg, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+			if code := c.Run([]string{arg, planPath}); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_onlyErrors(t *testing.T) {
+	_, snap := testModuleWithSnapshot(t, "show")
+	plan := testPlan(t)
+	plan.Timestamp = time.Now().Add(-48 * time.Hour)
+	planPath := testPlanFile(t, snap, states.NewState(), plan)
+	defer testChdir(t, testFixturePath("show"))()
+
+	t.Run("stale plan", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-only-errors", "-max-age=24h", planPath}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); got != "" {
+			t.Errorf("unexpected warning\n%s", got)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-only-errors", "missing.tfplan"}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.ErrorWriter.String())
+		}
+		if ui.ErrorWriter.String() == "" {
+			t.Error("no error shown")
+		}
+	})
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Warning, "First warning", ""))
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "First error", ""))
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Warning, "Second warning", ""))
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Second error", ""))
+
+	for _, onlyErrors := range []bool{false, true} {
+		t.Run(fmt.Sprintf("mixed diagnostics %t", onlyErrors), func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta:       Meta{Ui: ui},
+				onlyErrors: onlyErrors,
+			}
+			c.showDiagnostics(diags)
+
+			got := ui.ErrorWriter.String()
+			for _, summary := range []string{"First error", "Second error"} {
+				if !strings.Contains(got, summary) {
+					t.Errorf("error %q not shown\n%s", summary, got)
+				}
+			}
+			for _, summary := range []string{"First warning", "Second warning"} {
+				if strings.Contains(got, summary) == onlyErrors {
+					t.Errorf("wrong output for warning %q with -only-errors %t\n%s", summary, onlyErrors, got)
+				}
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta:       Meta{Ui: ui},
+			onlyErrors: true,
+			jsonUi:     &showJSONUi{Ui: ui},
+		}
+		c.showDiagnostics(diags)
+		if got := c.jsonUi.diags; len(got) != 2 || got[0].Description().Summary != "First error" || got[1].Description().Summary != "Second error" {
+			t.Errorf("wrong diagnostics\n%#v", got)
+		}
+	})
+}
+
+func TestShow_watch(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	f, err := os.Create(DefaultStateFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeStateForTesting(testState(), f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("not a terminal", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+			terminal: func() bool { return false },
+		}
+		if code := c.Run([]string{"-watch"}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if strings.Contains(got, "\033[2J") {
+			t.Errorf("screen cleared when not a terminal\n%q", got)
+		}
+		if !strings.Contains(got, "test_instance.foo") {
+			t.Errorf("state not shown\n%s", got)
+		}
+	})
+
+	t.Run("terminal", func(t *testing.T) {
+		shutdownCh := make(chan struct{})
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+				ShutdownCh:       shutdownCh,
+			},
+			terminal: func() bool { return true },
+		}
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			close(shutdownCh)
+		}()
+		if code := c.Run([]string{"-watch", "-interval=10ms"}); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if n := strings.Count(got, "\033[2J"); n < 2 {
+			t.Errorf("state shown %d times; want at least 2\n%s", n, got)
+		}
+		if !strings.Contains(got, "Every 10ms:") {
+			t.Errorf("no header shown\n%s", got)
+		}
+	})
+
+	for _, args := range [][]string{
+		{"-watch", DefaultStateFilename},
+		{"-watch", "-json"},
+		{"-watch", "-backup"},
+		{"-watch", "-interval=0"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(testProvider()),
+					Ui:               ui,
+				},
+			}
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_decryptCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need a POSIX shell")
+	}
+
+	src, err := ioutil.ReadFile(testStateFile(t, testState()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The "encryption" here is rot13, which tr can undo.
+	rot13 := func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}
+	encryptedPath := testTempFile(t)
+	if err := ioutil.WriteFile(encryptedPath, []byte(strings.Map(rot13, string(src))), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer testChdir(t, testFixturePath("show"))()
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("decrypted", func(t *testing.T) {
+		ui, code := run("-decrypt-cmd=tr A-Za-z N-ZA-Mn-za-m", encryptedPath)
+		if code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		if got := ui.OutputWriter.String(); !strings.Contains(got, "test_instance.foo") {
+			t.Errorf("wrong output\n%s", got)
+		}
+	})
+
+	t.Run("not decrypted", func(t *testing.T) {
+		ui, code := run(encryptedPath)
+		if code != 2 {
+			t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.OutputWriter.String())
+		}
+	})
+
+	t.Run("command fails", func(t *testing.T) {
+		ui, code := run("-decrypt-cmd=echo wrong key >&2; exit 3", encryptedPath)
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		got := ui.ErrorWriter.String()
+		if !strings.Contains(got, "Error decrypting file") || !strings.Contains(got, "wrong key") {
+			t.Errorf("wrong error\n%s", got)
+		}
+	})
+
+	t.Run("no path", func(t *testing.T) {
+		ui, code := run("-decrypt-cmd=cat")
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); !strings.Contains(got, "can only be used together with the path to a file") {
+			t.Errorf("wrong error\n%s", got)
+		}
+	})
+}
+
+func TestShow_jsonCache(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	defer testChdir(t, testFixturePath("show"))()
+
+	cacheDir := testTempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	run := func(args ...string) string {
+		t.Helper()
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		args = append([]string{"-json", "-json-cache=" + cacheDir}, args...)
+		if code := c.Run(append(args, planPath)); code != 0 {
+			t.Fatalf("wrong exit status %d; want 0\n%s", code, ui.ErrorWriter.String())
+		}
+		return strings.TrimSpace(ui.OutputWriter.String())
+	}
+	cacheFiles := func() []string {
+		t.Helper()
+		files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	// A miss marshals the plan and caches the result.
+	want := run()
+	files := cacheFiles()
+	if len(files) != 1 {
+		t.Fatalf("wrong cache files after a miss: %#v", files)
+	}
+	if got, err := ioutil.ReadFile(files[0]); err != nil || string(got) != want {
+		t.Fatalf("wrong cached document: %s\n%s", err, got)
+	}
+
+	// A hit is recognizable by a marker in a doctored cached document.
+	marked := fmt.Sprintf(`{"format_version":%q,"marker":true}`, jsonplan.FormatVersion)
+	if err := ioutil.WriteFile(files[0], []byte(marked), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != marked {
+		t.Errorf("cached document not used\ngot:  %s\nwant: %s", got, marked)
+	}
+
+	// A document in another format version is ignored and replaced.
+	if err := ioutil.WriteFile(files[0], []byte(`{"format_version":"0.1","marker":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != want {
+		t.Errorf("cached document of another format version was used\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, err := ioutil.ReadFile(files[0]); err != nil || string(got) != want {
+		t.Errorf("cached document was not replaced: %s\n%s", err, got)
+	}
+
+	// Options that only format the output aren't part of the key, since the
+	// cached document is compact and formatted after it's read.
+	run("-json-pretty")
+	if files := cacheFiles(); len(files) != 1 {
+		t.Errorf("wrong cache files after a formatting option: %#v", files)
+	}
+
+	// Options that change the output are part of the key.
+	run("-no-sensitive")
+	if files := cacheFiles(); len(files) != 2 {
+		t.Errorf("wrong cache files after a different option: %#v", files)
+	}
+
+	t.Run("without -json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-json-cache=" + cacheDir, planPath}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_multiple(t *testing.T) {
+	statePath := testStateFile(t, testState())
+	moduleStatePath := testStateFile(t, showFixtureModuleState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	invalidPath := filepath.Join(td, "garbage")
+	if err := ioutil.WriteFile(invalidPath, []byte("\x00garbage\xff"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Color:            false,
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("human", func(t *testing.T) {
+		ui, code := run(statePath, moduleStatePath)
+		if code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		first := strings.Index(got, "==> "+statePath+" <==")
+		second := strings.Index(got, "==> "+moduleStatePath+" <==")
+		if first != 0 || second < first {
+			t.Fatalf("missing or misplaced file headers\n%s", got)
+		}
+		if !strings.Contains(got[second:], "module.db.test_instance.foo") {
+			t.Errorf("second file not shown after its header\n%s", got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui, code := run("-json", statePath, moduleStatePath)
+		if code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		var got []struct {
+			FormatVersion string `json:"format_version"`
+		}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not a JSON array: %s\n%s", err, ui.OutputWriter.String())
+		}
+		if len(got) != 2 || got[0].FormatVersion == "" || got[1].FormatVersion == "" {
+			t.Errorf("wrong documents %#v", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ui, code := run(invalidPath, statePath)
+		if code != 2 {
+			t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.ErrorWriter.String())
+		}
+		if strings.Contains(ui.OutputWriter.String(), statePath) {
+			t.Errorf("continued after the invalid file\n%s", ui.OutputWriter.String())
+		}
+	})
+}
+
+func TestShow_out(t *testing.T) {
+	statePath := testStateFile(t, testState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+
+	run := func(args ...string) (*cli.MockUi, int) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Color:            true,
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		return ui, c.Run(args)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		outPath := filepath.Join(td, "state.json")
+		if err := ioutil.WriteFile(outPath, []byte("previous content that is longer"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ui, code := run("-json", "-out="+outPath, statePath)
+		if code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		if got := ui.OutputWriter.String(); got != "" {
+			t.Errorf("unexpected output on stdout\n%s", got)
+		}
+		src, err := ioutil.ReadFile(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(src) {
+			t.Errorf("file is not valid JSON\n%s", src)
+		}
+	})
+
+	t.Run("human", func(t *testing.T) {
+		outPath := filepath.Join(td, "state.txt")
+		ui, code := run("-out="+outPath, statePath)
+		if code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		src, err := ioutil.ReadFile(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(src), "# test_instance.foo:") {
+			t.Errorf("file is missing the state\n%s", src)
+		}
+		if strings.Contains(string(src), "\x1b[") {
+			t.Errorf("file contains color codes\n%q", src)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		outPath := filepath.Join(td, "missing.json")
+		ui, code := run("-json", "-out="+outPath, filepath.Join(td, "does-not-exist"))
+		if code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.ErrorWriter.String())
+		}
+		if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+			t.Errorf("output file was written despite the error")
+		}
+		entries, err := ioutil.ReadDir(td)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.Contains(e.Name(), ".tmp") {
+				t.Errorf("temporary file %s left behind", e.Name())
+			}
+		}
+	})
+}
+
+func TestShow_planAgainstState(t *testing.T) {
+	planPath := showFixturePlanFile(t)
+	statePath := testStateFile(t, testState())
+	defer testChdir(t, testFixturePath("show"))()
+
+	ui := cli.NewMockUi()
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+	if code := c.Run([]string{"-json", "-state=" + statePath, planPath}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got struct {
+		PriorState struct {
+			Values struct {
+				RootModule struct {
+					Resources []struct {
+						Address string
+					}
+				} `json:"root_module"`
+			}
+		} `json:"prior_state"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	resources := got.PriorState.Values.RootModule.Resources
+	if len(resources) != 1 || resources[0].Address != "test_instance.foo" {
+		t.Errorf("prior state is not the given state: %#v", resources)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Showing the plan against a different state") {
+		t.Errorf("missing warning\n%s", ui.OutputWriter.String())
+	}
+
+	for name, args := range map[string][]string{
+		"state":      {"-state=" + statePath, statePath},
+		"no path":    {"-state=" + statePath},
+		"with check": {"-check", "-state=" + statePath, planPath},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_unsupportedVersion(t *testing.T) {
+	// foreignPlan returns a plan file whose plan records only the given
+	// format version and Terraform version, in protobuf wire format.
+	foreignPlan := func(formatVersion byte, tfVersion string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("tfplan")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte{0x08, formatVersion, 0x72, byte(len(tfVersion))})
+		w.Write([]byte(tfVersion))
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := map[string]struct {
+		src  []byte
+		want string
+	}{
+		"newer state": {
+			[]byte(`{"version": 99, "terraform_version": "99.0.0", "serial": 1, "lineage": "x"}`),
+			"upgrade Terraform to at least v99.0.0",
+		},
+		"newer state without terraform version": {
+			[]byte(`{"version": 99, "serial": 1, "lineage": "x"}`),
+			"uses format version 99",
+		},
+		"newer plan": {
+			foreignPlan(3, "99.0.0"),
+			"upgrade Terraform to v99.0.0",
+		},
+		"older plan": {
+			foreignPlan(3, "0.0.1"),
+			"use Terraform v0.0.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+				input: bytes.NewReader(test.src),
+			}
+
+			if code := c.Run([]string{"-"}); code != 2 {
+				t.Fatalf("wrong exit status %d; want 2\n%s", code, ui.OutputWriter.String())
+			}
+			if got := ui.ErrorWriter.String(); !strings.Contains(got, test.want) {
+				t.Errorf("error does not contain %q\n%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestShow_url(t *testing.T) {
+	planSrc, err := ioutil.ReadFile(showFixturePlanFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("wrong method %s; want GET", r.Method)
+		}
+		switch r.URL.Path {
+		case "/plan":
+			w.Write(planSrc)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer testChdir(t, testFixturePath("show"))()
+
+	tests := map[string]struct {
+		args    []string
+		want    int
+		wantErr string
+	}{
+		"plan":      {[]string{"-json", server.URL + "/plan"}, 0, ""},
+		"not found": {[]string{server.URL + "/missing"}, 1, "404 Not Found"},
+		"too large": {[]string{"-url-max-size=10", server.URL + "/plan"}, 1, "larger than the maximum of 10 bytes"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+					Ui:               ui,
+				},
+			}
+
+			if code := c.Run(test.args); code != test.want {
+				t.Fatalf("wrong exit status %d; want %d\n%s", code, test.want, ui.ErrorWriter.String())
+			}
+			if got := ui.ErrorWriter.String(); !strings.Contains(got, test.wantErr) {
+				t.Errorf("error does not contain %q\n%s", test.wantErr, got)
+			}
+			if test.want == 0 && !json.Valid(ui.OutputWriter.Bytes()) {
+				t.Errorf("output is not valid JSON\n%s", ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestShow_missingFile(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		filepath.Join(td, "does-not-exist"),
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.ErrorWriter.String())
+	}
+}
+
+func TestShow_planWithState(t *testing.T) {
+	// The prior state has test_instance.bar, which the plan destroys, to
+	// make sure that the planned state reflects the changes rather than
+	// the prior state.
+	_, snap := testModuleWithSnapshot(t, "show")
+	barAddr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_instance",
+		Name: "bar",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	providerAddr := addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance)
+	prior := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			barAddr,
+			&states.ResourceInstanceObjectSrc{
+				Status:    states.ObjectReady,
+				AttrsJSON: []byte(`{"id":"bar","ami":"old"}`),
+			},
+			providerAddr,
+		)
+	})
+	plannedVal := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.UnknownVal(cty.String),
+		"ami": cty.StringVal("new"),
+	})
+	plan := testPlan(t)
+	for _, change := range []*plans.ResourceInstanceChange{
+		{
+			Addr: addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			ProviderAddr: providerAddr,
+			Change: plans.Change{
+				Action: plans.Create,
+				Before: cty.NullVal(plannedVal.Type()),
+				After:  plannedVal,
+			},
+		},
+		{
+			Addr:         barAddr,
+			ProviderAddr: providerAddr,
+			Change: plans.Change{
+				Action: plans.Delete,
+				Before: cty.ObjectVal(map[string]cty.Value{
+					"id":  cty.StringVal("bar"),
+					"ami": cty.StringVal("old"),
+				}),
+				After: cty.NullVal(plannedVal.Type()),
+			},
+		},
+	} {
+		changeSrc, err := change.Encode(plannedVal.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan.Changes.Resources = append(plan.Changes.Resources, changeSrc)
+	}
+	planPath := testPlanFile(t, snap, prior, plan)
+
+	t.Run("human", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				Color:            false,
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-with-state", planPath}); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+
+		got := ui.OutputWriter.String()
+		sep := strings.Index(got, "Planned state after apply:")
+		if sep < 0 {
+			t.Fatalf("planned state header missing\n%s", got)
+		}
+		diff, planned := got[:sep], got[sep:]
+		if !strings.Contains(diff, "+ test_instance.foo") || !strings.Contains(diff, "- test_instance.bar") {
+			t.Errorf("diff is missing a change\n%s", diff)
+		}
+		if !strings.Contains(planned, "# test_instance.foo:") || !strings.Contains(planned, `"new"`) {
+			t.Errorf("planned state is missing test_instance.foo\n%s", planned)
+		}
+		if strings.Contains(planned, "test_instance.bar") || strings.Contains(planned, `"old"`) {
+			t.Errorf("planned state includes destroyed test_instance.bar\n%s", planned)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-with-state", "-json", planPath}); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+
+		var got struct {
+			PlannedState struct {
+				Values struct {
+					RootModule struct {
+						Resources []struct {
+							Address string
+							Values  map[string]interface{}
+						}
+					} `json:"root_module"`
+				}
+			} `json:"planned_state"`
+		}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, ui.OutputWriter.String())
+		}
+		resources := got.PlannedState.Values.RootModule.Resources
+		if len(resources) != 1 {
+			t.Fatalf("wrong number of planned resources %d; want 1\n%s", len(resources), ui.OutputWriter.String())
+		}
+		if resources[0].Address != "test_instance.foo" {
+			t.Errorf("wrong address %q", resources[0].Address)
+		}
+		if got, want := resources[0].Values["ami"], "new"; got != want {
+			t.Errorf("wrong ami %#v; want %#v", got, want)
+		}
+	})
+
+	t.Run("state", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := &ShowCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(showFixtureProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run([]string{"-with-state", testStateFile(t, testState())}); code != 1 {
+			t.Fatalf("wrong exit status %d; want 1\n%s", code, ui.ErrorWriter.String())
+		}
+	})
+}
+
+func TestUiOutputWriter(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := &uiOutputWriter{Ui: ui}
+	for _, s := range []string{"a\nb", "c\n\n", "d", ""} {
+		io.WriteString(w, s)
+	}
+	w.Close()
+
+	// The result should be the same as writing everything at once.
+	if got, want := ui.OutputWriter.String(), "a\nbc\n\nd\n"; got != want {
+		t.Errorf("wrong output\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestLoadPlanOrState(t *testing.T) {
+	plan, state, err := LoadPlanOrState(showFixturePlanFile(t))
+	if err != nil {
+		t.Fatalf("unexpected error loading plan: %s", err)
+	}
+	if plan == nil || len(plan.Changes.Resources) != 1 {
+		t.Fatalf("wrong plan %#v", plan)
+	}
+	if state == nil {
+		t.Fatal("prior state of plan is nil")
+	}
+
+	plan, state, err = LoadPlanOrState(testStateFile(t, testState()))
+	if err != nil {
+		t.Fatalf("unexpected error loading state: %s", err)
+	}
+	if plan != nil {
+		t.Fatalf("unexpected plan %#v", plan)
+	}
+	if !state.HasResources() {
+		t.Fatal("state has no resources")
+	}
+
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, "garbage")
+	if err := ioutil.WriteFile(path, []byte("\x00garbage\xff"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = LoadPlanOrState(path)
+	if err, ok := err.(*PlanOrStateError); !ok || err.PlanErr == nil || err.StateErr == nil {
+		t.Fatalf("wrong error %#v; want *PlanOrStateError with both errors", err)
+	}
+
+	if _, _, err := LoadPlanOrState(filepath.Join(td, "missing")); err == nil {
+		t.Fatal("succeeded for missing file; want error")
+	}
+}
+
+// showFixtureSchema returns a schema suitable for processing the
+// configuration in test-fixtures/show and the resources used by testState.
+// showJSONDiagnostics returns the diagnostics included in the given JSON
+// output of the show command.
+func showJSONDiagnostics(t *testing.T, src []byte) []jsonDiagnostic {
+	t.Helper()
+	var doc struct {
+		Diagnostics []jsonDiagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, src)
+	}
+	return doc.Diagnostics
+}
+
+func showFixtureSchema() *terraform.ProviderSchema {
+	return &terraform.ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":  {Type: cty.String, Optional: true, Computed: true},
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+	}
+}
+
+// showFixtureProvider returns a mock provider that is configured for basic
+// operation with the configuration in test-fixtures/show. This mock has
+// GetSchemaReturn populated so that the state and plan values can be
+// decoded.
+func showFixtureProvider() *terraform.MockProvider {
+	p := testProvider()
+	p.GetSchemaReturn = showFixtureSchema()
+	return p
+}
+
+// showFixturePlanFile creates a plan file at a temporary location containing
+// a single change to create the test_instance.foo resource, returning the
+// path to the plan file.
+func showFixturePlanFile(t *testing.T) string {
+	t.Helper()
+
+	_, snap := testModuleWithSnapshot(t, "show")
+	plannedVal := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.UnknownVal(cty.String),
+		"ami": cty.StringVal("bar"),
+	})
+	plan := testPlan(t)
+	change := &plans.ResourceInstanceChange{
+		Addr: addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_instance",
+			Name: "foo",
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+		ProviderAddr: addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		Change: plans.Change{
+			Action: plans.Create,
+			Before: cty.NullVal(plannedVal.Type()),
+			After:  plannedVal,
+		},
+	}
+	changeSrc, err := change.Encode(plannedVal.Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Changes.Resources = append(plan.Changes.Resources, changeSrc)
+
+	return testPlanFile(t, snap, states.NewState(), plan)
+}
+
+// showFixtureModuleState returns a state containing the root
+// test_instance.foo resource along with two instances of test_instance.foo
+// in module.db, and one instance each in module.db.module.replica and in
+// module.dbx.
+func showFixtureModuleState() *states.State {
+	dbAddr := addrs.RootModuleInstance.Child("db", addrs.NoKey)
+	return states.BuildState(func(s *states.SyncState) {
+		for _, mod := range []addrs.ModuleInstance{
+			dbAddr.Child("replica", addrs.NoKey),
+			addrs.RootModuleInstance.Child("dbx", addrs.NoKey),
+		} {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: "foo",
+				}.Instance(addrs.NoKey).Absolute(mod),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(fmt.Sprintf(`{"id":%q,"ami":"qux"}`, mod)),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "foo",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON: []byte(`{"id":"foo","ami":"bar"}`),
+				Status:    states.ObjectReady,
+			},
+			addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+		)
+		for i, id := range []string{"db0", "db1"} {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: "foo",
+				}.Instance(addrs.IntKey(i)).Absolute(dbAddr),
+				&states.ResourceInstanceObjectSrc{
+					AttrsJSON: []byte(fmt.Sprintf(`{"id":%q,"ami":"baz"}`, id)),
+					Status:    states.ObjectReady,
+				},
+				addrs.ProviderConfig{Type: "test"}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/hashicorp/terraform/helper/wrappedstreams"
+)
+
+// defaultShowWatchInterval is the default for the -interval option.
+const defaultShowWatchInterval = 2 * time.Second
+
+// showWatch shows the current state repeatedly, re-reading it after each
+// -interval and clearing the screen before each rendering, until interrupted,
+// and returns the exit status. A failure to show the state is reported but
+// doesn't stop the watch, since it may be only while the state is updated.
+//
+// If the output isn't to a terminal, where clearing the screen would only
+// clutter it, the state is shown just once instead.
+func (c *ShowCommand) showWatch() int {
+	terminal := c.terminal
+	if terminal == nil {
+		terminal = func() bool {
+			return isatty.IsTerminal(wrappedstreams.Stdout().Fd())
+		}
+	}
+	if !terminal() {
+		return c.show("")
+	}
+
+	for {
+		// The escape codes move the cursor to the top left of the screen
+		// and then clear it. On Windows they are interpreted by the
+		// colorable writer that the output goes through.
+		c.Ui.Output("\033[H\033[2J" + c.Colorize().Color(fmt.Sprintf(
+			"[reset][bold]Every %s:[reset] terraform show, at %s. Press Ctrl-C to stop.\n",
+			c.watchInterval, time.Now().Format("15:04:05"),
+		)))
+		c.show("")
+
+		select {
+		case <-c.ShutdownCh:
+			return 0
+		case <-time.After(c.watchInterval):
+		}
+	}
+}
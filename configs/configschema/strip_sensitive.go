@@ -0,0 +1,122 @@
+package configschema
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StripSensitive returns a copy of the given value, which must conform to the
+// type implied by the receiver, with every attribute that the receiver marks
+// as sensitive removed, including those within nested blocks.
+//
+// The result conforms to the type implied by a copy of the receiver without
+// its sensitive attributes. This is intended for producing representations
+// of objects that are safe to share; a stripped value cannot be used in place
+// of the original.
+func (b *Block) StripSensitive(val cty.Value) cty.Value {
+	return b.stripSensitive(val, b.withoutSensitive())
+}
+
+func (b *Block) stripSensitive(val cty.Value, stripped *Block) cty.Value {
+	switch {
+	case val.IsNull():
+		return cty.NullVal(stripped.ImpliedType())
+	case !val.IsKnown():
+		return cty.UnknownVal(stripped.ImpliedType())
+	}
+
+	attrs := make(map[string]cty.Value)
+	for name, attrS := range b.Attributes {
+		if attrS.Sensitive {
+			continue
+		}
+		attrs[name] = val.GetAttr(name)
+	}
+
+	strippedTy := stripped.ImpliedType()
+	for name, blockS := range b.BlockTypes {
+		strippedS := &stripped.BlockTypes[name].Block
+		blockV := val.GetAttr(name)
+		ty := blockV.Type()
+
+		switch {
+		case blockS.Nesting == NestingSingle:
+			attrs[name] = blockS.Block.stripSensitive(blockV, strippedS)
+			continue
+		case blockV.IsNull():
+			attrs[name] = cty.NullVal(strippedTy.AttributeType(name))
+			continue
+		case !blockV.IsKnown():
+			attrs[name] = cty.UnknownVal(strippedTy.AttributeType(name))
+			continue
+		case blockV.LengthInt() == 0:
+			elemTy := strippedS.ImpliedType()
+			switch {
+			case ty.IsListType():
+				attrs[name] = cty.ListValEmpty(elemTy)
+			case ty.IsSetType():
+				attrs[name] = cty.SetValEmpty(elemTy)
+			case ty.IsMapType():
+				attrs[name] = cty.MapValEmpty(elemTy)
+			default:
+				// An empty tuple or object has no element type to change.
+				attrs[name] = blockV
+			}
+			continue
+		}
+
+		// Blocks containing attributes of dynamic type are represented as
+		// tuples and objects rather than as lists and maps, since their
+		// elements may have different types.
+		var elems []cty.Value
+		elemMap := make(map[string]cty.Value)
+		for it := blockV.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			v = blockS.Block.stripSensitive(v, strippedS)
+			if ty.IsMapType() || ty.IsObjectType() {
+				elemMap[k.AsString()] = v
+			} else {
+				elems = append(elems, v)
+			}
+		}
+		switch {
+		case ty.IsListType():
+			attrs[name] = cty.ListVal(elems)
+		case ty.IsSetType():
+			attrs[name] = cty.SetVal(elems)
+		case ty.IsTupleType():
+			attrs[name] = cty.TupleVal(elems)
+		case ty.IsMapType():
+			attrs[name] = cty.MapVal(elemMap)
+		default:
+			attrs[name] = cty.ObjectVal(elemMap)
+		}
+	}
+
+	return cty.ObjectVal(attrs)
+}
+
+// withoutSensitive returns a deep copy of the receiver with any sensitive
+// attributes removed.
+func (b *Block) withoutSensitive() *Block {
+	ret := &Block{}
+
+	if b.Attributes != nil {
+		ret.Attributes = make(map[string]*Attribute, len(b.Attributes))
+	}
+	for name, attrS := range b.Attributes {
+		if !attrS.Sensitive {
+			ret.Attributes[name] = attrS
+		}
+	}
+
+	if b.BlockTypes != nil {
+		ret.BlockTypes = make(map[string]*NestedBlock, len(b.BlockTypes))
+	}
+	for name, blockS := range b.BlockTypes {
+		nested := *blockS
+		nested.Block = *blockS.Block.withoutSensitive()
+		ret.BlockTypes[name] = &nested
+	}
+
+	return ret
+}
@@ -0,0 +1,137 @@
+package configschema
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestBlockStripSensitive(t *testing.T) {
+	nested := Block{
+		Attributes: map[string]*Attribute{
+			"user":  {Type: cty.String, Optional: true},
+			"token": {Type: cty.String, Optional: true, Sensitive: true},
+		},
+	}
+	schema := &Block{
+		Attributes: map[string]*Attribute{
+			"name":     {Type: cty.String, Optional: true},
+			"password": {Type: cty.String, Optional: true, Sensitive: true},
+		},
+		BlockTypes: map[string]*NestedBlock{
+			"single": {Block: nested, Nesting: NestingSingle},
+			"list":   {Block: nested, Nesting: NestingList},
+			"set":    {Block: nested, Nesting: NestingSet},
+			"map":    {Block: nested, Nesting: NestingMap},
+		},
+	}
+	strippedNestedTy := cty.Object(map[string]cty.Type{
+		"user": cty.String,
+	})
+	cred := func(user, token string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"user":  cty.StringVal(user),
+			"token": cty.StringVal(token),
+		})
+	}
+	strippedCred := func(user string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"user": cty.StringVal(user),
+		})
+	}
+
+	tests := map[string]struct {
+		Input cty.Value
+		Want  cty.Value
+	}{
+		"null": {
+			cty.NullVal(schema.ImpliedType()),
+			cty.NullVal(schema.withoutSensitive().ImpliedType()),
+		},
+		"unknown": {
+			cty.UnknownVal(schema.ImpliedType()),
+			cty.UnknownVal(schema.withoutSensitive().ImpliedType()),
+		},
+		"populated": {
+			cty.ObjectVal(map[string]cty.Value{
+				"name":     cty.StringVal("foo"),
+				"password": cty.StringVal("hunter2"),
+				"single":   cred("a", "secret"),
+				"list":     cty.ListVal([]cty.Value{cred("b", "secret"), cred("c", "secret")}),
+				"set":      cty.SetVal([]cty.Value{cred("d", "secret")}),
+				"map":      cty.MapVal(map[string]cty.Value{"e": cred("e", "secret")}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name":   cty.StringVal("foo"),
+				"single": strippedCred("a"),
+				"list":   cty.ListVal([]cty.Value{strippedCred("b"), strippedCred("c")}),
+				"set":    cty.SetVal([]cty.Value{strippedCred("d")}),
+				"map":    cty.MapVal(map[string]cty.Value{"e": strippedCred("e")}),
+			}),
+		},
+		"empty blocks": {
+			cty.ObjectVal(map[string]cty.Value{
+				"name":     cty.NullVal(cty.String),
+				"password": cty.UnknownVal(cty.String),
+				"single":   cty.NullVal(nested.ImpliedType()),
+				"list":     cty.ListValEmpty(nested.ImpliedType()),
+				"set":      cty.UnknownVal(cty.Set(nested.ImpliedType())),
+				"map":      cty.NullVal(cty.Map(nested.ImpliedType())),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name":   cty.NullVal(cty.String),
+				"single": cty.NullVal(strippedNestedTy),
+				"list":   cty.ListValEmpty(strippedNestedTy),
+				"set":    cty.UnknownVal(cty.Set(strippedNestedTy)),
+				"map":    cty.NullVal(cty.Map(strippedNestedTy)),
+			}),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := schema.StripSensitive(test.Input)
+			if !test.Want.RawEquals(got) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestBlockStripSensitive_dynamic(t *testing.T) {
+	schema := &Block{
+		BlockTypes: map[string]*NestedBlock{
+			"list": {
+				Block: Block{
+					Attributes: map[string]*Attribute{
+						"value":  {Type: cty.DynamicPseudoType, Optional: true},
+						"secret": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+				Nesting: NestingList,
+			},
+		},
+	}
+
+	got := schema.StripSensitive(cty.ObjectVal(map[string]cty.Value{
+		"list": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"value":  cty.StringVal("a"),
+				"secret": cty.StringVal("x"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"value":  cty.True,
+				"secret": cty.StringVal("y"),
+			}),
+		}),
+	}))
+	want := cty.ObjectVal(map[string]cty.Value{
+		"list": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"value": cty.StringVal("a")}),
+			cty.ObjectVal(map[string]cty.Value{"value": cty.True}),
+		}),
+	})
+	if !want.RawEquals(got) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package plans
 
 import (
 	"sort"
+	"time"
 
 	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/configs/configschema"
@@ -25,6 +26,11 @@ type Plan struct {
 	TargetAddrs     []addrs.Targetable
 	ProviderSHA256s map[string][]byte
 	Backend         Backend
+
+	// Timestamp is the time at which the plan was created, or the zero
+	// time if it isn't known. It is recorded in the metadata of a plan
+	// file rather than as part of the plan itself.
+	Timestamp time.Time
 }
 
 // Backend represents the backend-related configuration and other data as it
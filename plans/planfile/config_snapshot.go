@@ -91,6 +91,11 @@ func readConfigSnapshot(z *zip.Reader) (*configload.Snapshot, error) {
 	}
 
 	if manifestSrc == nil {
+		if len(snap.Modules) == 0 {
+			// There's no sign of a snapshot at all, rather than a snapshot
+			// that is incomplete.
+			return nil, ErrNoConfigSnapshot
+		}
 		return nil, fmt.Errorf("config snapshot does not have manifest file")
 	}
 
@@ -123,6 +128,12 @@ func readConfigSnapshot(z *zip.Reader) (*configload.Snapshot, error) {
 		}
 	}
 
+	// The root module is where loading begins, so we can't do anything with
+	// a snapshot that doesn't include it.
+	if _, exists := snap.Modules[""]; !exists {
+		return nil, fmt.Errorf("config snapshot does not include the root module")
+	}
+
 	// Finally, we'll make sure we don't have any errant files for modules that
 	// aren't in the manifest.
 	for k := range snap.Modules {
@@ -50,3 +50,46 @@ func TestConfigSnapshotRoundtrip(t *testing.T) {
 		t.Errorf("result does not match input\nresult: %sinput: %s", spew.Sdump(snapOut), spew.Sdump(snapIn))
 	}
 }
+
+func TestReadConfigSnapshot_invalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no snapshot": {},
+		"no manifest": {
+			configSnapshotModulePrefix + "/main.tf": `resource "foo" "bar" {}`,
+		},
+		"no root module": {
+			configSnapshotManifestFile: `[]`,
+		},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			for fn, src := range files {
+				w, err := zw.Create(fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write([]byte(src)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			zw.Close()
+
+			raw := buf.Bytes()
+			zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = readConfigSnapshot(zr)
+			if err == nil {
+				t.Fatal("succeeded; want error")
+			}
+			if got, want := err == ErrNoConfigSnapshot, name == "no snapshot"; got != want {
+				t.Errorf("wrong error %q", err)
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package planfile
 
 import (
+	"archive/zip"
+	"bytes"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 
@@ -55,6 +58,7 @@ func TestRoundtrip(t *testing.T) {
 			Config:    plans.DynamicValue([]byte("config placeholder")),
 			Workspace: "default",
 		},
+		Timestamp: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
 	}
 
 	workDir, err := ioutil.TempDir("", "tf-planfile")
@@ -112,4 +116,93 @@ func TestRoundtrip(t *testing.T) {
 			t.Errorf("when reading config: %s", diags.Err())
 		}
 	})
+
+	t.Run("NewReader", func(t *testing.T) {
+		src, err := ioutil.ReadFile(planFn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr, err := NewReader(bytes.NewReader(src), int64(len(src)))
+		if err != nil {
+			t.Fatalf("failed to open in-memory plan file for reading: %s", err)
+		}
+		defer mr.Close()
+
+		planOut, err := mr.ReadPlan()
+		if err != nil {
+			t.Fatalf("failed to read plan: %s", err)
+		}
+		if !reflect.DeepEqual(planIn, planOut) {
+			t.Errorf("plan did not survive round-trip\nresult: %sinput: %s", spew.Sdump(planOut), spew.Sdump(planIn))
+		}
+	})
+}
+
+func TestNewReader_invalid(t *testing.T) {
+	tests := map[string][]byte{
+		"legacy":   []byte("tfplan\x00legacy plan contents"),
+		"not zip":  []byte("{}"),
+		"empty":    []byte{},
+		"zip only": emptyZip(t),
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewReader(bytes.NewReader(src), int64(len(src)))
+			if err == nil {
+				t.Fatal("succeeded; want error")
+			}
+			if name == "legacy" && err != errLegacyPlanFile {
+				t.Fatalf("wrong error %q; want %q", err, errLegacyPlanFile)
+			}
+		})
+	}
+}
+
+// emptyZip returns the bytes of a valid zip archive that does not contain
+// any files, and so is not a valid plan file.
+func emptyZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadPlan_noTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(tfplanFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeTfplan(&plans.Plan{
+		Changes:         plans.NewChanges(),
+		ProviderSHA256s: map[string][]byte{},
+		VariableValues:  map[string]plans.DynamicValue{},
+		Backend: plans.Backend{
+			Type:      "local",
+			Config:    plans.DynamicValue([]byte("config placeholder")),
+			Workspace: "default",
+		},
+	}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := pr.ReadPlan()
+	if err != nil {
+		t.Fatalf("failed to read plan: %s", err)
+	}
+	if !plan.Timestamp.IsZero() {
+		t.Errorf("wrong timestamp %s; want zero", plan.Timestamp)
+	}
 }
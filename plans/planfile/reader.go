@@ -3,7 +3,9 @@ package planfile
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 
 	"github.com/hashicorp/terraform/configs"
@@ -22,7 +24,12 @@ const tfstateFilename = "tfstate"
 // be used to access the individual portions of the file for further
 // processing.
 type Reader struct {
-	zip *zip.ReadCloser
+	zip *zip.Reader
+
+	// closer is the underlying file, if any, which must be closed when the
+	// caller is finished with the reader. It is nil for readers created
+	// with NewReader.
+	closer io.Closer
 }
 
 // Open creates a Reader for the file at the given filename, or returns an
@@ -34,36 +41,82 @@ func Open(filename string) (*Reader, error) {
 		// like our old plan format from versions prior to 0.12.
 		if b, sErr := ioutil.ReadFile(filename); sErr == nil {
 			if bytes.HasPrefix(b, []byte("tfplan")) {
-				return nil, fmt.Errorf("the given plan file was created by an earlier version of Terraform; plan files cannot be shared between different Terraform versions")
+				return nil, errLegacyPlanFile
+			}
+		}
+		return nil, err
+	}
+
+	if err := checkPlanFile(&r.Reader); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return &Reader{
+		zip:    &r.Reader,
+		closer: r,
+	}, nil
+}
+
+// NewReader creates a Reader for a plan file whose content is available via
+// the given io.ReaderAt, which has the given size in bytes. This is useful
+// for plan files that have already been loaded into memory, such as when
+// reading from stdin.
+//
+// The caller is responsible for closing the underlying data source, if
+// necessary, once it is finished with the returned Reader.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		// As with Open, we'll sniff to see if this looks like our old plan
+		// format from versions prior to 0.12 to give a better error message.
+		prefix := make([]byte, len("tfplan"))
+		if _, sErr := r.ReadAt(prefix, 0); sErr == nil {
+			if bytes.Equal(prefix, []byte("tfplan")) {
+				return nil, errLegacyPlanFile
 			}
 		}
 		return nil, err
 	}
 
-	// Sniff to make sure this looks like a plan file, as opposed to any other
-	// random zip file the user might have around.
+	if err := checkPlanFile(zr); err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		zip: zr,
+	}, nil
+}
+
+var errLegacyPlanFile = fmt.Errorf("the given plan file was created by an earlier version of Terraform; plan files cannot be shared between different Terraform versions")
+
+// checkPlanFile sniffs the given zip archive to make sure it looks like a
+// plan file, as opposed to any other random zip file the user might have
+// around.
+func checkPlanFile(z *zip.Reader) error {
 	var planFile *zip.File
-	for _, file := range r.File {
+	for _, file := range z.File {
 		if file.Name == tfplanFilename {
 			planFile = file
 			break
 		}
 	}
 	if planFile == nil {
-		return nil, fmt.Errorf("the given file is not a valid plan file")
+		return fmt.Errorf("the given file is not a valid plan file")
 	}
 
 	// For now, we'll just accept the presence of the tfplan file as enough,
 	// and wait to validate the version when the caller requests the plan
 	// itself.
-
-	return &Reader{
-		zip: r,
-	}, nil
+	return nil
 }
 
 // ReadPlan reads the plan embedded in the plan file.
 //
+// The plan's Timestamp is set from the modification time of the embedded
+// plan, which records when the plan file was created, or left as the zero
+// time if the file doesn't record one.
+//
 // Errors can be returned for various reasons, including if the plan file
 // is not of an appropriate format version, if it was created by a different
 // version of Terraform, if it is invalid, etc.
@@ -87,7 +140,18 @@ func (r *Reader) ReadPlan() (*plans.Plan, error) {
 	}
 	defer pr.Close()
 
-	return readTfplan(pr)
+	plan, err := readTfplan(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zip entry created without a modification time has a zero MS-DOS
+	// date, which Modified would otherwise report as a time in 1979.
+	if planFile.ModifiedDate != 0 {
+		plan.Timestamp = planFile.Modified.UTC()
+	}
+
+	return plan, nil
 }
 
 // ReadStateFile reads the state file embedded in the plan file.
@@ -107,13 +171,38 @@ func (r *Reader) ReadStateFile() (*statefile.File, error) {
 	return nil, statefile.ErrNoState
 }
 
+// UnsupportedVersionError is returned by ReadPlan when the plan was created
+// by a different version of Terraform, which may also have used a plan
+// format version that this version does not support.
+//
+// Plan files can only be used with the exact version of Terraform that
+// created them.
+type UnsupportedVersionError struct {
+	// FormatVersion is the format version of the plan.
+	FormatVersion uint64
+
+	// TerraformVersion is the version of Terraform that created the plan,
+	// or an empty string if it isn't recorded.
+	TerraformVersion string
+
+	err error
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return e.err.Error()
+}
+
+// ErrNoConfigSnapshot is returned by ReadConfigSnapshot if the plan file
+// does not contain a configuration snapshot at all.
+var ErrNoConfigSnapshot = errors.New("plan file does not contain a configuration snapshot")
+
 // ReadConfigSnapshot reads the configuration snapshot embedded in the plan
-// file.
+// file, returning ErrNoConfigSnapshot if there is none.
 //
 // This is a lower-level alternative to ReadConfig that just extracts the
 // source files, without attempting to parse them.
 func (r *Reader) ReadConfigSnapshot() (*configload.Snapshot, error) {
-	return readConfigSnapshot(&r.zip.Reader)
+	return readConfigSnapshot(r.zip)
 }
 
 // ReadConfig reads the configuration embedded in the plan file.
@@ -125,6 +214,14 @@ func (r *Reader) ReadConfig() (*configs.Config, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 
 	snap, err := r.ReadConfigSnapshot()
+	if err == ErrNoConfigSnapshot {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Plan file has no configuration",
+			"The plan file does not contain a snapshot of the configuration it was created from, so it cannot be used. Create a new plan file with \"terraform plan -out\".",
+		))
+		return nil, diags
+	}
 	if err != nil {
 		diags = diags.Append(tfdiags.Sourceless(
 			tfdiags.Error,
@@ -144,5 +241,8 @@ func (r *Reader) ReadConfig() (*configs.Config, tfdiags.Diagnostics) {
 
 // Close closes the file, after which no other operations may be performed.
 func (r *Reader) Close() error {
-	return r.zip.Close()
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
 }
@@ -42,11 +42,19 @@ func readTfplan(r io.Reader) (*plans.Plan, error) {
 	}
 
 	if rawPlan.Version != tfplanFormatVersion {
-		return nil, fmt.Errorf("unsupported plan file format version %d; only version %d is supported", rawPlan.Version, tfplanFormatVersion)
+		return nil, &UnsupportedVersionError{
+			FormatVersion:    rawPlan.Version,
+			TerraformVersion: rawPlan.TerraformVersion,
+			err:              fmt.Errorf("unsupported plan file format version %d; only version %d is supported", rawPlan.Version, tfplanFormatVersion),
+		}
 	}
 
 	if rawPlan.TerraformVersion != version.String() {
-		return nil, fmt.Errorf("plan file was created by Terraform %s, but this is %s; plan files cannot be transferred between different Terraform versions", rawPlan.TerraformVersion, version.String())
+		return nil, &UnsupportedVersionError{
+			FormatVersion:    rawPlan.Version,
+			TerraformVersion: rawPlan.TerraformVersion,
+			err:              fmt.Errorf("plan file was created by Terraform %s, but this is %s; plan files cannot be transferred between different Terraform versions", rawPlan.TerraformVersion, version.String()),
+		}
 	}
 
 	plan := &plans.Plan{
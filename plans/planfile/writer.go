@@ -30,10 +30,16 @@ func Create(filename string, configSnap *configload.Snapshot, stateFile *statefi
 
 	// tfplan file
 	{
+		// The modification time of the tfplan file records when the plan
+		// was created.
+		created := plan.Timestamp
+		if created.IsZero() {
+			created = time.Now()
+		}
 		w, err := zw.CreateHeader(&zip.FileHeader{
 			Name:     tfplanFilename,
 			Method:   zip.Deflate,
-			Modified: time.Now(),
+			Modified: created,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create tfplan file: %s", err)
@@ -17,6 +17,24 @@ import (
 // ErrNoState is returned by ReadState when the state file is empty.
 var ErrNoState = errors.New("no state")
 
+// UnsupportedVersionError is returned by Read when the state file was
+// created by a newer version of Terraform, either in a format version that
+// this version does not support or with a newer Terraform version recorded.
+type UnsupportedVersionError struct {
+	// FormatVersion is the format version of the state file.
+	FormatVersion uint64
+
+	// TerraformVersion is the version of Terraform that created the state
+	// file, or an empty string if it isn't known.
+	TerraformVersion string
+
+	err error
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return e.err.Error()
+}
+
 // Read reads a state from the given reader.
 //
 // Legacy state format versions 1 through 3 are supported, but the result will
@@ -54,6 +72,13 @@ func Read(r io.Reader) (*File, error) {
 
 	state, diags := readState(src)
 	if diags.HasErrors() {
+		if v, _ := sniffJSONStateVersion(src); v > maxFormatVersion {
+			return nil, &UnsupportedVersionError{
+				FormatVersion:    v,
+				TerraformVersion: sniffJSONStateTerraformVersion(src),
+				err:              diags.Err(),
+			}
+		}
 		return nil, diags.Err()
 	}
 
@@ -63,12 +88,16 @@ func Read(r io.Reader) (*File, error) {
 	}
 
 	if state.TerraformVersion != nil && state.TerraformVersion.GreaterThan(tfversion.SemVer) {
-		return state, fmt.Errorf(
-			"state snapshot was created by Terraform v%s, which is newer than current v%s; upgrade to Terraform v%s or greater to work with this state",
-			state.TerraformVersion,
-			tfversion.SemVer,
-			state.TerraformVersion,
-		)
+		return state, &UnsupportedVersionError{
+			FormatVersion:    maxFormatVersion,
+			TerraformVersion: state.TerraformVersion.String(),
+			err: fmt.Errorf(
+				"state snapshot was created by Terraform v%s, which is newer than current v%s; upgrade to Terraform v%s or greater to work with this state",
+				state.TerraformVersion,
+				tfversion.SemVer,
+				state.TerraformVersion,
+			),
+		}
 	}
 
 	return state, diags.Err()
@@ -199,6 +228,10 @@ func sniffJSONStateTerraformVersion(src []byte) string {
 	return sniff.Version
 }
 
+// maxFormatVersion is the newest state format version that readState can
+// read.
+const maxFormatVersion = 4
+
 // unsupportedFormat is a diagnostic summary message for when the state file
 // seems to not be a state file at all, or is not a supported version.
 //
@@ -146,10 +146,13 @@ func loadProviderSchemas(schemas map[string]*ProviderSchema, config *configs.Con
 			Provider:      resp.Provider.Block,
 			ResourceTypes: make(map[string]*configschema.Block),
 			DataSources:   make(map[string]*configschema.Block),
+
+			ResourceTypeSchemaVersions: make(map[string]uint64),
 		}
 
 		for t, r := range resp.ResourceTypes {
 			s.ResourceTypes[t] = r.Block
+			s.ResourceTypeSchemaVersions[t] = r.Version
 		}
 
 		for t, d := range resp.DataSources {
@@ -241,6 +244,8 @@ type ProviderSchema struct {
 	Provider      *configschema.Block
 	ResourceTypes map[string]*configschema.Block
 	DataSources   map[string]*configschema.Block
+
+	ResourceTypeSchemaVersions map[string]uint64
 }
 
 // SchemaForResourceAddr attempts to find a schema for the mode and type from
@@ -259,6 +264,17 @@ func (ps *ProviderSchema) SchemaForResourceAddr(addr addrs.Resource) *configsche
 	return m[addr.Type]
 }
 
+// SchemaVersionForResourceAddr returns the current schema version number
+// for the resource type of the given resource address. Data sources are not
+// versioned, so the result is always zero for data resources and for any
+// resource type whose version is unknown.
+func (ps *ProviderSchema) SchemaVersionForResourceAddr(addr addrs.Resource) uint64 {
+	if addr.Mode != addrs.ManagedResourceMode {
+		return 0
+	}
+	return ps.ResourceTypeSchemaVersions[addr.Type]
+}
+
 // ProviderSchemaRequest is used to describe to a ResourceProvider which
 // aspects of schema are required, when calling the GetSchema method.
 type ProviderSchemaRequest struct {